@@ -0,0 +1,3 @@
+package buf
+
+//go:generate buf generate --template buf.gen.local.yaml
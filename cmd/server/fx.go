@@ -10,11 +10,13 @@ import (
 	defaultauth "github.com/webitel/im-gateway-service/infra/auth/standard"
 	webiteldi "github.com/webitel/im-gateway-service/infra/client/di"
 	"github.com/webitel/im-gateway-service/infra/pubsub"
+	"github.com/webitel/im-gateway-service/infra/redis"
 	grpcsrv "github.com/webitel/im-gateway-service/infra/server/grpc"
 	httpsrv "github.com/webitel/im-gateway-service/infra/server/http"
 	"github.com/webitel/im-gateway-service/infra/tls"
 	grpchandler "github.com/webitel/im-gateway-service/internal/handler/grpc"
 	httphandler "github.com/webitel/im-gateway-service/internal/handler/http"
+	pubsubhandler "github.com/webitel/im-gateway-service/internal/handler/pubsub"
 	"github.com/webitel/im-gateway-service/internal/service"
 )
 
@@ -30,11 +32,13 @@ func NewApp(cfg *config.Config) *fx.App {
 		webiteldi.Module,
 		defaultauth.Module,
 		pubsub.Module,
+		redis.Module,
 		tls.Module,
 		service.Module,
 		grpcsrv.Module,
 		grpchandler.Module,
 		httphandler.Module,
+		pubsubhandler.Module,
 		httpsrv.Module,
 		profiler.Module,
 	)
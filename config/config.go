@@ -3,7 +3,12 @@ package config
 import (
 	"fmt"
 	"log/slog"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/pflag"
@@ -26,8 +31,15 @@ type ServiceConfig struct {
 	Addr            string             `mapstructure:"addr"`
 	Connection      appconfig.GRPCConn `mapstructure:"conn"`
 	HTTP            HTTPConfig         `mapstructure:"http"`
+	Admin           AdminConfig        `mapstructure:"admin"`
 	MaxUploadSize   int64              `mapstructure:"max_upload_size"`
 	UploadChunkSize int                `mapstructure:"upload_chunk_size"`
+	Media           MediaConfig        `mapstructure:"media"`
+	Updates         UpdatesConfig      `mapstructure:"updates"`
+	Webhooks        WebhooksConfig     `mapstructure:"webhooks"`
+	ChatActions     ChatActionsConfig  `mapstructure:"chat_actions"`
+	Events          EventsConfig       `mapstructure:"events"`
+	Push            PushConfig         `mapstructure:"push"`
 }
 
 type HTTPConfig struct {
@@ -35,12 +47,178 @@ type HTTPConfig struct {
 	VerifyCerts bool          `mapstructure:"verify_certs"`
 	TLS         appconfig.TLS `mapstructure:"tls"`
 	CORS        CORSConfig    `mapstructure:"cors"`
+	// Explorer serves an interactive API explorer at /explorer.
+	Explorer bool `mapstructure:"explorer"`
+	// ExplorerAssets is the directory holding the Swagger UI files of the
+	// explorer, swagger-ui.css and swagger-ui-bundle.js, served by the
+	// gateway itself rather than loaded from a CDN.
+	ExplorerAssets string `mapstructure:"explorer_assets"`
+	// AdvertiseURL is the base URL other replicas reach this one at. With
+	// it, upload sessions are registered in Redis and requests for them are
+	// forwarded to the replica that holds them.
+	AdvertiseURL string `mapstructure:"advertise_url"`
+}
+
+// AdminConfig enables the internal HTTP listener at Addr serving the admin
+// endpoints, such as the media usage of domains; it must not be reachable
+// from outside the cluster. Its bearer token is a secret, so it is not a
+// flag: it is read from TokenFile, or from the AdminTokenEnv environment
+// variable without one. Contact tokens carry no admin permission to guard
+// these endpoints with.
+type AdminConfig struct {
+	Addr      string `mapstructure:"addr"`
+	TokenFile string `mapstructure:"token_file"`
+}
+
+// AdminTokenEnv is the environment variable the admin token is read from
+// when no token file is configured.
+const AdminTokenEnv = "SERVICE_ADMIN_TOKEN"
+
+// Token returns the bearer token of the admin listener, empty if none is set.
+func (c AdminConfig) Token() (string, error) {
+	if c.TokenFile == "" {
+		return strings.TrimSpace(os.Getenv(AdminTokenEnv)), nil
+	}
+
+	token, err := os.ReadFile(c.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("config: service.admin.token_file: %w", err)
+	}
+
+	return strings.TrimSpace(string(token)), nil
 }
 
 type CORSConfig struct {
 	AllowedOrigins string `mapstructure:"allowed_origins"`
 }
 
+// MediaConfig controls how downloaded files may be cached and how thumbnails
+// are made. CacheControl maps a MIME family ("image", "video", ...) to the
+// Cache-Control header of its files; the "default" entry applies to all other
+// types. Images larger than ThumbnailMaxSourceSize bytes or
+// ThumbnailMaxPixels pixels are never decoded. Signed links are signed with
+// LinkSecret and disabled without one; they point at LinkBaseURL, the public
+// base URL of the gateway. Uploads follow UploadPolicy, or the
+// entry of DomainUploadPolicies keyed by their domain ID, which replaces it.
+// Upload sessions are discarded UploadTTL after their last write. On shutdown,
+// writes in progress get DrainTimeout to complete before their uploads are
+// terminated.
+type MediaConfig struct {
+	CacheControl           map[string]string       `mapstructure:"cache_control"`
+	ThumbnailMaxSourceSize int64                   `mapstructure:"thumbnail_max_source_size"`
+	ThumbnailMaxPixels     int64                   `mapstructure:"thumbnail_max_pixels"`
+	ThumbnailCacheSize     int64                   `mapstructure:"thumbnail_cache_size"`
+	LinkSecret             string                  `mapstructure:"link_secret"`
+	LinkBaseURL            string                  `mapstructure:"link_base_url"`
+	LinkTTL                time.Duration           `mapstructure:"link_ttl"`
+	LinkMaxTTL             time.Duration           `mapstructure:"link_max_ttl"`
+	UploadPolicy           UploadPolicy            `mapstructure:"upload_policy"`
+	DomainUploadPolicies   map[string]UploadPolicy `mapstructure:"domain_upload_policies"`
+	Scan                   ScanConfig              `mapstructure:"scan"`
+	Quota                  QuotaConfig             `mapstructure:"quota"`
+	UploadTTL              time.Duration           `mapstructure:"upload_ttl"`
+	DrainTimeout           time.Duration           `mapstructure:"drain_timeout"`
+}
+
+// QuotaConfig limits what each domain may upload. Its limits apply to every
+// domain without an entry in Domains, keyed by domain ID. Usage is read and
+// reset on the admin listener.
+type QuotaConfig struct {
+	QuotaLimits `mapstructure:",squash"`
+	Domains     map[string]QuotaLimits `mapstructure:"domains"`
+}
+
+// QuotaLimits are the upload limits of a domain; zero means unlimited.
+// MaxDailyBytes counts the bytes uploaded since midnight UTC.
+type QuotaLimits struct {
+	MaxBytes      int64 `mapstructure:"max_bytes"`
+	MaxFiles      int64 `mapstructure:"max_files"`
+	MaxDailyBytes int64 `mapstructure:"max_daily_bytes"`
+}
+
+// ScanConfig enables malware scanning of uploads with the clamd at ClamdAddr,
+// host:port or a unix socket path. Timeout bounds connecting, each write and
+// the wait for a verdict. Files that cannot be scanned are rejected.
+type ScanConfig struct {
+	ClamdAddr string        `mapstructure:"clamd_addr"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+// UploadPolicy restricts the files that may be uploaded. Types are MIME types
+// sniffed from the content, or families like "image/*"; extensions include
+// the dot. Empty allow lists allow anything not denied. With MatchExtension,
+// the extension of a file must name the type of its content. MaxSizes caps
+// the size of files per type or family, the most specific entry applying.
+type UploadPolicy struct {
+	AllowedTypes      []string         `mapstructure:"allowed_types"`
+	DeniedTypes       []string         `mapstructure:"denied_types"`
+	AllowedExtensions []string         `mapstructure:"allowed_extensions"`
+	DeniedExtensions  []string         `mapstructure:"denied_extensions"`
+	MatchExtension    bool             `mapstructure:"match_extension"`
+	MaxSizes          map[string]int64 `mapstructure:"max_sizes"`
+}
+
+// UpdatesConfig describes where im-thread publishes its realtime events, how
+// many undelivered updates a single subscriber may hold and how many recent
+// updates are kept per identity for reconnecting clients. Updates go to the
+// thread members read from im-thread, cached for MembersTTL.
+type UpdatesConfig struct {
+	Exchange   string        `mapstructure:"exchange"`
+	RoutingKey string        `mapstructure:"routing_key"`
+	BufferSize int           `mapstructure:"buffer_size"`
+	ReplaySize int           `mapstructure:"replay_size"`
+	ReplayTTL  time.Duration `mapstructure:"replay_ttl"`
+	PublishTTL time.Duration `mapstructure:"publish_ttl"`
+	MembersTTL time.Duration `mapstructure:"members_ttl"`
+
+	// GatewayExchange carries the updates the gateway publishes itself,
+	// such as chat actions, to the updates consumers of every replica.
+	GatewayExchange string `mapstructure:"gateway_exchange"`
+}
+
+// WebhooksConfig controls outbound delivery of bot messages to the webhook
+// URLs registered for bot contacts.
+type WebhooksConfig struct {
+	Queue          string        `mapstructure:"queue"`
+	Workers        int           `mapstructure:"workers"`
+	Timeout        time.Duration `mapstructure:"timeout"`
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	DeadLetterSize int64         `mapstructure:"dead_letter_size"`
+	AllowPrivate   bool          `mapstructure:"allow_private"`
+}
+
+// ChatActionsConfig controls typing and similar indicators: how long they are
+// shown to other members and how often a member may send one.
+type ChatActionsConfig struct {
+	TTL      time.Duration `mapstructure:"ttl"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// EventsConfig controls the domain events published for downstream
+// consumers. Events the broker refuses are kept in a bounded buffer and
+// retried; the oldest ones are dropped once it is full.
+type EventsConfig struct {
+	Exchange        string        `mapstructure:"exchange"`
+	BufferSize      int           `mapstructure:"buffer_size"`
+	RetryInterval   time.Duration `mapstructure:"retry_interval"`
+	MaxRetryBackoff time.Duration `mapstructure:"max_retry_backoff"`
+}
+
+// PushConfig controls push notifications to the registered devices of
+// members that have no realtime connection open.
+type PushConfig struct {
+	Enabled           bool          `mapstructure:"enabled"`
+	Queue             string        `mapstructure:"queue"`
+	RoutingKey        string        `mapstructure:"routing_key"`
+	DevicesExchange   string        `mapstructure:"devices_exchange"`
+	DevicesQueue      string        `mapstructure:"devices_queue"`
+	DevicesRoutingKey string        `mapstructure:"devices_routing_key"`
+	PresenceTTL       time.Duration `mapstructure:"presence_ttl"`
+	PreviewLength     int           `mapstructure:"preview_length"`
+}
+
 func LoadConfig() (*Config, error) {
 	loader := appconfig.NewLoader(appconfig.Sections{
 		Log:      true,
@@ -86,23 +264,189 @@ func registerServiceFlags() {
 	appconfig.RegisterGRPCConnFlags(pflag.CommandLine, "service.conn", true)
 
 	pflag.String("service.http.addr", "localhost:8081", "HTTP listen address")
+	pflag.String("service.http.advertise_url", "", "Base URL other replicas reach this one at, to share upload sessions; empty for a single replica")
 	pflag.Bool("service.http.verify_certs", false, "Enable TLS for HTTP")
 	pflag.String("service.http.tls.ca", "", "HTTP CA certificate path")
 	pflag.String("service.http.tls.cert", "", "HTTP certificate path")
 	pflag.String("service.http.tls.key", "", "HTTP certificate key path")
 	pflag.String("service.http.cors.allowed_origins", "*", "Allowed CORS origins")
+	pflag.Bool("service.http.explorer", false, "Serve the interactive API explorer at /explorer")
+	pflag.String("service.http.explorer_assets", "", "Directory with the Swagger UI files of the API explorer (swagger-ui-dist)")
+	pflag.String("service.admin.addr", "", "Internal HTTP listen address of the admin endpoints (empty = disabled)")
+	pflag.String("service.admin.token_file", "", "File holding the bearer token of the admin endpoints (default: "+AdminTokenEnv+" env)")
 
 	pflag.Int64("service.max_upload_size", 0, "Max upload body size in bytes (0 = unlimited)")
 	pflag.Int("service.upload_chunk_size", 4096, "Upload chunk size in bytes for streaming uploads to storage")
+	pflag.StringToString("service.media.cache_control", map[string]string{
+		"image":   "private, max-age=604800, immutable",
+		"default": "private, max-age=86400",
+	}, "Cache-Control of downloaded files per MIME family (image, video, ...), default for the rest")
+	pflag.Int64("service.media.thumbnail_max_source_size", 32<<20, "Max size in bytes of images thumbnails are made of")
+	pflag.Int64("service.media.thumbnail_max_pixels", 25_000_000, "Max width*height of images thumbnails are made of")
+	pflag.Int64("service.media.thumbnail_cache_size", 64<<20, "Bytes of thumbnails kept in memory (0 = no cache)")
+	pflag.String("service.media.link_secret", "", "HMAC secret of signed media links (empty = links disabled)")
+	pflag.String("service.media.link_base_url", "", "Public base URL of the gateway signed media links point at, e.g. https://im.example.com")
+	pflag.Duration("service.media.link_ttl", 15*time.Minute, "Default lifetime of signed media links")
+	pflag.Duration("service.media.link_max_ttl", 7*24*time.Hour, "Max lifetime of signed media links")
+	pflag.StringSlice("service.media.upload_policy.allowed_types", nil, "MIME types or families (image/*) that may be uploaded (empty = any)")
+	pflag.StringSlice("service.media.upload_policy.denied_types", []string{
+		"text/html", "application/x-msdownload", "application/x-executable", "application/x-mach-binary",
+	}, "MIME types or families that may not be uploaded")
+	pflag.StringSlice("service.media.upload_policy.allowed_extensions", nil, "File extensions that may be uploaded (empty = any)")
+	pflag.StringSlice("service.media.upload_policy.denied_extensions", []string{
+		".exe", ".dll", ".msi", ".com", ".scr", ".bat", ".cmd", ".ps1", ".vbs", ".htm", ".html", ".xhtml",
+	}, "File extensions that may not be uploaded")
+	pflag.Bool("service.media.upload_policy.match_extension", true, "Reject files whose extension does not match their content")
+	pflag.StringToInt("service.media.upload_policy.max_sizes", nil, "Max size in bytes of uploads per MIME type or family (image/*)")
+	pflag.String("service.media.scan.clamd_addr", "", "clamd address (host:port or socket path) uploads are scanned with (empty = no scanning)")
+	pflag.Duration("service.media.scan.timeout", 30*time.Second, "Timeout of clamd connections, writes and verdicts")
+	pflag.Int64("service.media.quota.max_bytes", 0, "Max bytes uploaded per domain (0 = unlimited)")
+	pflag.Int64("service.media.quota.max_files", 0, "Max files uploaded per domain (0 = unlimited)")
+	pflag.Int64("service.media.quota.max_daily_bytes", 0, "Max bytes uploaded per domain and UTC day (0 = unlimited)")
+	pflag.Duration("service.media.upload_ttl", 10*time.Minute, "How long an upload session is kept after its last write")
+	pflag.Duration("service.media.drain_timeout", 10*time.Second, "How long uploads in progress may go on after a shutdown starts (0 = none)")
+
+	pflag.String("service.updates.exchange", "im_thread.events", "Exchange im-thread publishes realtime events to")
+	pflag.String("service.updates.routing_key", "updates.#", "Routing key the updates queue is bound with")
+	pflag.String("service.updates.gateway_exchange", "im_gateway.updates", "Exchange the gateway publishes its own realtime updates to")
+	pflag.Int("service.updates.buffer_size", 64, "Max pending updates per subscriber before it is disconnected")
+	pflag.Int("service.updates.replay_size", 100, "Recent updates kept per identity for resuming streams (0 = disabled)")
+	pflag.Duration("service.updates.replay_ttl", 5*time.Minute, "How long recent updates are kept after the identity's last stream closed")
+	pflag.Duration("service.updates.publish_ttl", 30*time.Second, "Broker expiration of updates published by the gateway itself")
+	pflag.Duration("service.updates.members_ttl", 30*time.Second, "How long the members of a thread are cached to route its updates (0 = not cached)")
+
+	pflag.String("service.webhooks.queue", "im_gateway.bot_webhooks", "Queue shared by all replicas for bot webhook deliveries")
+	pflag.Int("service.webhooks.workers", 8, "Messages delivered to bot webhooks concurrently per replica")
+	pflag.Duration("service.webhooks.timeout", 10*time.Second, "Timeout of a single webhook request")
+	pflag.Int("service.webhooks.max_attempts", 6, "Delivery attempts before a webhook message is dead-lettered")
+	pflag.Duration("service.webhooks.initial_backoff", time.Second, "Delay before the first webhook retry, doubled on every attempt")
+	pflag.Duration("service.webhooks.max_backoff", time.Minute, "Upper bound of the webhook retry delay")
+	pflag.Int64("service.webhooks.dead_letter_size", 100, "Failed deliveries kept per bot")
+	pflag.Bool("service.webhooks.allow_private", false, "Allow webhook URLs resolving to loopback or private addresses")
+
+	pflag.Duration("service.chat_actions.ttl", 6*time.Second, "How long a chat action is shown to other thread members")
+	pflag.Duration("service.chat_actions.interval", 2*time.Second, "Min interval between chat actions of one member in a thread")
+	pflag.String("service.events.exchange", "im_gateway.events", "Exchange the gateway publishes its domain events to")
+	pflag.Int("service.events.buffer_size", 1000, "Max domain events kept in memory while the broker is unavailable")
+	pflag.Duration("service.events.retry_interval", time.Second, "Delay before the first retry of an unpublished domain event")
+	pflag.Duration("service.events.max_retry_backoff", 30*time.Second, "Max delay between retries of unpublished domain events")
+	pflag.Bool("service.push.enabled", false, "Send push notifications about new messages to offline members; the built-in sender only logs them")
+	pflag.String("service.push.queue", "im_gateway.push", "Queue shared by all replicas for push notifications")
+	pflag.String("service.push.routing_key", "updates.message.new", "Binding of the push queue to the updates exchange")
+	pflag.String("service.push.devices_exchange", "im_system.events", "Exchange im-auth publishes device registrations to")
+	pflag.String("service.push.devices_queue", "im_gateway.push_devices", "Queue shared by all replicas for device registrations")
+	pflag.String("service.push.devices_routing_key", "updates.device.#", "Binding of the devices queue to the devices exchange")
+	pflag.Duration("service.push.presence_ttl", 45*time.Second, "How long a realtime connection counts as online without a heartbeat")
+	pflag.Int("service.push.preview_length", 100, "Max characters of the message body shown in a notification, 0 hides it")
 }
 
 func (c *Config) validate() error {
 	if c.Service.Addr == "" {
 		return fmt.Errorf("config: service.addr is required")
 	}
+	if c.Service.Admin.Addr != "" {
+		if c.Service.Admin.Addr == c.Service.HTTP.Addr {
+			return fmt.Errorf("config: service.admin.addr must differ from service.http.addr")
+		}
+		if token, err := c.Service.Admin.Token(); err != nil {
+			return err
+		} else if token == "" {
+			return fmt.Errorf("config: service.admin.addr requires a token (use service.admin.token_file or %s env)", AdminTokenEnv)
+		}
+	}
+	if c.Service.HTTP.Explorer && c.Service.HTTP.ExplorerAssets == "" {
+		return fmt.Errorf("config: service.http.explorer_assets is required with service.http.explorer")
+	}
 	if c.Service.UploadChunkSize < minUploadChunkSize {
 		return fmt.Errorf("config: service.upload_chunk_size must be >= %d bytes (mime sniff window)", minUploadChunkSize)
 	}
+	if c.Service.Media.ThumbnailMaxSourceSize <= 0 || c.Service.Media.ThumbnailMaxPixels <= 0 {
+		return fmt.Errorf("config: service.media.thumbnail_max_source_size and service.media.thumbnail_max_pixels must be > 0")
+	}
+	if c.Service.Media.LinkTTL <= 0 || c.Service.Media.LinkMaxTTL < c.Service.Media.LinkTTL {
+		return fmt.Errorf("config: service.media.link_ttl must be > 0 and <= service.media.link_max_ttl")
+	}
+	if c.Service.Media.LinkSecret != "" {
+		base, err := url.Parse(c.Service.Media.LinkBaseURL)
+		if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+			return fmt.Errorf("config: service.media.link_base_url must be an http(s) URL with service.media.link_secret")
+		}
+	}
+	for name, policy := range c.Service.Media.DomainUploadPolicies {
+		if _, err := strconv.ParseInt(name, 10, 64); err != nil {
+			return fmt.Errorf("config: service.media.domain_upload_policies keys must be domain IDs, not %q", name)
+		}
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("config: service.media.domain_upload_policies.%s: %w", name, err)
+		}
+	}
+	if err := c.Service.Media.UploadPolicy.validate(); err != nil {
+		return fmt.Errorf("config: service.media.upload_policy: %w", err)
+	}
+	if c.Service.Media.UploadTTL <= 0 {
+		return fmt.Errorf("config: service.media.upload_ttl must be > 0")
+	}
+	if c.Service.Media.DrainTimeout < 0 {
+		return fmt.Errorf("config: service.media.drain_timeout must be >= 0")
+	}
+	if c.Service.Media.Scan.ClamdAddr != "" && c.Service.Media.Scan.Timeout <= 0 {
+		return fmt.Errorf("config: service.media.scan.timeout must be > 0")
+	}
+	for name, limits := range c.Service.Media.Quota.Domains {
+		if _, err := strconv.ParseInt(name, 10, 64); err != nil {
+			return fmt.Errorf("config: service.media.quota.domains keys must be domain IDs, not %q", name)
+		}
+		if limits.MaxBytes < 0 || limits.MaxFiles < 0 || limits.MaxDailyBytes < 0 {
+			return fmt.Errorf("config: service.media.quota.domains.%s limits must be >= 0", name)
+		}
+	}
+	if q := c.Service.Media.Quota; q.MaxBytes < 0 || q.MaxFiles < 0 || q.MaxDailyBytes < 0 {
+		return fmt.Errorf("config: service.media.quota limits must be >= 0")
+	}
+	if c.Service.Updates.Exchange == "" || c.Service.Updates.GatewayExchange == "" {
+		return fmt.Errorf("config: service.updates.exchange and service.updates.gateway_exchange are required")
+	}
+	if c.Service.Updates.BufferSize <= 0 {
+		return fmt.Errorf("config: service.updates.buffer_size must be > 0")
+	}
+	if c.Service.Updates.ReplaySize < 0 {
+		return fmt.Errorf("config: service.updates.replay_size must be >= 0")
+	}
+	if c.Service.Updates.ReplaySize > 0 && c.Service.Updates.ReplayTTL <= 0 {
+		return fmt.Errorf("config: service.updates.replay_ttl must be > 0")
+	}
+	if c.Service.Updates.MembersTTL < 0 {
+		return fmt.Errorf("config: service.updates.members_ttl must be >= 0")
+	}
+	if c.Service.Webhooks.Queue == "" {
+		return fmt.Errorf("config: service.webhooks.queue is required")
+	}
+	if c.Service.Webhooks.Workers <= 0 || c.Service.Webhooks.MaxAttempts <= 0 {
+		return fmt.Errorf("config: service.webhooks.workers and service.webhooks.max_attempts must be > 0")
+	}
+	if c.Service.Webhooks.Timeout <= 0 {
+		return fmt.Errorf("config: service.webhooks.timeout must be > 0")
+	}
+	if c.Service.Webhooks.DeadLetterSize <= 0 {
+		return fmt.Errorf("config: service.webhooks.dead_letter_size must be > 0")
+	}
+	if c.Service.ChatActions.TTL <= 0 {
+		return fmt.Errorf("config: service.chat_actions.ttl must be > 0")
+	}
+	if c.Service.Events.Exchange == "" {
+		return fmt.Errorf("config: service.events.exchange is required")
+	}
+	if c.Service.Events.BufferSize <= 0 || c.Service.Events.RetryInterval <= 0 {
+		return fmt.Errorf("config: service.events.buffer_size and service.events.retry_interval must be > 0")
+	}
+	if c.Service.Push.Enabled {
+		if c.Service.Push.Queue == "" || c.Service.Push.DevicesQueue == "" || c.Service.Push.DevicesExchange == "" {
+			return fmt.Errorf("config: service.push.queue, service.push.devices_queue and service.push.devices_exchange are required")
+		}
+	}
+	if c.Service.Push.PresenceTTL <= 0 {
+		return fmt.Errorf("config: service.push.presence_ttl must be > 0")
+	}
 	if err := appconfig.ValidateGRPCConn("service.conn", c.Service.Connection); err != nil {
 		return err
 	}
@@ -111,6 +455,11 @@ func (c *Config) validate() error {
 			return err
 		}
 	}
+	if advertise := c.Service.HTTP.AdvertiseURL; advertise != "" {
+		if u, err := url.Parse(advertise); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("config: service.http.advertise_url must be an absolute http(s) URL")
+		}
+	}
 	if c.Log.Level == "" {
 		c.Log.Level = "info"
 	}
@@ -123,11 +472,39 @@ func (c *Config) validate() error {
 	if c.Consul.Addr == "" {
 		return fmt.Errorf("config: consul.addr is required")
 	}
-	if c.Pubsub.URL == "" {
-		return fmt.Errorf("config: pubsub.url is required (use --pubsub.url or PUBSUB_URL env)")
+	if c.Pubsub.Driver == "" {
+		c.Pubsub.Driver = "amqp"
 	}
-	if !strings.HasPrefix(c.Pubsub.URL, "amqp://") && !strings.HasPrefix(c.Pubsub.URL, "amqps://") {
-		return fmt.Errorf("config: pubsub.url must start with amqp:// or amqps://")
+	switch c.Pubsub.Driver {
+	case "gochannel", "redis":
+		// In-process broker, or the Redis configured under redis.*. Redis
+		// streams cannot route: every queue reads the whole exchange stream
+		// and drops the routing keys it does not bind, so each queue added
+		// costs a full read of the stream.
+	case "amqp":
+		if c.Pubsub.URL == "" {
+			return fmt.Errorf("config: pubsub.url is required (use --pubsub.url or PUBSUB_URL env)")
+		}
+		if !strings.HasPrefix(c.Pubsub.URL, "amqp://") && !strings.HasPrefix(c.Pubsub.URL, "amqps://") {
+			return fmt.Errorf("config: pubsub.url must start with amqp:// or amqps://")
+		}
+	default:
+		return fmt.Errorf("config: pubsub.driver %q is not supported (amqp, gochannel, redis)", c.Pubsub.Driver)
+	}
+	return nil
+}
+
+func (p UploadPolicy) validate() error {
+	for typ, size := range p.MaxSizes {
+		if size <= 0 {
+			return fmt.Errorf("max_sizes.%s must be > 0", typ)
+		}
 	}
+	for _, ext := range slices.Concat(p.AllowedExtensions, p.DeniedExtensions) {
+		if !strings.HasPrefix(ext, ".") {
+			return fmt.Errorf("extension %q must start with a dot", ext)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,135 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: api/gateway/ext/v1/bot.proto
+
+// The RPCs the gateway serves itself on services whose other RPCs are
+// published in webitel/protos: their package there cannot take a service of
+// the same name.
+
+package ext
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Bots_ListDeliveries_FullMethodName = "/webitel.im.api.gateway.ext.v1.Bots/ListDeliveries"
+)
+
+// BotsClient is the client API for Bots service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Bots extends webitel.im.api.gateway.v1.Bots with the bot webhooks.
+type BotsClient interface {
+	// ListDeliveries returns the latest webhook deliveries of a bot that were
+	// dead-lettered, newest first. Only the bot and the contact that created
+	// it may list them.
+	ListDeliveries(ctx context.Context, in *ListDeliveriesRequest, opts ...grpc.CallOption) (*ListDeliveriesResponse, error)
+}
+
+type botsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBotsClient(cc grpc.ClientConnInterface) BotsClient {
+	return &botsClient{cc}
+}
+
+func (c *botsClient) ListDeliveries(ctx context.Context, in *ListDeliveriesRequest, opts ...grpc.CallOption) (*ListDeliveriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDeliveriesResponse)
+	err := c.cc.Invoke(ctx, Bots_ListDeliveries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BotsServer is the server API for Bots service.
+// All implementations must embed UnimplementedBotsServer
+// for forward compatibility.
+//
+// Bots extends webitel.im.api.gateway.v1.Bots with the bot webhooks.
+type BotsServer interface {
+	// ListDeliveries returns the latest webhook deliveries of a bot that were
+	// dead-lettered, newest first. Only the bot and the contact that created
+	// it may list them.
+	ListDeliveries(context.Context, *ListDeliveriesRequest) (*ListDeliveriesResponse, error)
+	mustEmbedUnimplementedBotsServer()
+}
+
+// UnimplementedBotsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBotsServer struct{}
+
+func (UnimplementedBotsServer) ListDeliveries(context.Context, *ListDeliveriesRequest) (*ListDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeliveries not implemented")
+}
+func (UnimplementedBotsServer) mustEmbedUnimplementedBotsServer() {}
+func (UnimplementedBotsServer) testEmbeddedByValue()              {}
+
+// UnsafeBotsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BotsServer will
+// result in compilation errors.
+type UnsafeBotsServer interface {
+	mustEmbedUnimplementedBotsServer()
+}
+
+func RegisterBotsServer(s grpc.ServiceRegistrar, srv BotsServer) {
+	// If the following call pancis, it indicates UnimplementedBotsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Bots_ServiceDesc, srv)
+}
+
+func _Bots_ListDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BotsServer).ListDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bots_ListDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BotsServer).ListDeliveries(ctx, req.(*ListDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Bots_ServiceDesc is the grpc.ServiceDesc for Bots service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Bots_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "webitel.im.api.gateway.ext.v1.Bots",
+	HandlerType: (*BotsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDeliveries",
+			Handler:    _Bots_ListDeliveries_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/gateway/ext/v1/bot.proto",
+}
@@ -0,0 +1,135 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: api/gateway/ext/v1/message.proto
+
+package ext
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Message_SendChatAction_FullMethodName = "/webitel.im.api.gateway.ext.v1.Message/SendChatAction"
+)
+
+// MessageClient is the client API for Message service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Message extends webitel.im.api.gateway.v1.Message with the messages the
+// gateway relays without storing them.
+type MessageClient interface {
+	// SendChatAction tells the other members of a thread what the caller is
+	// doing. The action is not stored: it reaches the members subscribed right
+	// now and expires shortly after. Clients repeat it while the action lasts,
+	// at most once per throttling interval.
+	SendChatAction(ctx context.Context, in *SendChatActionRequest, opts ...grpc.CallOption) (*SendChatActionResponse, error)
+}
+
+type messageClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMessageClient(cc grpc.ClientConnInterface) MessageClient {
+	return &messageClient{cc}
+}
+
+func (c *messageClient) SendChatAction(ctx context.Context, in *SendChatActionRequest, opts ...grpc.CallOption) (*SendChatActionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendChatActionResponse)
+	err := c.cc.Invoke(ctx, Message_SendChatAction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MessageServer is the server API for Message service.
+// All implementations must embed UnimplementedMessageServer
+// for forward compatibility.
+//
+// Message extends webitel.im.api.gateway.v1.Message with the messages the
+// gateway relays without storing them.
+type MessageServer interface {
+	// SendChatAction tells the other members of a thread what the caller is
+	// doing. The action is not stored: it reaches the members subscribed right
+	// now and expires shortly after. Clients repeat it while the action lasts,
+	// at most once per throttling interval.
+	SendChatAction(context.Context, *SendChatActionRequest) (*SendChatActionResponse, error)
+	mustEmbedUnimplementedMessageServer()
+}
+
+// UnimplementedMessageServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMessageServer struct{}
+
+func (UnimplementedMessageServer) SendChatAction(context.Context, *SendChatActionRequest) (*SendChatActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendChatAction not implemented")
+}
+func (UnimplementedMessageServer) mustEmbedUnimplementedMessageServer() {}
+func (UnimplementedMessageServer) testEmbeddedByValue()                 {}
+
+// UnsafeMessageServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MessageServer will
+// result in compilation errors.
+type UnsafeMessageServer interface {
+	mustEmbedUnimplementedMessageServer()
+}
+
+func RegisterMessageServer(s grpc.ServiceRegistrar, srv MessageServer) {
+	// If the following call pancis, it indicates UnimplementedMessageServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Message_ServiceDesc, srv)
+}
+
+func _Message_SendChatAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendChatActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServer).SendChatAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Message_SendChatAction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServer).SendChatAction(ctx, req.(*SendChatActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Message_ServiceDesc is the grpc.ServiceDesc for Message service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Message_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "webitel.im.api.gateway.ext.v1.Message",
+	HandlerType: (*MessageServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendChatAction",
+			Handler:    _Message_SendChatAction_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/gateway/ext/v1/message.proto",
+}
@@ -0,0 +1,133 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: api/gateway/ext/v1/thread.proto
+
+package ext
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ThreadManagement_ListReadPositions_FullMethodName = "/webitel.im.api.gateway.ext.v1.ThreadManagement/ListReadPositions"
+)
+
+// ThreadManagementClient is the client API for ThreadManagement service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ThreadManagement extends webitel.im.api.gateway.v1.ThreadManagement with
+// the thread state the gateway keeps itself.
+type ThreadManagementClient interface {
+	// ListReadPositions reports how far every member of a thread has read.
+	// Members that have not read anything yet are left out. Only the members
+	// of the thread may list them.
+	ListReadPositions(ctx context.Context, in *ListReadPositionsRequest, opts ...grpc.CallOption) (*ListReadPositionsResponse, error)
+}
+
+type threadManagementClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewThreadManagementClient(cc grpc.ClientConnInterface) ThreadManagementClient {
+	return &threadManagementClient{cc}
+}
+
+func (c *threadManagementClient) ListReadPositions(ctx context.Context, in *ListReadPositionsRequest, opts ...grpc.CallOption) (*ListReadPositionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListReadPositionsResponse)
+	err := c.cc.Invoke(ctx, ThreadManagement_ListReadPositions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ThreadManagementServer is the server API for ThreadManagement service.
+// All implementations must embed UnimplementedThreadManagementServer
+// for forward compatibility.
+//
+// ThreadManagement extends webitel.im.api.gateway.v1.ThreadManagement with
+// the thread state the gateway keeps itself.
+type ThreadManagementServer interface {
+	// ListReadPositions reports how far every member of a thread has read.
+	// Members that have not read anything yet are left out. Only the members
+	// of the thread may list them.
+	ListReadPositions(context.Context, *ListReadPositionsRequest) (*ListReadPositionsResponse, error)
+	mustEmbedUnimplementedThreadManagementServer()
+}
+
+// UnimplementedThreadManagementServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedThreadManagementServer struct{}
+
+func (UnimplementedThreadManagementServer) ListReadPositions(context.Context, *ListReadPositionsRequest) (*ListReadPositionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReadPositions not implemented")
+}
+func (UnimplementedThreadManagementServer) mustEmbedUnimplementedThreadManagementServer() {}
+func (UnimplementedThreadManagementServer) testEmbeddedByValue()                          {}
+
+// UnsafeThreadManagementServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ThreadManagementServer will
+// result in compilation errors.
+type UnsafeThreadManagementServer interface {
+	mustEmbedUnimplementedThreadManagementServer()
+}
+
+func RegisterThreadManagementServer(s grpc.ServiceRegistrar, srv ThreadManagementServer) {
+	// If the following call pancis, it indicates UnimplementedThreadManagementServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ThreadManagement_ServiceDesc, srv)
+}
+
+func _ThreadManagement_ListReadPositions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReadPositionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThreadManagementServer).ListReadPositions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ThreadManagement_ListReadPositions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThreadManagementServer).ListReadPositions(ctx, req.(*ListReadPositionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ThreadManagement_ServiceDesc is the grpc.ServiceDesc for ThreadManagement service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ThreadManagement_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "webitel.im.api.gateway.ext.v1.ThreadManagement",
+	HandlerType: (*ThreadManagementServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListReadPositions",
+			Handler:    _ThreadManagement_ListReadPositions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/gateway/ext/v1/thread.proto",
+}
@@ -0,0 +1,140 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: api/gateway/v1/updates.proto
+
+package api
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Updates_Subscribe_FullMethodName = "/webitel.im.api.gateway.v1.Updates/Subscribe"
+)
+
+// UpdatesClient is the client API for Updates service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Updates streams the realtime events of the threads the caller is a member
+// of: new messages, read receipts, member changes, variables and chat
+// actions, the latter sent with Message.SendChatAction.
+type UpdatesClient interface {
+	// Subscribe streams the updates of the authenticated identity until the
+	// client cancels it. The stream ends with UNAVAILABLE when the server
+	// shuts down and RESOURCE_EXHAUSTED when the client falls too far behind;
+	// clients reconnect with the cursor of the last update they received.
+	Subscribe(ctx context.Context, in *SubscribeUpdatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Update], error)
+}
+
+type updatesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUpdatesClient(cc grpc.ClientConnInterface) UpdatesClient {
+	return &updatesClient{cc}
+}
+
+func (c *updatesClient) Subscribe(ctx context.Context, in *SubscribeUpdatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Update], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Updates_ServiceDesc.Streams[0], Updates_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeUpdatesRequest, Update]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Updates_SubscribeClient = grpc.ServerStreamingClient[Update]
+
+// UpdatesServer is the server API for Updates service.
+// All implementations must embed UnimplementedUpdatesServer
+// for forward compatibility.
+//
+// Updates streams the realtime events of the threads the caller is a member
+// of: new messages, read receipts, member changes, variables and chat
+// actions, the latter sent with Message.SendChatAction.
+type UpdatesServer interface {
+	// Subscribe streams the updates of the authenticated identity until the
+	// client cancels it. The stream ends with UNAVAILABLE when the server
+	// shuts down and RESOURCE_EXHAUSTED when the client falls too far behind;
+	// clients reconnect with the cursor of the last update they received.
+	Subscribe(*SubscribeUpdatesRequest, grpc.ServerStreamingServer[Update]) error
+	mustEmbedUnimplementedUpdatesServer()
+}
+
+// UnimplementedUpdatesServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUpdatesServer struct{}
+
+func (UnimplementedUpdatesServer) Subscribe(*SubscribeUpdatesRequest, grpc.ServerStreamingServer[Update]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedUpdatesServer) mustEmbedUnimplementedUpdatesServer() {}
+func (UnimplementedUpdatesServer) testEmbeddedByValue()                 {}
+
+// UnsafeUpdatesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UpdatesServer will
+// result in compilation errors.
+type UnsafeUpdatesServer interface {
+	mustEmbedUnimplementedUpdatesServer()
+}
+
+func RegisterUpdatesServer(s grpc.ServiceRegistrar, srv UpdatesServer) {
+	// If the following call pancis, it indicates UnimplementedUpdatesServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Updates_ServiceDesc, srv)
+}
+
+func _Updates_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeUpdatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UpdatesServer).Subscribe(m, &grpc.GenericServerStream[SubscribeUpdatesRequest, Update]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Updates_SubscribeServer = grpc.ServerStreamingServer[Update]
+
+// Updates_ServiceDesc is the grpc.ServiceDesc for Updates service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Updates_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "webitel.im.api.gateway.v1.Updates",
+	HandlerType: (*UpdatesServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Updates_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/gateway/v1/updates.proto",
+}
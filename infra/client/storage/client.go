@@ -108,6 +108,15 @@ func (c *Client) GetUploadInfo(ctx context.Context, uploadID string) (int64, err
 	}
 }
 
+// DeleteFiles removes stored files by their IDs.
+func (c *Client) DeleteFiles(ctx context.Context, ids ...int64) error {
+	return c.rpc.Execute(ctx, func(api storagev1.FileServiceClient) error {
+		_, err := api.DeleteFiles(ctx, &storagev1.DeleteFilesRequest{Id: ids})
+
+		return err
+	})
+}
+
 func (c *Client) Close() error {
 	if c.rpc != nil {
 		return c.rpc.Close()
@@ -2,10 +2,12 @@ package amqp
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill-amqp/v3/pkg/amqp"
 	"github.com/ThreeDotsLabs/watermill/message"
+	amqp091 "github.com/rabbitmq/amqp091-go"
 	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
 )
 
@@ -35,7 +37,7 @@ func (f *Factory) BuildSubscriber(name string, subConfig *factory.SubscriberConf
 		Connection: amqp.ConnectionConfig{
 			AmqpURI: f.url,
 		},
-		Marshaler: amqp.DefaultMarshaler{},
+		Marshaler: routingKeyMarshaler{},
 		Exchange: amqp.ExchangeConfig{
 			GenerateName: func(s string) string {
 				return subConfig.Exchange.Name
@@ -47,7 +49,8 @@ func (f *Factory) BuildSubscriber(name string, subConfig *factory.SubscriberConf
 			GenerateName: func(s string) string {
 				return subConfig.Queue
 			},
-			Durable: true,
+			Durable:    true,
+			AutoDelete: subConfig.AutoDelete,
 		},
 		QueueBind: amqp.QueueBindConfig{
 			GenerateRoutingKey: func(s string) string {
@@ -64,11 +67,23 @@ func (f *Factory) BuildSubscriber(name string, subConfig *factory.SubscriberConf
 }
 
 func (f *Factory) BuildPublisher(pubConfig *factory.PublisherConfig) (message.Publisher, error) {
+	marshaler := amqp.DefaultMarshaler{
+		NotPersistentDeliveryMode: pubConfig.Transient,
+	}
+	if pubConfig.TTL > 0 {
+		expiration := strconv.FormatInt(pubConfig.TTL.Milliseconds(), 10)
+		marshaler.PostprocessPublishing = func(p amqp091.Publishing) amqp091.Publishing {
+			p.Expiration = expiration
+
+			return p
+		}
+	}
+
 	conf := amqp.Config{
 		Connection: amqp.ConnectionConfig{
 			AmqpURI: f.url,
 		},
-		Marshaler: amqp.DefaultMarshaler{},
+		Marshaler: marshaler,
 		Exchange: amqp.ExchangeConfig{
 			GenerateName: func(s string) string {
 				return pubConfig.Exchange.Name
@@ -85,3 +100,19 @@ func (f *Factory) BuildPublisher(pubConfig *factory.PublisherConfig) (message.Pu
 	}
 	return amqp.NewPublisher(conf, f.logger)
 }
+
+// routingKeyMarshaler exposes the routing key of consumed messages under
+// factory.RoutingKeyMetadata.
+type routingKeyMarshaler struct {
+	amqp.DefaultMarshaler
+}
+
+func (m routingKeyMarshaler) Unmarshal(delivery amqp091.Delivery) (*message.Message, error) {
+	msg, err := m.DefaultMarshaler.Unmarshal(delivery)
+	if err != nil {
+		return nil, err
+	}
+	msg.Metadata.Set(factory.RoutingKeyMetadata, delivery.RoutingKey)
+
+	return msg, nil
+}
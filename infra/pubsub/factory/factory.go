@@ -1,9 +1,16 @@
 package factory
 
 import (
+	"strings"
+	"time"
+
 	"github.com/ThreeDotsLabs/watermill/message"
 )
 
+// RoutingKeyMetadata is the metadata key subscribers store the routing key
+// of a consumed message under.
+const RoutingKeyMetadata = "routing_key"
+
 type SubscriberFactory interface {
 	BuildSubscriber(name string, config *SubscriberConfig) (message.Subscriber, error)
 }
@@ -33,8 +40,52 @@ type SubscriberConfig struct {
 	Queue             string
 	ExclusiveConsumer bool
 	RoutingKey        string
+	// AutoDelete removes the queue once its last consumer is gone. Used for
+	// per-instance queues that must not outlive the process.
+	AutoDelete bool
 }
 
 type PublisherConfig struct {
 	Exchange ExchangeConfig
+	// Transient messages are not persisted by the broker. Used for
+	// ephemeral events that are worthless after a restart.
+	Transient bool
+	// TTL, when set, expires messages not consumed in time.
+	TTL time.Duration
+}
+
+// MatchRoutingKey reports whether routingKey matches an AMQP topic binding
+// pattern, where "*" stands for exactly one dot-separated word and "#" for
+// zero or more words.
+func MatchRoutingKey(pattern, routingKey string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(routingKey, "."))
+}
+
+func matchWords(pattern, key []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case "#":
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchWords(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+
+			return false
+		case "*":
+			if len(key) == 0 {
+				return false
+			}
+		default:
+			if len(key) == 0 || key[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, key = pattern[1:], key[1:]
+	}
+
+	return len(key) == 0
 }
@@ -0,0 +1,23 @@
+package factory
+
+import "testing"
+
+func TestMatchRoutingKey(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"updates.#", "updates.message.new", true},
+		{"updates.#", "updates", true},
+		{"updates.*", "updates.message.new", false},
+		{"updates.*.new", "updates.message.new", true},
+		{"#.new", "updates.message.new", true},
+		{"updates.message.new", "updates.message.read", false},
+		{"#", "anything.at.all", true},
+	}
+	for _, tt := range tests {
+		if got := MatchRoutingKey(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("MatchRoutingKey(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
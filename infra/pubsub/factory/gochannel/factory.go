@@ -0,0 +1,201 @@
+// Package gochannel is an in-process factory.Factory for local runs and
+// tests. It mimics AMQP topic exchanges: subscribers bind named queues to an
+// exchange with a routing key pattern, and every message published to the
+// exchange is copied to each queue with a matching binding. Consumers of the
+// same queue share its messages, each going to one of them; they are handed
+// out one at a time, the next once the previous one is acked.
+//
+// Nothing survives a restart, message TTLs are ignored and a message
+// published before its queue is consumed is lost.
+package gochannel
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
+)
+
+// outputBuffer is the number of messages a queue holds for its consumer.
+const outputBuffer = 1024
+
+var _ factory.Factory = (*Factory)(nil)
+
+type binding struct {
+	pattern string
+	queue   string
+}
+
+// queue is the subscription of every consumer of a queue.
+type queue struct {
+	messages <-chan *message.Message
+	cancel   context.CancelFunc
+	refs     int
+}
+
+type Factory struct {
+	pubsub *gochannel.GoChannel
+
+	mu       sync.RWMutex
+	bindings map[string][]binding // exchange -> bindings
+	queues   map[string]*queue
+}
+
+func NewFactory(logger watermill.LoggerAdapter) *Factory {
+	return &Factory{
+		pubsub: gochannel.NewGoChannel(gochannel.Config{
+			OutputChannelBuffer: outputBuffer,
+		}, logger),
+		bindings: make(map[string][]binding),
+		queues:   make(map[string]*queue),
+	}
+}
+
+// BuildSubscriber binds subConfig.Queue to the exchange. The topic the
+// subscriber is later asked for is ignored: it always consumes its queue.
+func (f *Factory) BuildSubscriber(_ string, subConfig *factory.SubscriberConfig) (message.Subscriber, error) {
+	if subConfig == nil {
+		return nil, fmt.Errorf("no subscriber configured")
+	}
+	if subConfig.Queue == "" {
+		return nil, fmt.Errorf("gochannel: queue name is required")
+	}
+
+	b := binding{pattern: subConfig.RoutingKey, queue: subConfig.Queue}
+
+	f.mu.Lock()
+	exchange := subConfig.Exchange.Name
+	if !slices.Contains(f.bindings[exchange], b) {
+		f.bindings[exchange] = append(f.bindings[exchange], b)
+	}
+	f.mu.Unlock()
+
+	return &subscriber{factory: f, queue: subConfig.Queue}, nil
+}
+
+// BuildPublisher returns a publisher to pubConfig.Exchange. The topic passed
+// to Publish is the routing key.
+func (f *Factory) BuildPublisher(pubConfig *factory.PublisherConfig) (message.Publisher, error) {
+	if pubConfig == nil {
+		return nil, fmt.Errorf("no publisher configured")
+	}
+
+	return &publisher{factory: f, exchange: pubConfig.Exchange.Name}, nil
+}
+
+// route returns the queues of exchange bound to routingKey, each once.
+func (f *Factory) route(exchange, routingKey string) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var queues []string
+	for _, b := range f.bindings[exchange] {
+		if factory.MatchRoutingKey(b.pattern, routingKey) && !slices.Contains(queues, b.queue) {
+			queues = append(queues, b.queue)
+		}
+	}
+
+	return queues
+}
+
+// consume returns the messages of the named queue until ctx is done. The
+// queue is subscribed to while it has consumers.
+func (f *Factory) consume(ctx context.Context, name string) (<-chan *message.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	q, ok := f.queues[name]
+	if !ok {
+		queueCtx, cancel := context.WithCancel(context.Background())
+		messages, err := f.pubsub.Subscribe(queueCtx, queueTopic(name))
+		if err != nil {
+			cancel()
+
+			return nil, err
+		}
+		q = &queue{messages: messages, cancel: cancel}
+		f.queues[name] = q
+	}
+	q.refs++
+
+	go func() {
+		<-ctx.Done()
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if q.refs--; q.refs == 0 {
+			q.cancel()
+			delete(f.queues, name)
+		}
+	}()
+
+	return q.messages, nil
+}
+
+type publisher struct {
+	factory  *Factory
+	exchange string
+}
+
+func (p *publisher) Publish(routingKey string, messages ...*message.Message) error {
+	for _, queue := range p.factory.route(p.exchange, routingKey) {
+		copies := make([]*message.Message, 0, len(messages))
+		for _, msg := range messages {
+			c := msg.Copy()
+			c.Metadata.Set(factory.RoutingKeyMetadata, routingKey)
+			copies = append(copies, c)
+		}
+
+		if err := p.factory.pubsub.Publish(queueTopic(queue), copies...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *publisher) Close() error {
+	return nil
+}
+
+type subscriber struct {
+	factory *Factory
+	queue   string
+
+	mu      sync.Mutex
+	cancels []context.CancelFunc
+}
+
+func (s *subscriber) Subscribe(ctx context.Context, _ string) (<-chan *message.Message, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	s.mu.Lock()
+	s.cancels = append(s.cancels, cancel)
+	s.mu.Unlock()
+
+	return s.factory.consume(ctx, s.queue)
+}
+
+// Close ends every subscription, closing their channels.
+func (s *subscriber) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, cancel := range s.cancels {
+		cancel()
+	}
+	s.cancels = nil
+
+	return nil
+}
+
+func queueTopic(queue string) string {
+	return "queue." + queue
+}
@@ -0,0 +1,97 @@
+package gochannel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
+)
+
+func subscribe(t *testing.T, f *Factory, queue, routingKey string) <-chan *message.Message {
+	t.Helper()
+
+	sub, err := f.BuildSubscriber(queue, &factory.SubscriberConfig{
+		Exchange:   factory.ExchangeConfig{Name: "events"},
+		Queue:      queue,
+		RoutingKey: routingKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sub.Close() })
+
+	messages, err := sub.Subscribe(context.Background(), "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return messages
+}
+
+func TestFactory_RoutesToMatchingQueues(t *testing.T) {
+	f := NewFactory(watermill.NopLogger{})
+
+	all := subscribe(t, f, "all", "updates.#")
+	reads := subscribe(t, f, "reads", "updates.message.read")
+
+	pub, err := f.BuildPublisher(&factory.PublisherConfig{Exchange: factory.ExchangeConfig{Name: "events"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Publish("updates.message.new", message.NewMessage("m1", []byte("new"))); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-all:
+		if got := msg.Metadata.Get(factory.RoutingKeyMetadata); got != "updates.message.new" {
+			t.Errorf("routing key = %q", got)
+		}
+		msg.Ack()
+	case <-time.After(time.Second):
+		t.Fatal("wildcard queue got nothing")
+	}
+
+	select {
+	case msg := <-reads:
+		t.Errorf("reads queue got %s", msg.UUID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFactory_ConsumersShareQueue(t *testing.T) {
+	f := NewFactory(watermill.NopLogger{})
+
+	first := subscribe(t, f, "shared", "updates.#")
+	second := subscribe(t, f, "shared", "updates.#")
+
+	pub, err := f.BuildPublisher(&factory.PublisherConfig{Exchange: factory.ExchangeConfig{Name: "events"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Publish("updates.message.new", message.NewMessage("m1", []byte("new"))); err != nil {
+		t.Fatal(err)
+	}
+
+	received := 0
+	timeout := time.After(100 * time.Millisecond)
+	for done := false; !done; {
+		select {
+		case msg := <-first:
+			received++
+			msg.Ack()
+		case msg := <-second:
+			received++
+			msg.Ack()
+		case <-timeout:
+			done = true
+		}
+	}
+	if received != 1 {
+		t.Errorf("the consumers of a queue got %d copies of a message, want 1", received)
+	}
+}
@@ -0,0 +1,503 @@
+// Package redisstream is a factory.Factory backed by Redis Streams, for
+// installations that run without RabbitMQ.
+//
+// Every exchange is a stream of the same name, and the routing key a message
+// is published with is stored in its entry. A subscriber queue is a consumer
+// group on the exchange stream: replicas consuming the same queue share its
+// entries, and each queue gets every entry once. Streams cannot route, so a
+// consumer reads all entries of its group and acknowledges, without
+// delivering, those that do not match the routing key of the queue. Every
+// queue thus reads the whole stream: the cost of publishing grows with the
+// number of queues, not with the number that bind the routing key.
+//
+// Groups are created at the end of the stream, so entries published before
+// a queue is first consumed are not delivered to it. Entries left pending by
+// a consumer that went away are claimed by the other consumers of the group.
+//
+// The group of an AutoDelete queue is destroyed when its subscriber closes.
+// Such groups are recorded in a set next to the stream, so that the groups of
+// replicas that crashed, whose consumers all stay idle, can be found and
+// destroyed by the next AutoDelete subscriber of the stream. A consumer whose
+// group was destroyed while it was still reading creates it again.
+package redisstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
+)
+
+const (
+	// maxStreamLength caps every stream, approximately: the oldest entries
+	// are trimmed as new ones are added.
+	maxStreamLength = 100_000
+	// readCount is the number of entries read or claimed at once.
+	readCount = 64
+	// blockTimeout bounds a blocking read, so a closed subscriber notices
+	// in time.
+	blockTimeout = time.Second
+	// claimInterval is how often pending entries of other consumers are
+	// checked, and claimMinIdle how long they must have been pending to be
+	// taken over.
+	claimInterval = 30 * time.Second
+	claimMinIdle  = time.Minute
+	// retryDelay is the pause after a failed read and before a nacked
+	// message is delivered again.
+	retryDelay = time.Second
+	// staleGroupIdle is how long every consumer of an AutoDelete group must
+	// have been idle for the group to be destroyed. Live consumers read the
+	// stream every blockTimeout.
+	staleGroupIdle = 10 * time.Minute
+)
+
+// Stream entry fields.
+const (
+	fieldUUID       = "uuid"
+	fieldPayload    = "payload"
+	fieldMetadata   = "metadata"
+	fieldRoutingKey = "routing_key"
+	fieldExpiresAt  = "expires_at"
+)
+
+var _ factory.Factory = (*Factory)(nil)
+
+type Factory struct {
+	client *redis.Client
+	logger watermill.LoggerAdapter
+}
+
+func NewFactory(client *redis.Client, logger watermill.LoggerAdapter) *Factory {
+	return &Factory{client: client, logger: logger}
+}
+
+// BuildSubscriber returns a subscriber consuming subConfig.Queue, the
+// consumer group of the exchange stream. The topic the subscriber is later
+// asked for is ignored.
+func (f *Factory) BuildSubscriber(name string, subConfig *factory.SubscriberConfig) (message.Subscriber, error) {
+	if subConfig == nil {
+		return nil, fmt.Errorf("no subscriber configured")
+	}
+	if subConfig.Queue == "" {
+		return nil, fmt.Errorf("redisstream: queue name is required")
+	}
+
+	return &subscriber{
+		client:     f.client,
+		logger:     f.logger.With(watermill.LogFields{"subscriber": name, "queue": subConfig.Queue}),
+		stream:     subConfig.Exchange.Name,
+		group:      subConfig.Queue,
+		routingKey: subConfig.RoutingKey,
+		autoDelete: subConfig.AutoDelete,
+		closing:    make(chan struct{}),
+	}, nil
+}
+
+// BuildPublisher returns a publisher to the pubConfig.Exchange stream. The
+// topic passed to Publish is the routing key.
+func (f *Factory) BuildPublisher(pubConfig *factory.PublisherConfig) (message.Publisher, error) {
+	if pubConfig == nil {
+		return nil, fmt.Errorf("no publisher configured")
+	}
+
+	return &publisher{client: f.client, stream: pubConfig.Exchange.Name, ttl: pubConfig.TTL}, nil
+}
+
+type publisher struct {
+	client *redis.Client
+	stream string
+	ttl    time.Duration
+}
+
+func (p *publisher) Publish(routingKey string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return fmt.Errorf("redisstream: marshal metadata of %s: %w", msg.UUID, err)
+		}
+
+		values := map[string]any{
+			fieldUUID:       msg.UUID,
+			fieldPayload:    []byte(msg.Payload),
+			fieldMetadata:   metadata,
+			fieldRoutingKey: routingKey,
+		}
+		if p.ttl > 0 {
+			values[fieldExpiresAt] = time.Now().Add(p.ttl).UnixMilli()
+		}
+
+		err = p.client.XAdd(msg.Context(), &redis.XAddArgs{
+			Stream: p.stream,
+			MaxLen: maxStreamLength,
+			Approx: true,
+			Values: values,
+		}).Err()
+		if err != nil {
+			return fmt.Errorf("redisstream: publish %s to %s: %w", msg.UUID, p.stream, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *publisher) Close() error {
+	return nil
+}
+
+type subscriber struct {
+	client     *redis.Client
+	logger     watermill.LoggerAdapter
+	stream     string
+	group      string
+	routingKey string
+	autoDelete bool
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	wg        sync.WaitGroup
+}
+
+func (s *subscriber) Subscribe(ctx context.Context, _ string) (<-chan *message.Message, error) {
+	select {
+	case <-s.closing:
+		return nil, fmt.Errorf("redisstream: subscriber is closed")
+	default:
+	}
+
+	consumer := consumerName()
+	if err := s.createGroup(ctx, consumer); err != nil {
+		return nil, fmt.Errorf("redisstream: create group %s on %s: %w", s.group, s.stream, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan *message.Message)
+
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+
+		select {
+		case <-s.closing:
+		case <-ctx.Done():
+		}
+	}()
+	go func() {
+		defer s.wg.Done()
+		defer close(out)
+
+		s.consume(ctx, consumer, out)
+	}()
+
+	return out, nil
+}
+
+// createGroup creates the group of the queue unless it exists. The group of
+// an AutoDelete queue is created in one transaction with consumer and its
+// record in the set of such groups, so a recorded group always has a
+// consumer whose idle time tells whether it is stale.
+func (s *subscriber) createGroup(ctx context.Context, consumer string) error {
+	cmds, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XGroupCreateMkStream(ctx, s.stream, s.group, "$")
+		if s.autoDelete {
+			pipe.XGroupCreateConsumer(ctx, s.stream, s.group, consumer)
+			pipe.SAdd(ctx, autoDeleteGroupsKey(s.stream), s.group)
+		}
+
+		return nil
+	})
+	if len(cmds) == 0 {
+		return err
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// destroyStaleGroups destroys the AutoDelete groups of the stream whose
+// consumers have all been idle for staleGroupIdle: their replicas went away
+// without closing them. It runs with every claim.
+func (s *subscriber) destroyStaleGroups(ctx context.Context) {
+	key := autoDeleteGroupsKey(s.stream)
+	recorded, err := s.client.SMembers(ctx, key).Result()
+	if err != nil {
+		s.logger.Error("List auto-delete groups", err, watermill.LogFields{"stream": s.stream})
+
+		return
+	}
+
+	for _, group := range recorded {
+		if group == s.group {
+			continue
+		}
+
+		consumers, err := s.client.XInfoConsumers(ctx, s.stream, group).Result()
+		if err != nil {
+			if !isNoGroup(err) {
+				s.logger.Error("Inspect group", err, watermill.LogFields{"stream": s.stream, "group": group})
+			}
+
+			continue
+		}
+		if !stale(consumers) {
+			continue
+		}
+
+		_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.XGroupDestroy(ctx, s.stream, group)
+			pipe.SRem(ctx, key, group)
+
+			return nil
+		})
+		if err != nil {
+			s.logger.Error("Destroy stale group", err, watermill.LogFields{"stream": s.stream, "group": group})
+
+			continue
+		}
+		s.logger.Info("Destroyed stale group", watermill.LogFields{"stream": s.stream, "group": group})
+	}
+}
+
+// stale reports whether the group has consumers and every one of them has
+// been idle for staleGroupIdle. A group without consumers is not stale: its
+// replica may not have joined it yet.
+func stale(consumers []redis.XInfoConsumer) bool {
+	if len(consumers) == 0 {
+		return false
+	}
+	for _, consumer := range consumers {
+		if consumer.Idle < staleGroupIdle {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Close ends every subscription, closing their channels. The consumer group
+// of an AutoDelete queue is destroyed with the entries still pending in it.
+func (s *subscriber) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closing)
+		s.wg.Wait()
+
+		if s.autoDelete {
+			ctx, cancel := context.WithTimeout(context.Background(), blockTimeout)
+			defer cancel()
+
+			_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.XGroupDestroy(ctx, s.stream, s.group)
+				pipe.SRem(ctx, autoDeleteGroupsKey(s.stream), s.group)
+
+				return nil
+			})
+		}
+	})
+
+	return err
+}
+
+func (s *subscriber) consume(ctx context.Context, consumer string, out chan<- *message.Message) {
+	var lastClaim time.Time
+	for ctx.Err() == nil {
+		var entries []redis.XMessage
+		if time.Since(lastClaim) >= claimInterval {
+			entries = s.claim(ctx, consumer)
+			lastClaim = time.Now()
+			if s.autoDelete {
+				s.destroyStaleGroups(ctx)
+			}
+		}
+
+		if len(entries) == 0 {
+			streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    s.group,
+				Consumer: consumer,
+				Streams:  []string{s.stream, ">"},
+				Count:    readCount,
+				Block:    blockTimeout,
+			}).Result()
+			switch {
+			case errors.Is(err, redis.Nil):
+				continue
+			case isNoGroup(err):
+				// Destroyed by another replica that took the group for
+				// stale, or lost with the stream.
+				s.logger.Info("Recreate lost group", watermill.LogFields{"stream": s.stream, "group": s.group})
+				if err := s.createGroup(ctx, consumer); err != nil && ctx.Err() == nil {
+					s.logger.Error("Recreate group", err, watermill.LogFields{"stream": s.stream, "group": s.group})
+					sleep(ctx, retryDelay)
+				}
+
+				continue
+			case err != nil:
+				if ctx.Err() != nil {
+					return
+				}
+				s.logger.Error("Read stream", err, watermill.LogFields{"stream": s.stream})
+				sleep(ctx, retryDelay)
+
+				continue
+			}
+			for _, stream := range streams {
+				entries = append(entries, stream.Messages...)
+			}
+		}
+
+		for _, entry := range entries {
+			if !s.deliver(ctx, out, entry) {
+				return
+			}
+		}
+	}
+}
+
+// isNoGroup reports whether err is the NOGROUP error of a group or stream that
+// does not exist.
+func isNoGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP")
+}
+
+// claim takes over entries pending for longer than claimMinIdle in other
+// consumers of the group.
+func (s *subscriber) claim(ctx context.Context, consumer string) []redis.XMessage {
+	entries, _, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   s.stream,
+		Group:    s.group,
+		Consumer: consumer,
+		MinIdle:  claimMinIdle,
+		Start:    "0-0",
+		Count:    readCount,
+	}).Result()
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error("Claim pending entries", err, watermill.LogFields{"stream": s.stream})
+	}
+
+	return entries
+}
+
+// deliver sends the message of entry to out until it is acked, and reports
+// false if ctx ended first. The entry then stays pending, to be claimed by
+// another consumer.
+func (s *subscriber) deliver(ctx context.Context, out chan<- *message.Message, entry redis.XMessage) bool {
+	msg, err := s.decode(entry)
+	if err != nil {
+		s.logger.Error("Drop malformed entry", err, watermill.LogFields{"entry_id": entry.ID})
+	}
+	if msg == nil {
+		return s.ack(entry.ID)
+	}
+
+	for {
+		attempt := msg.Copy()
+		attempt.SetContext(ctx)
+
+		select {
+		case out <- attempt:
+		case <-ctx.Done():
+			return false
+		}
+
+		select {
+		case <-attempt.Acked():
+			return s.ack(entry.ID)
+		case <-attempt.Nacked():
+			if !sleep(ctx, retryDelay) {
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (s *subscriber) ack(id string) bool {
+	// The entry was handled: acknowledge it even if the subscription is
+	// being closed.
+	ctx, cancel := context.WithTimeout(context.Background(), blockTimeout)
+	defer cancel()
+
+	if err := s.client.XAck(ctx, s.stream, s.group, id).Err(); err != nil {
+		s.logger.Error("Ack entry", err, watermill.LogFields{"entry_id": id})
+	}
+
+	return true
+}
+
+// decode returns the message of entry, or nil if it is expired or does not
+// match the routing key of the queue.
+func (s *subscriber) decode(entry redis.XMessage) (*message.Message, error) {
+	routingKey, _ := entry.Values[fieldRoutingKey].(string)
+	if !factory.MatchRoutingKey(s.routingKey, routingKey) {
+		return nil, nil
+	}
+
+	if expiresAt, ok := entry.Values[fieldExpiresAt].(string); ok {
+		ms, err := strconv.ParseInt(expiresAt, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", fieldExpiresAt, err)
+		}
+		if time.Now().UnixMilli() > ms {
+			return nil, nil
+		}
+	}
+
+	id, _ := entry.Values[fieldUUID].(string)
+	payload, _ := entry.Values[fieldPayload].(string)
+	msg := message.NewMessage(id, []byte(payload))
+
+	if metadata, ok := entry.Values[fieldMetadata].(string); ok && metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), &msg.Metadata); err != nil {
+			return nil, fmt.Errorf("unmarshal %s: %w", fieldMetadata, err)
+		}
+	}
+	if msg.Metadata == nil {
+		msg.Metadata = make(message.Metadata)
+	}
+	msg.Metadata.Set(factory.RoutingKeyMetadata, routingKey)
+
+	return msg, nil
+}
+
+// autoDeleteGroupsKey is the set of the AutoDelete groups of stream.
+func autoDeleteGroupsKey(stream string) string {
+	return stream + ":auto_delete_groups"
+}
+
+// consumerName names a consumer uniquely, with the host name to tell the
+// replicas apart when inspecting a group.
+func consumerName() string {
+	host, _ := os.Hostname()
+
+	return host + "-" + uuid.NewString()
+}
+
+// sleep waits for d and reports false if ctx ended first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
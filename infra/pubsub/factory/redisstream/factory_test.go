@@ -0,0 +1,285 @@
+package redisstream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
+)
+
+func newFactory(t *testing.T) (*Factory, *redis.Client) {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return NewFactory(client, watermill.NopLogger{}), client
+}
+
+func subscribe(t *testing.T, f *Factory, queue, routingKey string) <-chan *message.Message {
+	t.Helper()
+
+	sub, err := f.BuildSubscriber(queue, &factory.SubscriberConfig{
+		Exchange:   factory.ExchangeConfig{Name: "events"},
+		Queue:      queue,
+		RoutingKey: routingKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sub.Close() })
+
+	messages, err := sub.Subscribe(context.Background(), "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return messages
+}
+
+func publish(t *testing.T, f *Factory, routingKey string, messages ...*message.Message) {
+	t.Helper()
+
+	pub, err := f.BuildPublisher(&factory.PublisherConfig{Exchange: factory.ExchangeConfig{Name: "events"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Publish(routingKey, messages...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func receive(t *testing.T, messages <-chan *message.Message) *message.Message {
+	t.Helper()
+
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(3 * time.Second):
+		t.Fatal("no message received")
+
+		return nil
+	}
+}
+
+func TestFactory_RoutesToMatchingGroups(t *testing.T) {
+	f, _ := newFactory(t)
+
+	all := subscribe(t, f, "all", "updates.#")
+	reads := subscribe(t, f, "reads", "updates.message.read")
+
+	sent := message.NewMessage("m1", []byte("new"))
+	sent.Metadata.Set("trace", "t1")
+	publish(t, f, "updates.message.new", sent)
+
+	msg := receive(t, all)
+	if msg.UUID != "m1" || string(msg.Payload) != "new" || msg.Metadata.Get("trace") != "t1" {
+		t.Errorf("got %s %q %v", msg.UUID, msg.Payload, msg.Metadata)
+	}
+	if got := msg.Metadata.Get(factory.RoutingKeyMetadata); got != "updates.message.new" {
+		t.Errorf("routing key = %q", got)
+	}
+	msg.Ack()
+
+	select {
+	case msg := <-reads:
+		t.Errorf("reads group got %s", msg.UUID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestFactory_ConsumersShareGroup(t *testing.T) {
+	f, _ := newFactory(t)
+
+	first := subscribe(t, f, "push", "#")
+	second := subscribe(t, f, "push", "#")
+
+	const total = 20
+	messages := make([]*message.Message, 0, total)
+	for range total {
+		messages = append(messages, message.NewMessage(watermill.NewUUID(), nil))
+	}
+	publish(t, f, "updates.message.new", messages...)
+
+	seen := make(map[string]bool)
+	for len(seen) < total {
+		var msg *message.Message
+		select {
+		case msg = <-first:
+		case msg = <-second:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("received %d of %d messages", len(seen), total)
+		}
+		if seen[msg.UUID] {
+			t.Errorf("%s delivered twice", msg.UUID)
+		}
+		seen[msg.UUID] = true
+		msg.Ack()
+	}
+}
+
+func TestFactory_RedeliversNacked(t *testing.T) {
+	f, client := newFactory(t)
+
+	messages := subscribe(t, f, "push", "#")
+	publish(t, f, "updates.message.new", message.NewMessage("m1", nil))
+
+	receive(t, messages).Nack()
+
+	msg := receive(t, messages)
+	if msg.UUID != "m1" {
+		t.Fatalf("redelivered %s", msg.UUID)
+	}
+	msg.Ack()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		pending, err := client.XPending(context.Background(), "events", "push").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending.Count == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d entries still pending", pending.Count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFactory_DestroysStaleAutoDeleteGroups(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	f := NewFactory(client, watermill.NopLogger{})
+	ctx := context.Background()
+
+	autoDelete := func(queue string) *subscriber {
+		sub, err := f.BuildSubscriber(queue, &factory.SubscriberConfig{
+			Exchange:   factory.ExchangeConfig{Name: "events"},
+			Queue:      queue,
+			RoutingKey: "#",
+			AutoDelete: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return sub.(*subscriber)
+	}
+
+	// A replica that crashed used its group once and never closed it.
+	// miniredis tracks the idle time of a consumer through claims only.
+	if err := autoDelete("crashed").createGroup(ctx, "gone"); err != nil {
+		t.Fatal(err)
+	}
+	err := client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   "events",
+		Group:    "crashed",
+		Consumer: "gone",
+		Messages: []string{"0-1"},
+	}).Err()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		t.Fatal(err)
+	}
+
+	if err := client.XGroupCreate(ctx, "events", "durable", "$").Err(); err != nil {
+		t.Fatal(err)
+	}
+	mr.SetTime(time.Now().Add(staleGroupIdle + time.Minute))
+
+	live := autoDelete("live")
+	if _, err := live.Subscribe(ctx, "events"); err != nil {
+		t.Fatal(err)
+	}
+
+	groupNames := func() map[string]bool {
+		groups, err := client.XInfoGroups(ctx, "events").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make(map[string]bool, len(groups))
+		for _, group := range groups {
+			names[group.Name] = true
+		}
+
+		return names
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for groupNames()["crashed"] {
+		if time.Now().After(deadline) {
+			t.Fatal("stale group was not destroyed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if names := groupNames(); !names["live"] || !names["durable"] {
+		t.Errorf("groups = %v, want live and durable kept", names)
+	}
+
+	if err := live.Close(); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := client.SMembers(ctx, autoDeleteGroupsKey("events")).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 0 {
+		t.Errorf("auto-delete groups still recorded: %v", recorded)
+	}
+}
+
+func TestFactory_RecreatesLostGroup(t *testing.T) {
+	f, client := newFactory(t)
+	messages := subscribe(t, f, "q", "#")
+
+	if err := client.XGroupDestroy(context.Background(), "events", "q").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entries published before the group is back are not delivered.
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		groups, _ := client.XInfoGroups(context.Background(), "events").Result()
+		if len(groups) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("lost group was not recreated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	publish(t, f, "a", message.NewMessage("m1", nil))
+	if msg := receive(t, messages); msg.UUID != "m1" {
+		t.Errorf("received %s, want m1", msg.UUID)
+	}
+}
+
+func TestStale(t *testing.T) {
+	idle := redis.XInfoConsumer{Idle: staleGroupIdle}
+	active := redis.XInfoConsumer{Idle: time.Second}
+
+	tests := []struct {
+		name      string
+		consumers []redis.XInfoConsumer
+		want      bool
+	}{
+		{name: "no consumers"},
+		{name: "all idle", consumers: []redis.XInfoConsumer{idle, idle}, want: true},
+		{name: "one active", consumers: []redis.XInfoConsumer{idle, active}},
+	}
+	for _, tt := range tests {
+		if got := stale(tt.consumers); got != tt.want {
+			t.Errorf("%s: stale = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -3,15 +3,19 @@ package pubsub
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/fx"
 
 	"github.com/webitel/im-gateway-service/config"
 	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
 	"github.com/webitel/im-gateway-service/infra/pubsub/factory/amqp"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory/gochannel"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory/redisstream"
 )
 
 type Provider interface {
@@ -27,7 +31,7 @@ var Module = fx.Module("pubsub",
 	),
 )
 
-func ProvidePubSub(cfg *config.Config, l *slog.Logger, lc fx.Lifecycle) (Provider, error) {
+func ProvidePubSub(cfg *config.Config, l *slog.Logger, redisClient *redis.Client, lc fx.Lifecycle) (Provider, error) {
 	var (
 		pubsubConfig  = cfg.Pubsub
 		loggerAdapter = watermill.NewSlogLogger(l)
@@ -41,8 +45,13 @@ func ProvidePubSub(cfg *config.Config, l *slog.Logger, lc fx.Lifecycle) (Provide
 		if err != nil {
 			return nil, err
 		}
+	case "gochannel":
+		l.Warn("using the in-process gochannel pubsub driver: events are not shared with other services or replicas")
+		pubsubFactory = gochannel.NewFactory(loggerAdapter)
+	case "redis":
+		pubsubFactory = redisstream.NewFactory(redisClient, loggerAdapter)
 	default:
-		return nil, errors.New("pubsub driver not supported")
+		return nil, fmt.Errorf("pubsub driver %q not supported", pubsubConfig.Driver)
 	}
 
 	router, err := message.NewRouter(message.RouterConfig{}, loggerAdapter)
@@ -54,7 +63,23 @@ func ProvidePubSub(cfg *config.Config, l *slog.Logger, lc fx.Lifecycle) (Provide
 			return router.Close()
 		},
 		OnStart: func(ctx context.Context) error {
-			return router.Run(ctx)
+			// Run blocks until the router is closed, so it cannot be called
+			// inline: wait only until all handlers are subscribed.
+			errCh := make(chan error, 1)
+			go func() {
+				if err := router.Run(context.Background()); err != nil {
+					errCh <- err
+				}
+			}()
+
+			select {
+			case <-router.Running():
+				return nil
+			case err := <-errCh:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		},
 	})
 
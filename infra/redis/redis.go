@@ -0,0 +1,40 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+
+	"github.com/webitel/im-gateway-service/config"
+)
+
+var Module = fx.Module("redis",
+	fx.Provide(ProvideClient),
+)
+
+// ProvideClient connects to the Redis configured under redis.* and closes the
+// connection pool on shutdown.
+func ProvideClient(cfg *config.Config, lc fx.Lifecycle) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := client.Ping(ctx).Err(); err != nil {
+				return fmt.Errorf("redis: ping %s: %w", cfg.Redis.Addr, err)
+			}
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return client.Close()
+		},
+	})
+
+	return client
+}
@@ -18,3 +18,25 @@ func NewUnaryAuthInterceptor(authorizer auth.Authorizer) grpc.UnaryServerInterce
 		return handler(newCtx, req)
 	}
 }
+
+// NewStreamAuthInterceptor provides identification for streaming RPC calls
+func NewStreamAuthInterceptor(authorizer auth.Authorizer) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		newCtx, err := authorizer.SetIdentity(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &identifiedStream{ServerStream: ss, ctx: newCtx})
+	}
+}
+
+// identifiedStream overrides the stream context with the one carrying the identity.
+type identifiedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identifiedStream) Context() context.Context {
+	return s.ctx
+}
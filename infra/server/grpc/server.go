@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"strconv"
+	"strings"
 
 	"buf.build/go/protovalidate"
 	grpcdefaultinterceptors "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
@@ -15,7 +16,9 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 
 	intrcp "github.com/webitel/webitel-go-kit/pkg/interceptors"
 
@@ -25,6 +28,18 @@ import (
 	infratls "github.com/webitel/im-gateway-service/infra/tls"
 )
 
+// publicMethods can be called without credentials.
+var publicMethods = map[string]bool{
+	"/webitel.im.api.gateway.v1.Account/Token": true,
+}
+
+// IsPublicMethod reports whether fullMethod, e.g.
+// "/webitel.im.api.gateway.v1.Account/Token", is served without
+// authentication.
+func IsPublicMethod(fullMethod string) bool {
+	return publicMethods[fullMethod]
+}
+
 var Module = fx.Module("grpc_server",
 	fx.Provide(
 		fx.Annotate(
@@ -85,6 +100,16 @@ type Server struct {
 	port     int
 	log      *slog.Logger
 	listener net.Listener
+
+	// services are the registered services by full name, for Invoke.
+	services map[string]*registeredService
+	// localInterceptor runs around in-process calls made with Invoke.
+	localInterceptor grpc.UnaryServerInterceptor
+}
+
+type registeredService struct {
+	desc *grpc.ServiceDesc
+	impl any
 }
 
 type Config struct {
@@ -126,16 +151,22 @@ func New(addr string, opts ...Option) (*Server, error) {
 		return nil, err
 	}
 
+	var (
+		errorInterceptor      = intrcp.UnaryServerErrorInterceptor()
+		validationInterceptor = interceptors.ValidationInterceptor(validator)
+	)
 	serverOpts := []grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(
-			intrcp.UnaryServerErrorInterceptor(),
+			errorInterceptor,
 			selector.UnaryServerInterceptor(interceptors.NewUnaryAuthInterceptor(conf.Auther),
 				selector.MatchFunc(func(ctx context.Context, callMeta grpcdefaultinterceptors.CallMeta) bool {
-					method := fmt.Sprintf("%s/%s", callMeta.Service, callMeta.Method)
-					return method != "webitel.im.api.gateway.v1.Account/Token"
+					return !IsPublicMethod(callMeta.FullMethod())
 				})),
-			interceptors.ValidationInterceptor(validator),
+			validationInterceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			interceptors.NewStreamAuthInterceptor(conf.Auther),
 		),
 	}
 
@@ -166,15 +197,58 @@ func New(addr string, opts ...Option) (*Server, error) {
 	}
 
 	return &Server{
-		Addr:     addr,
-		Server:   s,
-		log:      log,
-		host:     h,
-		port:     port,
-		listener: l,
+		Addr:             addr,
+		Server:           s,
+		log:              log,
+		host:             h,
+		port:             port,
+		listener:         l,
+		services:         make(map[string]*registeredService),
+		localInterceptor: chainUnary(errorInterceptor, validationInterceptor),
 	}, nil
 }
 
+// chainUnary combines interceptors into one, the first being the outermost.
+func chainUnary(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(ctx context.Context, req any) (any, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// RegisterService registers a service with the gRPC server and keeps it
+// callable in-process with Invoke.
+func (s *Server) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	s.Server.RegisterService(desc, impl)
+	s.services[desc.ServiceName] = &registeredService{desc: desc, impl: impl}
+}
+
+// Invoke calls the unary method fullMethod, e.g.
+// "/webitel.im.api.gateway.v1.Bots/CreateBot", of a registered service
+// in-process. dec fills the request message. The call goes through the
+// interceptors of a network call, but authentication is left to the caller.
+func (s *Server) Invoke(ctx context.Context, fullMethod string, dec func(any) error) (any, error) {
+	serviceName, methodName, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+
+	service, ok := s.services[serviceName]
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "unknown service %s", serviceName)
+	}
+	for _, method := range service.desc.Methods {
+		if method.MethodName == methodName {
+			return method.Handler(service.impl, ctx, dec, s.localInterceptor)
+		}
+	}
+
+	return nil, status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod)
+}
+
 // Listen starts the gRPC server.
 func (s *Server) Listen() error {
 	return s.Serve(s.listener)
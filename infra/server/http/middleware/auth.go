@@ -3,12 +3,33 @@ package middleware
 import (
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 
 	"google.golang.org/grpc/metadata"
 
 	"github.com/webitel/im-gateway-service/infra/auth"
 )
 
+// BridgedHeaders are the HTTP request headers passed on to gRPC handlers as
+// incoming metadata, under their lower-cased names.
+var BridgedHeaders = []string{
+	"Authorization",
+	"X-Webitel-Access",
+	"X-Webitel-Device",
+	"X-Webitel-Client",
+}
+
+// IncomingHeaderMatcher maps a canonical HTTP header name to its metadata key
+// if it is one of BridgedHeaders.
+func IncomingHeaderMatcher(key string) (string, bool) {
+	if !slices.Contains(BridgedHeaders, key) {
+		return "", false
+	}
+
+	return strings.ToLower(key), true
+}
+
 // NewAuthMiddleware returns an HTTP middleware that bridges HTTP request headers into
 // gRPC incoming metadata, then delegates to the existing Authorizer.SetIdentity.
 // This reuses the same auth logic as the gRPC interceptor without reimplementation.
@@ -16,17 +37,11 @@ func NewAuthMiddleware(authorizer auth.Authorizer) func(http.Handler) http.Handl
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			md := metadata.MD{}
-			if v := r.Header.Get("Authorization"); v != "" {
-				md["authorization"] = []string{v}
-			}
-			if v := r.Header.Get("X-Webitel-Access"); v != "" {
-				md["x-webitel-access"] = []string{v}
-			}
-			if v := r.Header.Get("X-Webitel-Device"); v != "" {
-				md["x-webitel-device"] = []string{v}
-			}
-			if v := r.Header.Get("X-Webitel-Client"); v != "" {
-				md["x-webitel-client"] = []string{v}
+			for _, header := range BridgedHeaders {
+				if v := r.Header.Get(header); v != "" {
+					key, _ := IncomingHeaderMatcher(header)
+					md[key] = []string{v}
+				}
 			}
 
 			// Inject as gRPC incoming metadata so SetIdentity can read it.
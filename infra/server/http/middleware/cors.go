@@ -2,6 +2,11 @@ package middleware
 
 import "net/http"
 
+// exposedHeaders are the response headers browsers let scripts read: the tus
+// upload state and the ID of a completed upload.
+const exposedHeaders = "Location, Upload-Offset, Upload-Length, Upload-Expires, " +
+	"Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, X-Webitel-File-Id"
+
 func WithCORS(allowedOrigins string, h http.Handler) http.Handler {
 	if allowedOrigins == "" {
 		allowedOrigins = "*"
@@ -9,16 +14,20 @@ func WithCORS(allowedOrigins string, h http.Handler) http.Handler {
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", allowedOrigins)
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS")
+		w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
 
 		reqHeaders := r.Header.Get("Access-Control-Request-Headers")
 		if reqHeaders != "" {
 			w.Header().Set("Access-Control-Allow-Headers", reqHeaders)
 		} else {
-			w.Header().Set("Access-Control-Allow-Headers", "X-Portal-Token, X-Portal-Access, X-Portal-Client, Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "X-Portal-Token, X-Portal-Access, X-Portal-Client, Content-Type, Authorization, "+
+				"Tus-Resumable, Upload-Length, Upload-Offset, Upload-Metadata")
 		}
 
-		if r.Method == http.MethodOptions {
+		// Only preflights end here: a plain OPTIONS reaches the handlers, as tus
+		// clients use it for discovery.
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
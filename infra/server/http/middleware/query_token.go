@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// AccessTokenQueryParam is the query parameter browsers use to pass the access
+// token where they cannot set request headers (WebSocket, EventSource).
+const AccessTokenQueryParam = "access_token"
+
+// WithQueryToken copies the access_token query parameter into the
+// X-Webitel-Access header, unless the request already carries credentials.
+// It must run before the auth middleware and is meant only for streaming
+// routes: tokens in URLs end up in proxy logs.
+func WithQueryToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get(AccessTokenQueryParam)
+		if token != "" && r.Header.Get("Authorization") == "" && r.Header.Get("X-Webitel-Access") == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("X-Webitel-Access", token)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
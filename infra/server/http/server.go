@@ -18,7 +18,16 @@ import (
 )
 
 var Module = fx.Module("http_server",
-	fx.Invoke(ProvideServer),
+	fx.Invoke(
+		fx.Annotate(
+			ProvideServer,
+			fx.ParamTags(``, ``, ``, ``, `group:"http_on_shutdown"`),
+		),
+		fx.Annotate(
+			ProvideAdminServer,
+			fx.ParamTags(``, ``, `name:"adminHandler"`, ``),
+		),
+	),
 )
 
 func ProvideServer(
@@ -26,6 +35,7 @@ func ProvideServer(
 	logger *slog.Logger,
 	handler http.Handler,
 	lc fx.Lifecycle,
+	onShutdown ...func(),
 ) error {
 	var tlsCfg *tls.Config
 	if cfg.Service.HTTP.VerifyCerts {
@@ -55,6 +65,9 @@ func ProvideServer(
 		Handler:   finalHandler,
 		TLSConfig: tlsCfg,
 	}
+	for _, f := range onShutdown {
+		srv.RegisterOnShutdown(f)
+	}
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -80,3 +93,38 @@ func ProvideServer(
 
 	return nil
 }
+
+// ProvideAdminServer serves the admin endpoints on service.admin.addr, an
+// internal address apart from the public listener. Without one, they are not
+// served at all.
+func ProvideAdminServer(
+	cfg *config.Config,
+	logger *slog.Logger,
+	handler http.Handler,
+	lc fx.Lifecycle,
+) {
+	addr := cfg.Service.Admin.Addr
+	if addr == "" {
+		return
+	}
+
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: middleware.LoggingMiddleware(logger)(handler),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				logger.Info(fmt.Sprintf("listen admin http %s", addr))
+				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.Error("admin http server error", "err", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return srv.Shutdown(ctx)
+		},
+	})
+}
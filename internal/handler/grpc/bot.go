@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 
+	extpb "github.com/webitel/im-gateway-service/gen/go/gateway/ext/v1"
 	impb "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
 	"github.com/webitel/im-gateway-service/internal/handler/grpc/mapper"
 	"github.com/webitel/im-gateway-service/internal/service"
@@ -51,3 +52,30 @@ func NewBotService(logger *slog.Logger, botter service.Botter) *BotService {
 		botter: botter,
 	}
 }
+
+var _ extpb.BotsServer = (*BotWebhookService)(nil)
+
+// BotWebhookService serves the bot RPCs the gateway adds to the Bots
+// service.
+type BotWebhookService struct {
+	extpb.UnimplementedBotsServer
+
+	logger    *slog.Logger
+	webhooker service.Webhooker
+}
+
+func NewBotWebhookService(logger *slog.Logger, webhooker service.Webhooker) *BotWebhookService {
+	return &BotWebhookService{
+		logger:    logger,
+		webhooker: webhooker,
+	}
+}
+
+func (b *BotWebhookService) ListDeliveries(ctx context.Context, request *extpb.ListDeliveriesRequest) (*extpb.ListDeliveriesResponse, error) {
+	deliveries, err := b.webhooker.ListDeliveries(ctx, mapper.MapToListDeliveriesRequest(request))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapToListDeliveriesResponse(deliveries), nil
+}
@@ -1,6 +1,7 @@
 package mapper
 
 import (
+	extpb "github.com/webitel/im-gateway-service/gen/go/gateway/ext/v1"
 	impb "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
 	"github.com/webitel/im-gateway-service/internal/service/dto"
 )
@@ -52,3 +53,35 @@ func MapToBot(in *dto.Bot) *impb.Bot {
 		Metadata: in.Metadata,
 	}
 }
+
+func MapToListDeliveriesRequest(in *extpb.ListDeliveriesRequest) *dto.ListDeliveriesRequest {
+	if in == nil {
+		return nil
+	}
+
+	return &dto.ListDeliveriesRequest{
+		BotID: in.GetBotId(),
+		Size:  in.GetSize(),
+	}
+}
+
+func MapToListDeliveriesResponse(in []*dto.Delivery) *extpb.ListDeliveriesResponse {
+	items := make([]*extpb.Delivery, 0, len(in))
+	for _, d := range in {
+		items = append(items, &extpb.Delivery{
+			Id:         d.ID,
+			BotId:      d.BotID,
+			UpdateId:   d.UpdateID,
+			ThreadId:   d.ThreadID,
+			Url:        d.URL,
+			Attempts:   int32(d.Attempts),
+			StatusCode: int32(d.StatusCode),
+			Error:      d.Error,
+			CreatedAt:  d.CreatedAt,
+			FailedAt:   d.FailedAt,
+			Payload:    d.Payload,
+		})
+	}
+
+	return &extpb.ListDeliveriesResponse{Items: items}
+}
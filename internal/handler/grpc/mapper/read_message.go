@@ -1,6 +1,7 @@
 package mapper
 
 import (
+	extpb "github.com/webitel/im-gateway-service/gen/go/gateway/ext/v1"
 	impb "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
 	"github.com/webitel/im-gateway-service/internal/service/dto"
 )
@@ -14,3 +15,26 @@ func MapToReadMessageRequest(pb *impb.ReadMessageRequest) *dto.ReadMessageReques
 		ThreadID:  pb.GetThreadId(),
 	}
 }
+
+func MapToListReadPositionsRequest(pb *extpb.ListReadPositionsRequest) *dto.ListReadPositionsRequest {
+	if pb == nil {
+		return nil
+	}
+	return &dto.ListReadPositionsRequest{
+		ThreadID: pb.GetThreadId(),
+	}
+}
+
+func MapToListReadPositionsResponse(in *dto.ListReadPositionsResponse) *extpb.ListReadPositionsResponse {
+	items := make([]*extpb.ReadPosition, 0, len(in.Items))
+	for _, position := range in.Items {
+		items = append(items, &extpb.ReadPosition{
+			ContactId:   position.ContactID,
+			MessageId:   position.MessageID,
+			MessageDate: position.MessageDate,
+			ReadAt:      position.ReadAt,
+		})
+	}
+
+	return &extpb.ListReadPositionsResponse{Items: items}
+}
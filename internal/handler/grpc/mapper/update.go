@@ -0,0 +1,65 @@
+package mapper
+
+import (
+	impb "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+func MapToUpdate(u *dto.Update) *impb.Update {
+	if u == nil {
+		return nil
+	}
+
+	pb := &impb.Update{
+		Id:        u.ID,
+		Type:      string(u.Type),
+		ThreadId:  u.ThreadID,
+		Date:      u.Date,
+		ExpiresAt: u.ExpiresAt,
+		Cursor:    u.Cursor,
+	}
+
+	switch {
+	case u.Message != nil:
+		message := &impb.UpdateMessage{
+			Id:        u.Message.ID,
+			SenderId:  u.Message.SenderID,
+			Type:      u.Message.Type,
+			Body:      u.Message.Body,
+			Metadata:  u.Message.Metadata,
+			CreatedAt: u.Message.CreatedAt,
+		}
+		for _, doc := range u.Message.Documents {
+			message.Documents = append(message.Documents, &impb.UpdateDocument{
+				FileId:   doc.FileID,
+				Name:     doc.Name,
+				MimeType: doc.MimeType,
+				Size:     doc.Size,
+			})
+		}
+		pb.Payload = &impb.Update_Message{Message: message}
+	case u.Read != nil:
+		pb.Payload = &impb.Update_Read{Read: &impb.UpdateRead{
+			MessageId: u.Read.MessageID,
+			ContactId: u.Read.ContactID,
+			ReadAt:    u.Read.ReadAt,
+		}}
+	case u.Member != nil:
+		pb.Payload = &impb.Update_Member{Member: &impb.UpdateMember{
+			Id:        u.Member.ID,
+			ContactId: u.Member.ContactID,
+			Role:      u.Member.Role,
+		}}
+	case u.ChatAction != nil:
+		pb.Payload = &impb.Update_ChatAction{ChatAction: &impb.UpdateChatAction{
+			ContactId: u.ChatAction.ContactID,
+			Action:    string(u.ChatAction.Action),
+		}}
+	case u.Type == dto.UpdateVariablesChanged:
+		pb.Payload = &impb.Update_Variables{Variables: &impb.UpdateVariables{
+			Variables: u.Variables,
+		}}
+	}
+
+	return pb
+}
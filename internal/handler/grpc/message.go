@@ -4,13 +4,16 @@ import (
 	"context"
 	"log/slog"
 
+	extpb "github.com/webitel/im-gateway-service/gen/go/gateway/ext/v1"
 	impb "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
 	"github.com/webitel/im-gateway-service/internal/handler/grpc/mapper"
 	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
 )
 
 var (
-	_ impb.MessageServer = (*MessageService)(nil)
+	_ impb.MessageServer  = (*MessageService)(nil)
+	_ extpb.MessageServer = (*ChatActionService)(nil)
 )
 
 type MessageService struct {
@@ -97,3 +100,30 @@ func (m *MessageService) SendSystemMessage(ctx context.Context, in *impb.SendSys
 
 	return mapper.MapToSendSystemMessageResponse(out), nil
 }
+
+// ChatActionService serves the message RPCs the gateway adds to the Message
+// service.
+type ChatActionService struct {
+	extpb.UnimplementedMessageServer
+
+	logger      *slog.Logger
+	chatActions service.ChatActioner
+}
+
+func NewChatActionService(logger *slog.Logger, chatActions service.ChatActioner) *ChatActionService {
+	return &ChatActionService{
+		logger:      logger,
+		chatActions: chatActions,
+	}
+}
+
+func (m *ChatActionService) SendChatAction(ctx context.Context, in *extpb.SendChatActionRequest) (*extpb.SendChatActionResponse, error) {
+	if err := m.chatActions.SendChatAction(ctx, &dto.SendChatActionRequest{
+		ThreadID: in.GetThreadId(),
+		Action:   dto.ChatAction(in.GetAction()),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &extpb.SendChatActionResponse{}, nil
+}
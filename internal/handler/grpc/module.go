@@ -3,6 +3,7 @@ package grpc
 import (
 	"go.uber.org/fx"
 
+	extpb "github.com/webitel/im-gateway-service/gen/go/gateway/ext/v1"
 	impb "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
 	providerv1 "github.com/webitel/im-gateway-service/gen/go/provider/v1"
 	grpcsrv "github.com/webitel/im-gateway-service/infra/server/grpc"
@@ -11,27 +12,35 @@ import (
 var Module = fx.Module("grpc",
 	fx.Provide(
 		NewMessageService,
+		NewChatActionService,
 		NewMessageHistoryService,
 		NewThreadService,
+		NewReadPositionService,
 		NewThreadPermissionServer,
 		NewContactSettingsServer,
+		NewUpdatesServer,
 	),
 	fx.Invoke(
 		RegisterMessageService,
+		RegisterChatActionService,
 		RegisterHistoryMessageService,
 		RegisterThreadService,
+		RegisterReadPositionService,
 		RegisterThreadPermissionService,
 		RegisterContactSettingsServer,
+		RegisterUpdatesServer,
 	),
 	fx.Provide(
 		NewContactService,
 		NewBotService,
+		NewBotWebhookService,
 		NewAccountService,
 		newViaServer,
 	),
 	fx.Invoke(
 		RegisterContactService,
 		RegisterBotService,
+		RegisterBotWebhookService,
 		RegisterAccountService,
 		RegisterViaServer,
 	),
@@ -55,7 +64,11 @@ func RegisterMessageService(
 	server *grpcsrv.Server,
 	service *MessageService,
 ) {
-	impb.RegisterMessageServer(server.Server, service)
+	impb.RegisterMessageServer(server, service)
+}
+
+func RegisterChatActionService(server *grpcsrv.Server, service *ChatActionService) {
+	extpb.RegisterMessageServer(server, service)
 }
 
 func RegisterHistoryMessageService(server *grpcsrv.Server, service *MessageHistoryService) {
@@ -69,6 +82,10 @@ func RegisterContactService(server *grpcsrv.Server, service *ContactService) {
 func RegisterBotService(server *grpcsrv.Server, service *BotService) {
 	impb.RegisterBotsServer(server, service)
 }
+
+func RegisterBotWebhookService(server *grpcsrv.Server, service *BotWebhookService) {
+	extpb.RegisterBotsServer(server, service)
+}
 func RegisterAccountService(server *grpcsrv.Server, service *AccountService) {
 	impb.RegisterAccountServer(server, service)
 }
@@ -77,6 +94,10 @@ func RegisterThreadService(server *grpcsrv.Server, service *ThreadService) {
 	impb.RegisterThreadManagementServer(server, service)
 }
 
+func RegisterReadPositionService(server *grpcsrv.Server, service *ReadPositionService) {
+	extpb.RegisterThreadManagementServer(server, service)
+}
+
 func RegisterThreadPermissionService(server *grpcsrv.Server, service *ThreadPermissionServer) {
 	impb.RegisterThreadPermissionServer(server, service)
 }
@@ -85,26 +106,30 @@ func RegisterContactSettingsServer(server *grpcsrv.Server, service *ContactSetti
 	impb.RegisterContactSettingsManagementServer(server, service)
 }
 
+func RegisterUpdatesServer(server *grpcsrv.Server, service *UpdatesServer) {
+	impb.RegisterUpdatesServer(server, service)
+}
+
 func RegisterViaServer(server *grpcsrv.Server, service *ViaServer) {
 	impb.RegisterViasServiceServer(server, service)
 }
 
 func RegisterFacebookServiceHandler(server *grpcsrv.Server, h *FacebookServiceHandler) {
-	providerv1.RegisterFacebookServiceServer(server.Server, h)
+	providerv1.RegisterFacebookServiceServer(server, h)
 }
 
 func RegisterGateServiceHandler(server *grpcsrv.Server, h *GateServiceHandler) {
-	providerv1.RegisterGateServiceServer(server.Server, h)
+	providerv1.RegisterGateServiceServer(server, h)
 }
 
 func RegisterWhatsAppServiceHandler(server *grpcsrv.Server, h *WhatsAppServiceHandler) {
-	providerv1.RegisterWhatsAppServiceServer(server.Server, h)
+	providerv1.RegisterWhatsAppServiceServer(server, h)
 }
 
 func RegisterMetaAppServiceHandler(server *grpcsrv.Server, h *MetaAppServiceHandler) {
-	providerv1.RegisterMetaAppServiceServer(server.Server, h)
+	providerv1.RegisterMetaAppServiceServer(server, h)
 }
 
 func RegisterMetaOAuthServiceHandler(server *grpcsrv.Server, h *MetaOAuthServiceHandler) {
-	providerv1.RegisterMetaOAuthServiceServer(server.Server, h)
+	providerv1.RegisterMetaOAuthServiceServer(server, h)
 }
@@ -4,11 +4,16 @@ import (
 	"context"
 	"log/slog"
 
+	extpb "github.com/webitel/im-gateway-service/gen/go/gateway/ext/v1"
 	impb "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
+	"github.com/webitel/im-gateway-service/internal/handler/grpc/mapper"
 	"github.com/webitel/im-gateway-service/internal/service"
 )
 
-var _ impb.ThreadManagementServer = (*ThreadService)(nil)
+var (
+	_ impb.ThreadManagementServer  = (*ThreadService)(nil)
+	_ extpb.ThreadManagementServer = (*ReadPositionService)(nil)
+)
 
 type ThreadService struct {
 	impb.UnimplementedThreadManagementServer
@@ -122,3 +127,28 @@ func (s *ThreadService) LocateVariables(ctx context.Context, req *impb.LocateVar
 func (s *ThreadService) FlushVariables(ctx context.Context, req *impb.FlushVariablesRequest) (*impb.ThreadVariables, error) {
 	return s.threadManager.FlushVariables(ctx, req)
 }
+
+// ReadPositionService serves the thread RPCs the gateway adds to the
+// ThreadManagement service.
+type ReadPositionService struct {
+	extpb.UnimplementedThreadManagementServer
+
+	logger   *slog.Logger
+	receipts service.ReadReceipter
+}
+
+func NewReadPositionService(logger *slog.Logger, receipts service.ReadReceipter) *ReadPositionService {
+	return &ReadPositionService{
+		logger:   logger,
+		receipts: receipts,
+	}
+}
+
+func (s *ReadPositionService) ListReadPositions(ctx context.Context, req *extpb.ListReadPositionsRequest) (*extpb.ListReadPositionsResponse, error) {
+	resp, err := s.receipts.ListReadPositions(ctx, mapper.MapToListReadPositionsRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapToListReadPositionsResponse(resp), nil
+}
@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"errors"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	impb "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
+	"github.com/webitel/im-gateway-service/internal/handler/grpc/mapper"
+	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+var _ impb.UpdatesServer = (*UpdatesServer)(nil)
+
+// UpdatesServer streams realtime updates to gRPC clients, the counterpart of
+// the WebSocket and event stream endpoints of the HTTP server.
+type UpdatesServer struct {
+	impb.UnimplementedUpdatesServer
+
+	logger  *slog.Logger
+	updater service.Updater
+}
+
+func NewUpdatesServer(logger *slog.Logger, updater service.Updater) *UpdatesServer {
+	return &UpdatesServer{
+		logger:  logger,
+		updater: updater,
+	}
+}
+
+func (s *UpdatesServer) Subscribe(in *impb.SubscribeUpdatesRequest, stream impb.Updates_SubscribeServer) error {
+	sub, err := s.updater.Subscribe(stream.Context(), &dto.SubscribeRequest{After: in.GetAfter()})
+	if err != nil {
+		if errors.Is(err, service.ErrUpdaterClosed) {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case update, ok := <-sub.Updates():
+			if !ok {
+				return subscriptionStatus(sub.Err())
+			}
+			if err := stream.Send(mapper.MapToUpdate(update)); err != nil {
+				s.logger.Debug("updates stream send failed", slog.String("error", err.Error()))
+
+				return err
+			}
+		}
+	}
+}
+
+// subscriptionStatus tells the client why its subscription ended.
+func subscriptionStatus(reason error) error {
+	switch {
+	case errors.Is(reason, service.ErrSlowConsumer):
+		return status.Error(codes.ResourceExhausted, reason.Error())
+	case errors.Is(reason, service.ErrUpdaterClosed):
+		return status.Error(codes.Unavailable, reason.Error())
+	}
+
+	return nil
+}
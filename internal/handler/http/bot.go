@@ -0,0 +1,72 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// BotHandler serves bot webhook registration. The failed deliveries are
+// listed by the Bots.ListDeliveries RPC.
+type BotHandler struct {
+	logger    *slog.Logger
+	webhooker service.Webhooker
+}
+
+func NewBotHandler(
+	logger *slog.Logger,
+	webhooker service.Webhooker,
+	authMW func(http.Handler) http.Handler,
+	mux *http.ServeMux,
+) *BotHandler {
+	h := &BotHandler{
+		logger:    logger,
+		webhooker: webhooker,
+	}
+	h.registerRoutes(mux, authMW)
+
+	return h
+}
+
+func (h *BotHandler) registerRoutes(mux *http.ServeMux, authMW func(http.Handler) http.Handler) {
+	mux.Handle("PUT /bots/{id}/webhook", authMW(http.HandlerFunc(h.setWebhook)))
+	mux.Handle("DELETE /bots/{id}/webhook", authMW(http.HandlerFunc(h.deleteWebhook)))
+}
+
+// setWebhook registers the bot's webhook. The response carries the signing
+// secret; it is not returned anywhere else.
+func (h *BotHandler) setWebhook(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid request body")
+
+		return
+	}
+	req.BotID = r.PathValue("id")
+
+	hook, err := h.webhooker.SetWebhook(r.Context(), &req)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(hook); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+func (h *BotHandler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.webhooker.DeleteWebhook(r.Context(), &dto.DeleteWebhookRequest{BotID: r.PathValue("id")}); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
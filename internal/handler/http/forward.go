@@ -0,0 +1,139 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// forwardedHeader marks a request forwarded by another replica, which must
+// be served here: the registry may lag behind a session that moved or ended.
+const forwardedHeader = "X-Im-Gateway-Forwarded"
+
+// evictTimeout bounds the removal of the session of an unreachable replica
+// from the registry.
+const evictTimeout = 5 * time.Second
+
+// probeTimeout bounds the connection that confirms a replica is unreachable.
+const probeTimeout = 2 * time.Second
+
+// withSessionOwner serves requests for an upload session on the replica that
+// holds it, forwarding them there if it is another one. uploadID reads the
+// session of the request; requests without one are served here. A session
+// whose replica is unreachable went with it: it is evicted from the registry
+// and reported gone, for the client to start over. Other forwarding failures
+// may be transient and are answered 502, the session kept.
+func (h *Handler) withSessionOwner(uploadID func(r *http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := uploadID(r)
+		if id == "" || r.Header.Get(forwardedHeader) != "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		owner, err := h.sessions.Locate(r.Context(), id)
+		if err != nil {
+			// The session may well be held here.
+			h.logger.Warn("locating an upload session failed", slog.String("upload_id", id), slog.String("error", err.Error()))
+		}
+		if owner == "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+		target, err := url.Parse(owner)
+		if err != nil {
+			h.logger.Error("invalid upload session owner", slog.String("upload_id", id), slog.String("owner", owner))
+			renderError(w, http.StatusBadGateway, "api.bad_gateway", "the replica holding the upload is unavailable")
+
+			return
+		}
+
+		h.logger.Debug("forwarding an upload request", slog.String("upload_id", id), slog.String("owner", owner))
+
+		proxy := &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.SetURL(target)
+				pr.SetXForwarded()
+				pr.Out.Header.Set(forwardedHeader, "1")
+			},
+			Transport: h.forwardTransport,
+			ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+				// The client gave up, not the owner.
+				if r.Context().Err() != nil {
+					return
+				}
+				h.logger.Warn("forwarding an upload request failed",
+					slog.String("upload_id", id), slog.String("owner", owner), slog.String("error", err.Error()))
+
+				if !isUnreachable(err) || !ownerUnreachable(r.Context(), target) {
+					renderError(w, http.StatusBadGateway, "api.bad_gateway", "the replica holding the upload is unavailable")
+
+					return
+				}
+
+				ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), evictTimeout)
+				defer cancel()
+
+				if err := h.sessions.Evict(ctx, id, owner); err != nil {
+					h.logger.Warn("evicting an upload session failed",
+						slog.String("upload_id", id), slog.String("owner", owner), slog.String("error", err.Error()))
+				}
+				renderError(w, http.StatusGone, "api.gone", "the replica holding the upload is gone")
+			},
+		}
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+// isUnreachable reports whether err is a failed dial, refused or without a
+// route. Timeouts, resets and failures to read the body of the client are
+// not: the replica may still be there.
+func isUnreachable(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "dial" {
+		return false
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH)
+}
+
+// ownerUnreachable confirms with a fresh connection that the replica at
+// target cannot be reached, rather than a single dial having failed.
+func ownerUnreachable(ctx context.Context, target *url.URL) bool {
+	port := target.Port()
+	if port == "" {
+		port = "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(target.Hostname(), port))
+	if err != nil {
+		return isUnreachable(err)
+	}
+	_ = conn.Close()
+
+	return false
+}
+
+// queryUploadID reads the session of the PUT, GET and DELETE /media requests.
+func queryUploadID(r *http.Request) string {
+	return r.URL.Query().Get("uploadId")
+}
+
+// pathUploadID reads the session of tus requests.
+func pathUploadID(r *http.Request) string {
+	return r.PathValue("id")
+}
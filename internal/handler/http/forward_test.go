@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// fakeSessions locates every upload at owner, until it is evicted.
+type fakeSessions struct {
+	service.LocalSessions
+
+	owner string
+}
+
+func (s *fakeSessions) Locate(context.Context, string) (string, error) {
+	return s.owner, nil
+}
+
+func (s *fakeSessions) Evict(_ context.Context, _, owner string) error {
+	if owner == s.owner {
+		s.owner = ""
+	}
+
+	return nil
+}
+
+func TestWithSessionOwner(t *testing.T) {
+	owner := newFakeMedia()
+	ownerServer := newMediaServer(t, owner)
+
+	// The front replica holds no session and locates them all at the owner.
+	frontHandler := newMediaHandler(newFakeMedia())
+	frontHandler.sessions = &fakeSessions{owner: ownerServer.URL}
+	front := serveMedia(t, frontHandler)
+
+	info, _ := owner.CreateUpload(t.Context(), &dto.CreateUploadRequest{Name: "notes.txt"})
+
+	req, _ := http.NewRequest(http.MethodPut, front.URL+"/media?uploadId="+info.ID+"&offset=0", strings.NewReader("hello"))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted || string(owner.uploads[info.ID].data) != "hello" {
+		t.Fatalf("forwarded chunk = %d, owner has %q", resp.StatusCode, owner.uploads[info.ID].data)
+	}
+
+	// tus requests follow the session too.
+	req, _ = http.NewRequest(http.MethodHead, front.URL+"/media/"+info.ID, nil)
+	req.Header.Set(tusResumableHeader, tusVersion)
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(uploadOffsetHeader) != "5" {
+		t.Errorf("forwarded HEAD = %d, offset %q", resp.StatusCode, resp.Header.Get(uploadOffsetHeader))
+	}
+
+	// An owner that fails otherwise may only be unavailable for a while.
+	sessions := frontHandler.sessions.(*fakeSessions)
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		_ = conn.Close()
+	}))
+	t.Cleanup(broken.Close)
+	sessions.owner = broken.URL
+	req, _ = http.NewRequest(http.MethodGet, front.URL+"/media?uploadId="+info.ID, nil)
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || sessions.owner != broken.URL {
+		t.Errorf("request for a failing owner = %d, owner %q, want 502 and the session kept", resp.StatusCode, sessions.owner)
+	}
+	sessions.owner = ownerServer.URL
+
+	// The session of an unreachable owner went with it.
+	ownerServer.Close()
+	req, _ = http.NewRequest(http.MethodGet, front.URL+"/media?uploadId="+info.ID, nil)
+	client := http.Client{Timeout: time.Second}
+	if resp, err = client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusGone || sessions.owner != "" {
+		t.Errorf("request for an unreachable owner = %d, owner %q, want 410 and the session evicted", resp.StatusCode, sessions.owner)
+	}
+}
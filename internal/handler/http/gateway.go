@@ -0,0 +1,351 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	// Register the descriptors of gatewayPackages.
+	_ "github.com/webitel/im-gateway-service/gen/go/gateway/ext/v1"
+	_ "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
+	grpcsrv "github.com/webitel/im-gateway-service/infra/server/grpc"
+	httpmw "github.com/webitel/im-gateway-service/infra/server/http/middleware"
+)
+
+// gatewayPackages are the proto packages of the services transcoded to HTTP:
+// the services published in webitel/protos and the RPCs the gateway adds to
+// them.
+var gatewayPackages = []protoreflect.FullName{
+	"webitel.im.api.gateway.v1",
+	"webitel.im.api.gateway.ext.v1",
+}
+
+// pathVariable matches a variable of an HTTP rule path template, e.g.
+// "{thread_id}" or "{name=shelves/*}".
+var pathVariable = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// GatewayHandler serves the gateway gRPC services as REST/JSON, following
+// the google.api.http annotations of their methods. Methods without one are
+// served as POST /{service}/{method} with the request as the body.
+//
+// Calls are made in-process on the registered gRPC services, behind the same
+// authentication and request validation as over gRPC.
+type GatewayHandler struct {
+	logger  *slog.Logger
+	server  invoker
+	authMW  func(http.Handler) http.Handler
+	gateway *runtime.ServeMux
+}
+
+// invoker calls unary gRPC methods in-process, see grpcsrv.Server.Invoke.
+type invoker interface {
+	Invoke(ctx context.Context, fullMethod string, dec func(any) error) (any, error)
+}
+
+func NewGatewayHandler(
+	logger *slog.Logger,
+	server *grpcsrv.Server,
+	authMW func(http.Handler) http.Handler,
+	mux *http.ServeMux,
+) (*GatewayHandler, error) {
+	return newGatewayHandler(logger, server, authMW, mux)
+}
+
+func newGatewayHandler(
+	logger *slog.Logger,
+	server invoker,
+	authMW func(http.Handler) http.Handler,
+	mux *http.ServeMux,
+) (*GatewayHandler, error) {
+	h := &GatewayHandler{
+		logger: logger,
+		server: server,
+		authMW: authMW,
+		gateway: runtime.NewServeMux(
+			runtime.WithIncomingHeaderMatcher(httpmw.IncomingHeaderMatcher),
+			runtime.WithErrorHandler(func(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+				writeError(w, err)
+			}),
+			runtime.WithRoutingErrorHandler(renderRoutingError),
+		),
+	}
+
+	routes, err := gatewayRoutes()
+	if err != nil {
+		return nil, err
+	}
+	if err := h.registerRoutes(mux, routes); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+func (h *GatewayHandler) registerRoutes(mux *http.ServeMux, routes []*gatewayRoute) error {
+	var prefixes []string
+	for _, route := range routes {
+		if err := h.gateway.HandlePath(route.Method, route.Path, h.handle(route)); err != nil {
+			return fmt.Errorf("gateway: route %s %s: %w", route.Method, route.Path, err)
+		}
+
+		// Mount the gateway under the first segment of every route path.
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route.Path, "/"), "/")
+		if prefix := "/" + segment + "/"; !slices.Contains(prefixes, prefix) {
+			prefixes = append(prefixes, prefix)
+			mux.Handle(prefix, h.gateway)
+		}
+	}
+
+	return nil
+}
+
+// handle returns the transcoding handler of route.
+func (h *GatewayHandler) handle(route *gatewayRoute) runtime.HandlerFunc {
+	serve := func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		inbound, outbound := runtime.MarshalerForRequest(h.gateway, r)
+
+		ctx, err := runtime.AnnotateIncomingContext(r.Context(), h.gateway, r, route.FullMethod,
+			runtime.WithHTTPPathPattern(route.Path),
+		)
+		if err != nil {
+			runtime.HTTPError(r.Context(), h.gateway, outbound, w, r, err)
+
+			return
+		}
+
+		var stream runtime.ServerTransportStream
+		ctx = grpc.NewContextWithServerTransportStream(ctx, &stream)
+
+		resp, err := h.server.Invoke(ctx, route.FullMethod, func(in any) error {
+			return route.decode(in.(proto.Message), inbound, r, pathParams)
+		})
+		ctx = runtime.NewServerMetadataContext(ctx, runtime.ServerMetadata{
+			HeaderMD:  stream.Header(),
+			TrailerMD: stream.Trailer(),
+		})
+		if err != nil {
+			runtime.HTTPError(ctx, h.gateway, outbound, w, r, err)
+
+			return
+		}
+
+		runtime.ForwardResponseMessage(ctx, h.gateway, outbound, w, r, route.response(resp.(proto.Message)),
+			h.gateway.GetForwardResponseOptions()...,
+		)
+	}
+
+	if grpcsrv.IsPublicMethod(route.FullMethod) {
+		return serve
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		h.authMW(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serve(w, r, pathParams)
+		})).ServeHTTP(w, r)
+	}
+}
+
+// gatewayRoute is an HTTP binding of a gateway RPC.
+type gatewayRoute struct {
+	Method     string
+	Path       string
+	FullMethod string
+	RPC        protoreflect.MethodDescriptor
+	// Body is the request field bound to the request body: "*" for the
+	// whole request, empty for none.
+	Body string
+	// ResponseBody is the response field written as the response body,
+	// empty for the whole response.
+	ResponseBody string
+
+	bodyField     protoreflect.FieldDescriptor
+	responseField protoreflect.FieldDescriptor
+	// queryFilter lists the fields not taken from the query string.
+	queryFilter *utilities.DoubleArray
+}
+
+// gatewayRoutes returns the HTTP bindings of all unary gateway RPCs, the
+// paths with more variables first. runtime.ServeMux matches the handlers
+// registered last first, so a path with more variables is matched after the
+// literal paths it overlaps, e.g. /v1/threads/{id} after /v1/threads/left.
+func gatewayRoutes() ([]*gatewayRoute, error) {
+	var (
+		routes []*gatewayRoute
+		err    error
+	)
+	for _, pkg := range gatewayPackages {
+		protoregistry.GlobalFiles.RangeFilesByPackage(pkg, func(file protoreflect.FileDescriptor) bool {
+			for i := range file.Services().Len() {
+				methods := file.Services().Get(i).Methods()
+				for j := range methods.Len() {
+					var methodRoutes []*gatewayRoute
+					if methodRoutes, err = rpcRoutes(methods.Get(j)); err != nil {
+						return false
+					}
+					routes = append(routes, methodRoutes...)
+				}
+			}
+
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortFunc(routes, func(a, b *gatewayRoute) int {
+		if c := strings.Count(b.Path, "{") - strings.Count(a.Path, "{"); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Method, b.Method)
+	})
+
+	return routes, nil
+}
+
+func rpcRoutes(rpc protoreflect.MethodDescriptor) ([]*gatewayRoute, error) {
+	if rpc.IsStreamingClient() || rpc.IsStreamingServer() {
+		return nil, nil
+	}
+
+	fullMethod := fmt.Sprintf("/%s/%s", rpc.Parent().FullName(), rpc.Name())
+
+	rule, _ := proto.GetExtension(rpc.Options(), annotations.E_Http).(*annotations.HttpRule)
+	if rule == nil {
+		rule = &annotations.HttpRule{
+			Pattern: &annotations.HttpRule_Post{Post: fullMethod},
+			Body:    "*",
+		}
+	}
+
+	var routes []*gatewayRoute
+	for _, binding := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		route, err := newGatewayRoute(rpc, fullMethod, binding)
+		if err != nil {
+			return nil, fmt.Errorf("gateway: %s: %w", fullMethod, err)
+		}
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+func newGatewayRoute(rpc protoreflect.MethodDescriptor, fullMethod string, rule *annotations.HttpRule) (*gatewayRoute, error) {
+	route := &gatewayRoute{
+		FullMethod:   fullMethod,
+		RPC:          rpc,
+		Body:         rule.GetBody(),
+		ResponseBody: strings.TrimPrefix(rule.GetResponseBody(), "*"),
+	}
+
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		route.Method, route.Path = http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		route.Method, route.Path = http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		route.Method, route.Path = http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		route.Method, route.Path = http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		route.Method, route.Path = http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		route.Method, route.Path = pattern.Custom.GetKind(), pattern.Custom.GetPath()
+	default:
+		return nil, fmt.Errorf("no HTTP method in binding")
+	}
+
+	var filter [][]string
+	for _, match := range pathVariable.FindAllStringSubmatch(route.Path, -1) {
+		filter = append(filter, strings.Split(match[1], "."))
+	}
+
+	if route.Body != "" && route.Body != "*" {
+		field := rpc.Input().Fields().ByName(protoreflect.Name(route.Body))
+		if field == nil || field.Message() == nil || field.IsList() || field.IsMap() {
+			return nil, fmt.Errorf("body %q is not a message field of %s", route.Body, rpc.Input().FullName())
+		}
+		route.bodyField = field
+		filter = append(filter, []string{route.Body})
+	}
+	if route.ResponseBody != "" {
+		field := rpc.Output().Fields().ByName(protoreflect.Name(route.ResponseBody))
+		if field == nil || field.Message() == nil || field.IsList() || field.IsMap() {
+			return nil, fmt.Errorf("response body %q is not a message field of %s", route.ResponseBody, rpc.Output().FullName())
+		}
+		route.responseField = field
+	}
+	route.queryFilter = utilities.NewDoubleArray(filter)
+
+	return route, nil
+}
+
+// decode fills the request msg from the body, path and query of r.
+func (route *gatewayRoute) decode(msg proto.Message, marshaler runtime.Marshaler, r *http.Request, pathParams map[string]string) error {
+	switch {
+	case route.Body == "*":
+		if err := marshaler.NewDecoder(r.Body).Decode(msg); err != nil && !errors.Is(err, io.EOF) {
+			return status.Errorf(codes.InvalidArgument, "decode body: %v", err)
+		}
+	case route.bodyField != nil:
+		body := msg.ProtoReflect().Mutable(route.bodyField).Message().Interface()
+		if err := marshaler.NewDecoder(r.Body).Decode(body); err != nil && !errors.Is(err, io.EOF) {
+			return status.Errorf(codes.InvalidArgument, "decode body: %v", err)
+		}
+	}
+
+	for name, value := range pathParams {
+		if err := runtime.PopulateFieldFromPath(msg, name, value); err != nil {
+			return status.Errorf(codes.InvalidArgument, "path parameter %s: %v", name, err)
+		}
+	}
+
+	if route.Body != "*" {
+		if err := runtime.PopulateQueryParameters(msg, r.URL.Query(), route.queryFilter); err != nil {
+			return status.Errorf(codes.InvalidArgument, "query parameters: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// response returns the part of resp written as the response body.
+func (route *gatewayRoute) response(resp proto.Message) proto.Message {
+	if route.responseField == nil {
+		return resp
+	}
+
+	return resp.ProtoReflect().Get(route.responseField).Message().Interface()
+}
+
+func renderRoutingError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, httpCode int) {
+	id := "api.not_found"
+	switch httpCode {
+	case http.StatusMethodNotAllowed:
+		id = "api.method_not_allowed"
+	case http.StatusBadRequest:
+		id = "api.bad_args"
+	}
+
+	renderError(w, httpCode, id, http.StatusText(httpCode))
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	extpb "github.com/webitel/im-gateway-service/gen/go/gateway/ext/v1"
+	impb "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
+)
+
+// fakeServer invokes services like grpcsrv.Server, without validation.
+type fakeServer map[string]any
+
+func (s fakeServer) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	s[desc.ServiceName] = impl
+	for _, method := range desc.Methods {
+		s["/"+desc.ServiceName+"/"+method.MethodName] = method
+	}
+}
+
+func (s fakeServer) Invoke(ctx context.Context, fullMethod string, dec func(any) error) (any, error) {
+	method, ok := s[fullMethod].(grpc.MethodDesc)
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod)
+	}
+	serviceName, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+
+	return method.Handler(s[serviceName], ctx, dec, nil)
+}
+
+type fakeBots struct {
+	impb.UnimplementedBotsServer
+}
+
+func (fakeBots) DeleteBot(_ context.Context, in *impb.DeleteBotRequest) (*impb.Bot, error) {
+	return &impb.Bot{Id: in.GetId(), Name: "deleted"}, nil
+}
+
+type fakeBotWebhooks struct {
+	extpb.UnimplementedBotsServer
+}
+
+func (fakeBotWebhooks) ListDeliveries(_ context.Context, in *extpb.ListDeliveriesRequest) (*extpb.ListDeliveriesResponse, error) {
+	return &extpb.ListDeliveriesResponse{Items: []*extpb.Delivery{{BotId: in.GetBotId(), Attempts: int32(in.GetSize())}}}, nil
+}
+
+type fakeAccount struct {
+	impb.UnimplementedAccountServer
+}
+
+func (fakeAccount) Inspect(ctx context.Context, _ *impb.InspectRequest) (*impb.Authorization, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	return &impb.Authorization{Name: strings.Join(md.Get("x-webitel-client"), ",")}, nil
+}
+
+func newGatewayServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := fakeServer{}
+	impb.RegisterBotsServer(server, fakeBots{})
+	extpb.RegisterBotsServer(server, fakeBotWebhooks{})
+	impb.RegisterAccountServer(server, fakeAccount{})
+
+	// Only requests with an Authorization header pass.
+	authMW := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	mux := http.NewServeMux()
+	if _, err := newGatewayHandler(slog.New(slog.DiscardHandler), server, authMW, mux); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func do(t *testing.T, method, url string, header http.Header) (int, map[string]any) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header = header
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	var body map[string]any
+	_ = json.Unmarshal(data, &body)
+
+	return resp.StatusCode, body
+}
+
+func TestGatewayHandler_Transcodes(t *testing.T) {
+	ts := newGatewayServer(t)
+	auth := http.Header{"Authorization": {"Bearer token"}, "X-Webitel-Client": {"web"}}
+
+	code, body := do(t, http.MethodDelete, ts.URL+"/v1/bots/b1", auth)
+	if code != http.StatusOK || body["id"] != "b1" || body["name"] != "deleted" {
+		t.Errorf("DELETE /v1/bots/b1 = %d %v", code, body)
+	}
+
+	// An RPC of the gateway's own package on a published service.
+	code, body = do(t, http.MethodGet, ts.URL+"/v1/bots/b1/deliveries?size=5", auth)
+	if items, _ := body["items"].([]any); code != http.StatusOK || len(items) != 1 ||
+		items[0].(map[string]any)["botId"] != "b1" || items[0].(map[string]any)["attempts"] != float64(5) {
+		t.Errorf("GET /v1/bots/b1/deliveries = %d %v", code, body)
+	}
+
+	code, body = do(t, http.MethodGet, ts.URL+"/v1/auth/token", auth)
+	if code != http.StatusOK || body["name"] != "web" {
+		t.Errorf("GET /v1/auth/token = %d %v, want the client header bridged", code, body)
+	}
+
+	code, _ = do(t, http.MethodDelete, ts.URL+"/v1/bots/b1", http.Header{})
+	if code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated DELETE /v1/bots/b1 = %d", code)
+	}
+}
+
+func TestGatewayHandler_RendersErrors(t *testing.T) {
+	ts := newGatewayServer(t)
+	auth := http.Header{"Authorization": {"Bearer token"}}
+
+	// Registered route of a service this server does not implement.
+	code, body := do(t, http.MethodPost, ts.URL+"/v1/bots", auth)
+	if code != http.StatusNotImplemented || body["id"] != "api.not_implemented" {
+		t.Errorf("POST /v1/bots = %d %v", code, body)
+	}
+
+	// Token is public: unimplemented rather than unauthenticated.
+	code, _ = do(t, http.MethodPost, ts.URL+"/v1/auth/token", http.Header{})
+	if code != http.StatusNotImplemented {
+		t.Errorf("unauthenticated POST /v1/auth/token = %d", code)
+	}
+
+	code, body = do(t, http.MethodGet, ts.URL+"/v1/nowhere", auth)
+	if code != http.StatusNotFound || body["id"] != "api.not_found" {
+		t.Errorf("GET /v1/nowhere = %d %v", code, body)
+	}
+}
+
+func TestGatewayRoutes_LiteralPathsMatchFirst(t *testing.T) {
+	routes, err := gatewayRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := func(method, path string) int {
+		for i, route := range routes {
+			if route.Method == method && route.Path == path {
+				return i
+			}
+		}
+		t.Fatalf("no route %s %s", method, path)
+
+		return -1
+	}
+
+	// The mux tries the routes registered last first.
+	if index(http.MethodGet, "/v1/threads/left") < index(http.MethodGet, "/v1/threads/{id}") {
+		t.Error("/v1/threads/{id} would shadow /v1/threads/left")
+	}
+	index(http.MethodPost, "/webitel.im.api.gateway.v1.ViasService/Search")
+}
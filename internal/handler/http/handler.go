@@ -1,47 +1,98 @@
 package http
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/webitel/im-gateway-service/config"
 	"github.com/webitel/im-gateway-service/infra/auth"
+	apptls "github.com/webitel/im-gateway-service/infra/tls"
 	"github.com/webitel/im-gateway-service/internal/service"
 )
 
 // Handler registers and serves HTTP endpoints for the IM media API.
 type Handler struct {
-	logger *slog.Logger
-	media  service.Media
+	logger        *slog.Logger
+	media         service.Media
+	thumbnails    service.Thumbnailer
+	links         service.MediaLinker
+	sessions      service.SessionRegistry
+	maxUploadSize int64
+	// cacheControl is the Cache-Control of downloads per MIME family.
+	cacheControl map[string]string
+	// linkBaseURL is the public base URL signed links point at, without a
+	// trailing slash.
+	linkBaseURL string
+	// forwardTransport carries requests to the replica holding an upload,
+	// http.DefaultTransport if nil.
+	forwardTransport http.RoundTripper
 }
 
 func NewHandler(
 	logger *slog.Logger,
+	cfg *config.Config,
 	media service.Media,
+	thumbnails service.Thumbnailer,
+	links service.MediaLinker,
+	sessions service.SessionRegistry,
 	authMW func(http.Handler) http.Handler,
 	bodyLimitMW func(http.Handler) http.Handler,
 	mux *http.ServeMux,
-) *Handler {
+) (*Handler, error) {
 	h := &Handler{
-		logger: logger,
-		media:  media,
+		logger:        logger,
+		media:         media,
+		thumbnails:    thumbnails,
+		links:         links,
+		sessions:      sessions,
+		maxUploadSize: cfg.Service.MaxUploadSize,
+		cacheControl:  cfg.Service.Media.CacheControl,
+		linkBaseURL:   strings.TrimSuffix(cfg.Service.Media.LinkBaseURL, "/"),
+	}
+
+	// Replicas serve each other with the certificates they serve clients
+	// with.
+	if cfg.Service.HTTP.VerifyCerts {
+		tlsCfg, err := apptls.Load(cfg.Service.HTTP.TLS, tls.NoClientCert)
+		if err != nil {
+			return nil, err
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsCfg
+		h.forwardTransport = transport
 	}
 	h.registerRoutes(mux, authMW, bodyLimitMW)
 
-	return h
+	return h, nil
 }
 
 func (h *Handler) registerRoutes(mux *http.ServeMux, authMW, bodyLimitMW func(http.Handler) http.Handler) {
 	mux.Handle("GET /media/{id}/download", authMW(http.HandlerFunc(h.downloadFile)))
 	mux.Handle("GET /media/{id}/stream", authMW(http.HandlerFunc(h.streamFile)))
-	mux.Handle("GET /media", authMW(http.HandlerFunc(h.getUploadFileInfo)))
-	mux.Handle("PUT /media", authMW(bodyLimitMW(http.HandlerFunc(h.uploadFile))))
+	mux.Handle("GET /media/{id}/thumbnail", authMW(http.HandlerFunc(h.thumbnail)))
+	// Upload sessions are served by the replica that holds them.
+	mux.Handle("GET /media", authMW(h.withSessionOwner(queryUploadID, http.HandlerFunc(h.getUploadFileInfo))))
+	mux.Handle("PUT /media", authMW(h.withSessionOwner(queryUploadID, bodyLimitMW(http.HandlerFunc(h.uploadFile)))))
 	mux.Handle("POST /media", authMW(http.HandlerFunc(h.createUploadSession)))
-	mux.Handle("DELETE /media", authMW(http.HandlerFunc(h.terminateUploadSession)))
+	mux.Handle("DELETE /media", authMW(h.withSessionOwner(queryUploadID, http.HandlerFunc(h.terminateUploadSession))))
+	mux.Handle("POST /media/upload", authMW(bodyLimitMW(http.HandlerFunc(h.uploadMultipart))))
+	mux.Handle("POST /media/{id}/link", authMW(http.HandlerFunc(h.signLink)))
+
+	// Signed links carry their own authorization.
+	mux.HandleFunc("GET /media/signed/{id}/{token}", h.signedDownload)
+
+	// tus 1.0: creation is POST /media with a Tus-Resumable header.
+	mux.HandleFunc("OPTIONS /media", h.tusOptions)
+	mux.Handle("HEAD /media/{id}", authMW(h.withSessionOwner(pathUploadID, withTus(h.tusHead))))
+	mux.Handle("PATCH /media/{id}", authMW(h.withSessionOwner(pathUploadID, bodyLimitMW(withTus(h.tusPatch)))))
+	mux.Handle("DELETE /media/{id}", authMW(h.withSessionOwner(pathUploadID, withTus(h.tusTerminate))))
 }
 
 type apiError struct {
@@ -63,7 +114,7 @@ func renderError(w http.ResponseWriter, httpCode int, id, detail string) {
 	})
 }
 
-func (h *Handler) writeError(w http.ResponseWriter, err error) {
+func writeError(w http.ResponseWriter, err error) {
 	var (
 		httpCode int
 		id       string
@@ -81,12 +132,46 @@ func (h *Handler) writeError(w http.ResponseWriter, err error) {
 	case errors.Is(err, service.ErrSessionNotFound):
 		httpCode = http.StatusNotFound
 		id = "api.not_found"
-	case errors.Is(err, service.ErrSessionConflict), errors.Is(err, service.ErrSessionDone):
+	case errors.Is(err, service.ErrUploadLengthExceeded):
+		httpCode = http.StatusRequestEntityTooLarge
+		id = "api.request_too_large"
+	case errors.Is(err, service.ErrSessionConflict), errors.Is(err, service.ErrSessionDone),
+		errors.Is(err, service.ErrOffsetMismatch), errors.Is(err, service.ErrUploadLengthMismatch):
 		httpCode = http.StatusConflict
 		id = "api.conflict"
-	case errors.Is(err, service.ErrEmptyBody):
+	case errors.Is(err, service.ErrEmptyBody), errors.Is(err, service.ErrUploadIncomplete):
 		httpCode = http.StatusBadRequest
 		id = "api.bad_args"
+	case errors.Is(err, service.ErrUnsupportedImage), errors.Is(err, service.ErrUnsupportedMedia):
+		httpCode = http.StatusUnsupportedMediaType
+		id = "api.unsupported_media"
+	case errors.Is(err, service.ErrQuotaExceeded):
+		httpCode = http.StatusForbidden
+		id = "api.quota_exceeded"
+	case errors.Is(err, service.ErrFileInfected):
+		httpCode = http.StatusUnprocessableEntity
+		id = "api.file_infected"
+	case errors.Is(err, service.ErrScanFailed):
+		httpCode = http.StatusServiceUnavailable
+		id = "api.unavailable"
+	case errors.Is(err, service.ErrChecksumMismatch):
+		httpCode = http.StatusUnprocessableEntity
+		id = "api.checksum_mismatch"
+	case errors.Is(err, service.ErrStorageChecksumMismatch):
+		httpCode = http.StatusBadGateway
+		id = "api.bad_gateway"
+	case errors.Is(err, service.ErrImageTooLarge):
+		httpCode = http.StatusUnprocessableEntity
+		id = "api.unprocessable"
+	case errors.Is(err, service.ErrLinkInvalid), errors.Is(err, service.ErrLinkExpired):
+		httpCode = http.StatusForbidden
+		id = "api.forbidden"
+	case errors.Is(err, service.ErrLinksDisabled):
+		httpCode = http.StatusNotImplemented
+		id = "api.not_implemented"
+	case errors.Is(err, service.ErrUpdaterClosed), errors.Is(err, service.ErrDraining):
+		httpCode = http.StatusServiceUnavailable
+		id = "api.unavailable"
 	default:
 		if st, ok := status.FromError(err); ok {
 			switch st.Code() {
@@ -105,6 +190,12 @@ func (h *Handler) writeError(w http.ResponseWriter, err error) {
 			case codes.AlreadyExists:
 				httpCode = http.StatusConflict
 				id = "api.conflict"
+			case codes.ResourceExhausted:
+				httpCode = http.StatusTooManyRequests
+				id = "api.too_many_requests"
+			case codes.Unimplemented:
+				httpCode = http.StatusNotImplemented
+				id = "api.not_implemented"
 			default:
 				httpCode = http.StatusInternalServerError
 				id = "api.internal"
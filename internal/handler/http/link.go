@@ -0,0 +1,111 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// maxSignLinkBody bounds the JSON body of POST /media/{id}/link.
+const maxSignLinkBody = 4 << 10
+
+// signLink issues a signed link to a file that can be opened without
+// credentials until it expires.
+func (h *Handler) signLink(w http.ResponseWriter, r *http.Request) {
+	fileID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid file id")
+
+		return
+	}
+
+	var body dto.SignLinkBody
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxSignLinkBody)).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid request body")
+
+		return
+	}
+	if body.TTLSeconds < 0 {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid ttlSeconds")
+
+		return
+	}
+
+	link, err := h.links.SignLink(r.Context(), &dto.SignLinkRequest{
+		FileID:      fileID,
+		TTL:         time.Duration(body.TTLSeconds) * time.Second,
+		Disposition: body.Disposition,
+	})
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := json.NewEncoder(w).Encode(dto.SignedLinkResponse{
+		URL:       fmt.Sprintf("%s/media/signed/%d/%s", h.linkBaseURL, link.FileID, url.PathEscape(link.Token)),
+		ExpiresAt: link.ExpiresAt,
+	}); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// signedDownload serves the file of a signed link, ranges included. The link
+// is its only authorization; responses may be cached privately until it
+// expires. Only media is shown inline: anything else, which a browser could
+// run as a page of the gateway, is always downloaded.
+func (h *Handler) signedDownload(w http.ResponseWriter, r *http.Request) {
+	fileID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid file id")
+
+		return
+	}
+
+	ctx, link, err := h.links.Authorize(r.Context(), fileID, r.PathValue("token"))
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	h.serveFile(w, r, func(offset int64) (*dto.FileDownloadResult, error) {
+		return h.media.Download(ctx, &dto.MediaDownloadRequest{FileID: fileID, Offset: offset})
+	}, func(meta *dto.FileMetadata) {
+		maxAge := max(int64(time.Until(link.ExpiresAt)/time.Second), 0)
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+
+		disposition := link.Disposition
+		if !inlineSafe(meta.MimeType) {
+			disposition = dto.MediaDispositionAttachment
+		}
+		w.Header().Set("Content-Disposition",
+			mime.FormatMediaType(string(disposition), map[string]string{"filename": meta.Name}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+	})
+}
+
+// inlineSafe reports whether files of mimeType may be shown inline: images,
+// audio and video, but not SVG images, which may hold scripts.
+func inlineSafe(mimeType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	if mediaType == "image/svg+xml" {
+		return false
+	}
+	family, _, _ := strings.Cut(mediaType, "/")
+
+	return family == "image" || family == "audio" || family == "video"
+}
@@ -1,16 +1,23 @@
 package http
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"strconv"
 	"strings"
 
 	"github.com/webitel/webitel-go-kit/pkg/errors"
 
+	"github.com/webitel/im-gateway-service/internal/service"
 	"github.com/webitel/im-gateway-service/internal/service/dto"
 )
 
@@ -28,12 +35,19 @@ func (h *Handler) downloadFile(w http.ResponseWriter, r *http.Request) {
 		Offset: 0,
 	})
 	if err != nil {
-		h.writeError(w, err)
+		writeError(w, err)
 
 		return
 	}
 	defer result.Body.Close()
 
+	h.setCacheHeaders(w, fileETag(result.Metadata), result.Metadata.MimeType)
+	if notModified(r, fileETag(result.Metadata)) {
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
 	w.Header().Set("Content-Type", result.Metadata.MimeType)
 	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, result.Metadata.Name))
 
@@ -42,11 +56,13 @@ func (h *Handler) downloadFile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if _, err := io.Copy(w, result.Body); err != nil {
-		h.writeError(w, err)
+		writeError(w, err)
 	}
 }
 
-// streamFile supports Range-based partial downloads.
+// streamFile serves a file or ranges of it (RFC 9110, section 14): a single
+// range as 206 with Content-Range, several as multipart/byteranges. The
+// storage download starts at the first requested byte.
 func (h *Handler) streamFile(w http.ResponseWriter, r *http.Request) {
 	fileID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
@@ -55,82 +71,281 @@ func (h *Handler) streamFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var (
-		offset         int64
-		end            int64
-		isRangeRequest bool
-	)
+	h.serveFile(w, r, func(offset int64) (*dto.FileDownloadResult, error) {
+		return h.media.Download(r.Context(), &dto.MediaDownloadRequest{FileID: fileID, Offset: offset})
+	}, nil)
+}
 
-	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
-		var parseErr error
+// serveFile serves the file download opens at an offset, honouring Range,
+// If-Range and If-None-Match. setHeaders, if not nil, may add to the headers
+// of the response once the file is known.
+func (h *Handler) serveFile(
+	w http.ResponseWriter,
+	r *http.Request,
+	download func(offset int64) (*dto.FileDownloadResult, error),
+	setHeaders func(meta *dto.FileMetadata),
+) {
+	specs, isRangeRequest := parseRange(r.Header.Get("Range"))
+
+	var offset int64
+	if isRangeRequest {
+		offset = rangeOffset(specs)
+	}
 
-		offset, end, parseErr = parseRangeStart(rangeHeader)
-		if parseErr != nil {
-			renderError(w, http.StatusRequestedRangeNotSatisfiable, "api.bad_args", "invalid Range header")
+	result, err := download(offset)
+	if err != nil {
+		writeError(w, err)
 
-			return
+		return
+	}
+	// source is the download being read, nil once it is closed.
+	source := result.Body
+	defer func() {
+		if source != nil {
+			source.Close()
 		}
+	}()
 
-		isRangeRequest = true
+	meta := result.Metadata
+	etag := fileETag(meta)
+	h.setCacheHeaders(w, etag, meta.MimeType)
+	if setHeaders != nil {
+		setHeaders(meta)
 	}
-
-	result, err := h.media.Download(r.Context(), &dto.MediaDownloadRequest{
-		FileID: fileID,
-		Offset: offset,
-	})
-	if err != nil {
-		h.writeError(w, err)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
 
 		return
 	}
-	defer result.Body.Close()
 
-	w.Header().Set("Content-Type", result.Metadata.MimeType)
 	w.Header().Set("Accept-Ranges", "bytes")
 
-	if isRangeRequest {
-		if result.Metadata.Size > 0 && offset >= result.Metadata.Size {
-			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", result.Metadata.Size))
+	// A range of another version of the file is of no use to the client:
+	// send the whole file instead.
+	var ranges []byteRange
+	if isRangeRequest && ifRangeMatches(r, etag) {
+		if ranges = resolveRanges(specs, meta.Size); len(ranges) == 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", meta.Size))
 			renderError(w, http.StatusRequestedRangeNotSatisfiable, "api.bad_range", "requested range not satisfiable")
 
 			return
 		}
+		if len(ranges) > maxRanges {
+			ranges = nil
+		}
+	}
 
-		actualEnd := end
-		if actualEnd < 0 || actualEnd >= result.Metadata.Size {
-			actualEnd = result.Metadata.Size - 1
+	// Bring the download to the first byte to send: reopen it if it is past
+	// that byte (suffix ranges, If-Range) or far behind it.
+	var start int64
+	if len(ranges) > 0 {
+		start = ranges[0].start
+	}
+	if start < offset || start-offset > rangeSkipLimit {
+		source.Close()
+		source = nil
+
+		offset = start
+		reopened, err := download(offset)
+		if err != nil {
+			writeError(w, err)
+
+			return
 		}
+		source = reopened.Body
+	}
+	body := &skipReader{r: source, pos: offset}
 
-		bytesToRead := actualEnd - offset + 1
+	switch len(ranges) {
+	case 0:
+		w.Header().Set("Content-Type", meta.MimeType)
+		if meta.Size > 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(meta.Size, 10))
+		}
 
-		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, actualEnd, result.Metadata.Size))
-		w.Header().Set("Content-Length", strconv.FormatInt(bytesToRead, 10))
+		if _, err := io.Copy(w, body); err != nil {
+			h.logger.Error("copying download storage result", "error", err)
+		}
+	case 1:
+		w.Header().Set("Content-Type", meta.MimeType)
+		w.Header().Set("Content-Range", contentRange(ranges[0], meta.Size))
+		w.Header().Set("Content-Length", strconv.FormatInt(ranges[0].length(), 10))
 		w.WriteHeader(http.StatusPartialContent)
 
-		reader := io.LimitReader(result.Body, bytesToRead)
-		if _, err := io.Copy(w, reader); err != nil {
-			h.logger.Error("copying result body into limit reader response", "error", err)
+		if err := body.copyRange(w, ranges[0]); err != nil {
+			h.logger.Error("copying range of download storage result", "error", err)
+		}
+	default:
+		parts := multipart.NewWriter(w)
+		w.Header().Set("Content-Type", "multipart/byteranges; boundary="+parts.Boundary())
+		w.WriteHeader(http.StatusPartialContent)
 
-			return
+		for _, rng := range ranges {
+			part, err := parts.CreatePart(textproto.MIMEHeader{
+				"Content-Type":  {meta.MimeType},
+				"Content-Range": {contentRange(rng, meta.Size)},
+			})
+			if err == nil {
+				err = body.copyRange(part, rng)
+			}
+			if err != nil {
+				h.logger.Error("copying ranges of download storage result", "error", err)
+
+				return
+			}
 		}
 
+		if err := parts.Close(); err != nil {
+			h.logger.Error("closing multipart/byteranges response", "error", err)
+		}
+	}
+}
+
+func contentRange(r byteRange, size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.end, size)
+}
+
+// skipReader reads a download forward from pos, dropping the bytes between
+// ranges.
+type skipReader struct {
+	r   io.Reader
+	pos int64
+}
+
+func (s *skipReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.pos += int64(n)
+
+	return n, err
+}
+
+// copyRange copies rng to w. rng must not start before the current position.
+func (s *skipReader) copyRange(w io.Writer, rng byteRange) error {
+	if skip := rng.start - s.pos; skip > 0 {
+		if _, err := io.CopyN(io.Discard, s, skip); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.CopyN(w, s, rng.length())
+
+	return err
+}
+
+// thumbnail serves a downscaled copy of an image file, at most w by h pixels
+// fitted as fit says (contain, cover or fill).
+func (h *Handler) thumbnail(w http.ResponseWriter, r *http.Request) {
+	fileID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid file id")
+
 		return
 	}
 
-	if result.Metadata.Size > 0 {
-		w.Header().Set("Content-Length", strconv.FormatInt(result.Metadata.Size, 10))
+	query := r.URL.Query()
+	req := &dto.ThumbnailRequest{FileID: fileID, Fit: dto.ThumbnailFit(query.Get("fit"))}
+	for param, dim := range map[string]*int{"w": &req.Width, "h": &req.Height} {
+		if value := query.Get(param); value != "" {
+			if *dim, err = strconv.Atoi(value); err != nil {
+				renderError(w, http.StatusBadRequest, "api.bad_args", "invalid "+param)
+
+				return
+			}
+		}
 	}
 
-	if _, err := io.Copy(w, result.Body); err != nil {
-		h.logger.Error("copying download storage result", "error", err)
-		h.writeError(w, err)
+	thumb, err := h.thumbnails.Thumbnail(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	// The thumbnail changes with the file and with the request.
+	var etag string
+	if thumb.SourceHash != "" {
+		etag = fmt.Sprintf(`"%s-%dx%d-%s"`, thumb.SourceHash, req.Width, req.Height, req.Fit)
+	}
+	h.setCacheHeaders(w, etag, thumb.MimeType)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
 
 		return
 	}
+
+	w.Header().Set("Content-Type", thumb.MimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(thumb.Data)))
+
+	if _, err := w.Write(thumb.Data); err != nil {
+		h.logger.Error("writing thumbnail", "error", err)
+	}
+}
+
+// fileETag is the strong entity tag of a file, derived from its SHA-256. It is
+// empty for files storage has no hash of.
+func fileETag(meta *dto.FileMetadata) string {
+	if meta.Hash == "" {
+		return ""
+	}
+
+	return `"` + meta.Hash + `"`
+}
+
+// setCacheHeaders sets the ETag of a download and the Cache-Control
+// configured for the MIME family of its content.
+func (h *Handler) setCacheHeaders(w http.ResponseWriter, etag, mimeType string) {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	family, _, _ := strings.Cut(mimeType, "/")
+	cacheControl, ok := h.cacheControl[family]
+	if !ok {
+		cacheControl = h.cacheControl["default"]
+	}
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+}
+
+// notModified reports whether If-None-Match lists etag. The comparison is
+// weak, as RFC 9110 requires for If-None-Match.
+func notModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" || etag == "" {
+		return false
+	}
+
+	for tag := range strings.SplitSeq(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifRangeMatches reports whether the Range of r may be served: there is no
+// If-Range, or it names etag. The comparison is strong; dates never match, as
+// storage keeps no modification time.
+func ifRangeMatches(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Range")
+	if header == "" {
+		return true
+	}
+
+	return etag != "" && header == etag
 }
 
 // createUploadSession establish session to upload file
 func (h *Handler) createUploadSession(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(tusResumableHeader) != "" {
+		withTus(h.tusCreate).ServeHTTP(w, r)
+
+		return
+	}
+
 	var req dto.CreateUploadSessionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid request body")
@@ -141,7 +356,7 @@ func (h *Handler) createUploadSession(w http.ResponseWriter, r *http.Request) {
 	uploadID, err := h.media.CreateUploadSession(r.Context(), req.Name)
 	if err != nil {
 		h.logger.Error("failed to create upload session", slog.String("error", err.Error()))
-		h.writeError(w, err)
+		writeError(w, err)
 
 		return
 	}
@@ -165,7 +380,7 @@ func (h *Handler) getUploadFileInfo(w http.ResponseWriter, r *http.Request) {
 	size, err := h.media.GetUploadFileInfo(r.Context(), uploadID)
 	if err != nil {
 		h.logger.Error("failed to get upload file info", slog.String("error", err.Error()))
-		h.writeError(w, err)
+		writeError(w, err)
 
 		return
 	}
@@ -177,83 +392,329 @@ func (h *Handler) getUploadFileInfo(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// uploadFile forwards file to storage
+// uploadFile forwards file to storage. Without a Content-Range header or an
+// offset query parameter the body is the whole file, and Upload-Checksum may
+// declare its checksum. Otherwise it continues
+// the upload from that offset, and the file is stored once its declared total
+// length (Content-Range "bytes START-END/TOTAL") is reached or after a body
+// sent with final=true. A partial upload is answered 202 with the offset.
 func (h *Handler) uploadFile(w http.ResponseWriter, r *http.Request) {
-	uploadID := r.URL.Query().Get("uploadId")
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
 	if uploadID == "" {
 		renderError(w, http.StatusBadRequest, "api.bad_args", "missing uploadId")
 
 		return
 	}
 
-	meta, err := h.media.AppendContent(r.Context(), uploadID, r.Body)
+	whole := r.Header.Get("Content-Range") == "" && !query.Has("offset")
+	checksum, err := parseChecksum(r.Header, whole)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", err.Error())
+
+		return
+	}
+
+	if whole && checksum == nil {
+		meta, err := h.media.AppendContent(r.Context(), uploadID, r.Body)
+		if err != nil {
+			writeError(w, err)
+
+			return
+		}
+
+		h.writeUploadedFile(w, meta)
+
+		return
+	}
+
+	var req *dto.WriteUploadRequest
+	if whole {
+		// The rest of the file, as AppendContent would take it.
+		info, err := h.media.GetUpload(r.Context(), uploadID)
+		if err != nil {
+			writeError(w, err)
+
+			return
+		}
+		req = &dto.WriteUploadRequest{Offset: info.Offset, Body: r.Body, Final: true}
+	} else if req, err = parseUploadChunk(r); err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", err.Error())
+
+		return
+	}
+	req.UploadID = uploadID
+	req.Checksum = checksum
+
+	res, err := h.media.WriteUpload(r.Context(), req)
 	if err != nil {
-		h.writeError(w, err)
+		// Tell the client where to resume from.
+		if errors.Is(err, service.ErrOffsetMismatch) {
+			if info, infoErr := h.media.GetUpload(r.Context(), uploadID); infoErr == nil {
+				w.Header().Set(uploadOffsetHeader, strconv.FormatInt(info.Offset, 10))
+			}
+		}
+		writeError(w, err)
+
+		return
+	}
+
+	if res.File != nil {
+		h.writeUploadedFile(w, res.File)
 
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(res.Offset, 10))
+	w.WriteHeader(http.StatusAccepted)
 
-	if err := json.NewEncoder(w).Encode(dto.SuccessfullyUploadResponse{
-		FileID:   meta.ID,
-		Name:     meta.Name,
-		MimeType: meta.MimeType,
-		Size:     meta.Size,
-		Hash:     meta.Hash,
-	}); err != nil {
+	if err := json.NewEncoder(w).Encode(dto.FileInfoResponse{UploadID: uploadID, Size: res.Offset}); err != nil {
 		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
 	}
 }
 
-func (h *Handler) terminateUploadSession(w http.ResponseWriter, r *http.Request) {
-	uploadID := r.URL.Query().Get("uploadId")
-	if uploadID == "" {
-		renderError(w, http.StatusBadRequest, "api.bad_args", "missing uploadId")
+// uploadMultipart stores every file of a multipart/form-data body, one upload
+// session each, and returns them in the order of the parts. Parts are streamed
+// to storage as they are read; fields that are not files are skipped. The
+// form is stored as a whole or not at all: the files stored before a part
+// fails are deleted.
+func (h *Handler) uploadMultipart(w http.ResponseWriter, r *http.Request) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "expected a multipart/form-data body")
 
 		return
 	}
 
-	if err := h.media.TerminateUploadSession(uploadID); err != nil {
-		h.logger.Error("failed to terminate upload session", slog.String("error", err.Error()))
-		h.writeError(w, err)
+	files := make([]dto.SuccessfullyUploadResponse, 0, 1)
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			h.deleteUploaded(r, files)
+			writeError(w, err)
+
+			return
+		}
+
+		if part.FileName() == "" {
+			part.Close()
+
+			continue
+		}
+
+		meta, err := h.media.Upload(r.Context(), part.FileName(), part)
+		part.Close()
+		if err != nil {
+			h.logger.Error("failed to upload multipart file",
+				slog.String("name", part.FileName()), slog.Int("uploaded", len(files)), slog.String("error", err.Error()))
+			h.deleteUploaded(r, files)
+			writeError(w, err)
+
+			return
+		}
+
+		files = append(files, dto.SuccessfullyUploadResponse{
+			FileID:   meta.ID,
+			Name:     meta.Name,
+			MimeType: meta.MimeType,
+			Size:     meta.Size,
+			Hash:     meta.Hash,
+		})
+	}
+
+	if len(files) == 0 {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "no file in the form")
 
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(files); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
 }
 
-// parseRangeStart extracts the start byte offset from a Range header value.
-// Accepts "bytes=START-" and "bytes=START-END" formats.
-func parseRangeStart(rangeHeader string) (int64, int64, error) {
-	const prefix = "bytes="
-	if !strings.HasPrefix(rangeHeader, prefix) {
-		return 0, 0, errors.InvalidArgument("unsupported range unit", errors.WithID("http.media.parse_range_stat"))
+// deleteUploaded deletes the files of a multipart upload that failed.
+// Failures are logged only: the request has failed either way.
+func (h *Handler) deleteUploaded(r *http.Request, files []dto.SuccessfullyUploadResponse) {
+	if len(files) == 0 {
+		return
 	}
 
-	spec := strings.TrimPrefix(rangeHeader, prefix)
+	ids := make([]string, 0, len(files))
+	for _, file := range files {
+		ids = append(ids, file.FileID)
+	}
+	if err := h.media.DeleteFiles(r.Context(), ids...); err != nil {
+		h.logger.Error("failed to delete the files of a failed multipart upload",
+			slog.Any("file_ids", ids), slog.String("error", err.Error()))
+	}
+}
 
-	parts := strings.SplitN(spec, "-", 2)
-	if len(parts) == 0 || parts[0] == "" {
-		return 0, 0, errors.InvalidArgument("missing range start", errors.WithID("http.media.parse_range_start"))
+// parseUploadChunk reads the offset, declared length and final flag of a
+// PUT /media chunk, from Content-Range or else the offset and final query
+// parameters.
+func parseUploadChunk(r *http.Request) (*dto.WriteUploadRequest, error) {
+	query := r.URL.Query()
+	req := &dto.WriteUploadRequest{Body: r.Body}
+
+	var err error
+	if final := query.Get("final"); final != "" {
+		if req.Final, err = strconv.ParseBool(final); err != nil {
+			return nil, errors.New("invalid final")
+		}
 	}
 
-	endRange := int64(-1)
+	contentRange := r.Header.Get("Content-Range")
+	if contentRange == "" {
+		if req.Offset, err = strconv.ParseInt(query.Get("offset"), 10, 64); err != nil || req.Offset < 0 {
+			return nil, errors.New("invalid offset")
+		}
 
-	if len(parts) == 2 && parts[1] != "" {
-		end, err := strconv.ParseInt(parts[1], 10, 64)
-		if err != nil {
-			return 0, 0, errors.InvalidArgument("invalid end range value", errors.WithCause(err), errors.WithID("http.media.parse_range_start"))
+		return req, nil
+	}
+
+	// bytes START-END/TOTAL, TOTAL being * while unknown.
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return nil, errors.New("invalid Content-Range")
+	}
+	span, total, _ := strings.Cut(spec, "/")
+	first, last, _ := strings.Cut(span, "-")
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil, errors.New("invalid Content-Range")
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return nil, errors.New("invalid Content-Range")
+	}
+	if total != "*" {
+		if req.Length, err = strconv.ParseInt(total, 10, 64); err != nil || req.Length <= end {
+			return nil, errors.New("invalid Content-Range")
+		}
+	}
+	if r.ContentLength >= 0 && r.ContentLength != end-start+1 {
+		return nil, errors.New("body length does not match Content-Range")
+	}
+	req.Offset = start
+
+	return req, nil
+}
+
+// parseChecksum reads the checksum a client expects of the whole file, from
+// fileChecksumHeader ("sha256 <base64>" or "crc32c <base64>"), then
+// Upload-Checksum in the same format if the body is the whole file, or else
+// from Digest ("sha-256=<base64>", "crc32c=<base64>"). Digest algorithms the
+// gateway does not compute are ignored, as RFC 3230 allows. Upload-Checksum
+// is not read from a part of the file: tus gives it to the body of one
+// request, not to the file.
+func parseChecksum(header http.Header, whole bool) (*dto.Checksum, error) {
+	names := []string{fileChecksumHeader}
+	if whole {
+		names = append(names, uploadChecksumHeader)
+	}
+	for _, name := range names {
+		value := header.Get(name)
+		if value == "" {
+			continue
 		}
 
-		endRange = end
+		algorithm, sum, _ := strings.Cut(strings.TrimSpace(value), " ")
+		checksum, ok := decodeChecksum(strings.ToLower(algorithm), strings.TrimSpace(sum))
+		if !ok {
+			return nil, errors.New("invalid " + name)
+		}
+
+		return checksum, nil
 	}
 
-	startRange, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, 0, errors.InvalidArgument("invalid start range value", errors.WithCause(err), errors.WithID("http.media.parse_range_start"))
+	var found *dto.Checksum
+	for digest := range strings.SplitSeq(header.Get("Digest"), ",") {
+		algorithm, sum, ok := strings.Cut(strings.TrimSpace(digest), "=")
+		if !ok {
+			continue
+		}
+		switch algorithm = strings.ToLower(algorithm); algorithm {
+		case "sha-256":
+			algorithm = string(dto.ChecksumSHA256)
+		case string(dto.ChecksumCRC32C):
+		default:
+			continue
+		}
+
+		checksum, ok := decodeChecksum(algorithm, sum)
+		if !ok {
+			return nil, errors.New("invalid Digest")
+		}
+		// sha256 is the stronger of the two.
+		if found == nil || checksum.Algorithm == dto.ChecksumSHA256 {
+			found = checksum
+		}
+	}
+
+	return found, nil
+}
+
+// decodeChecksum decodes a base64 or hex sum of the algorithm.
+func decodeChecksum(algorithm, encoded string) (*dto.Checksum, bool) {
+	var size int
+	switch dto.ChecksumAlgorithm(algorithm) {
+	case dto.ChecksumSHA256:
+		size = sha256.Size
+	case dto.ChecksumCRC32C:
+		size = crc32.Size
+	default:
+		return nil, false
+	}
+
+	// A hex string never decodes from base64 to the size of the sum.
+	sum, err := hex.DecodeString(encoded)
+	if err != nil || len(sum) != size {
+		sum, err = base64.StdEncoding.DecodeString(encoded)
+	}
+	if err != nil || len(sum) != size {
+		return nil, false
 	}
 
-	return int64(startRange), endRange, nil
+	return &dto.Checksum{Algorithm: dto.ChecksumAlgorithm(algorithm), Sum: sum}, true
+}
+
+// writeUploadedFile renders a file stored by an upload.
+func (h *Handler) writeUploadedFile(w http.ResponseWriter, meta *dto.FileMetadata) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(dto.SuccessfullyUploadResponse{
+		FileID:   meta.ID,
+		Name:     meta.Name,
+		MimeType: meta.MimeType,
+		Size:     meta.Size,
+		Hash:     meta.Hash,
+	}); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+func (h *Handler) terminateUploadSession(w http.ResponseWriter, r *http.Request) {
+	uploadID := r.URL.Query().Get("uploadId")
+	if uploadID == "" {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "missing uploadId")
+
+		return
+	}
+
+	if err := h.media.TerminateUploadSession(uploadID); err != nil {
+		h.logger.Error("failed to terminate upload session", slog.String("error", err.Error()))
+		writeError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
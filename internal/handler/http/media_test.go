@@ -0,0 +1,693 @@
+package http
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// fakeMedia keeps uploads in memory, with the offset rules of service.Media.
+type fakeMedia struct {
+	service.Media
+
+	mu      sync.Mutex
+	uploads map[string]*fakeUpload
+	files   map[int64]string
+	deleted []string
+}
+
+type fakeUpload struct {
+	name   string
+	length int64
+	data   []byte
+	done   bool
+}
+
+func newFakeMedia() *fakeMedia {
+	return &fakeMedia{uploads: make(map[string]*fakeUpload), files: make(map[int64]string)}
+}
+
+func (m *fakeMedia) Download(_ context.Context, req *dto.MediaDownloadRequest) (*dto.FileDownloadResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.files[req.FileID]
+	if !ok {
+		return nil, service.ErrSessionNotFound
+	}
+	sum := sha256.Sum256([]byte(data))
+
+	return &dto.FileDownloadResult{
+		Metadata: &dto.FileMetadata{
+			ID:       strconv.FormatInt(req.FileID, 10),
+			Name:     "file",
+			MimeType: http.DetectContentType([]byte(data)),
+			Size:     int64(len(data)),
+			Hash:     hex.EncodeToString(sum[:]),
+		},
+		Body: io.NopCloser(strings.NewReader(data[req.Offset:])),
+	}, nil
+}
+
+func (m *fakeMedia) CreateUpload(_ context.Context, req *dto.CreateUploadRequest) (*dto.UploadInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := "u" + string(rune('1'+len(m.uploads)))
+	m.uploads[id] = &fakeUpload{name: req.Name, length: req.Length}
+
+	return &dto.UploadInfo{ID: id, Length: req.Length, ExpiresAt: time.Now().Add(time.Minute)}, nil
+}
+
+func (m *fakeMedia) GetUpload(_ context.Context, uploadID string) (*dto.UploadInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	upload, ok := m.uploads[uploadID]
+	switch {
+	case !ok:
+		return nil, service.ErrSessionNotFound
+	case upload.done:
+		return nil, service.ErrSessionDone
+	}
+
+	return &dto.UploadInfo{ID: uploadID, Offset: int64(len(upload.data)), Length: upload.length}, nil
+}
+
+func (m *fakeMedia) WriteUpload(_ context.Context, req *dto.WriteUploadRequest) (*dto.WriteUploadResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	upload, ok := m.uploads[req.UploadID]
+	switch {
+	case !ok:
+		return nil, service.ErrSessionNotFound
+	case upload.done:
+		return nil, service.ErrSessionDone
+	case req.Offset != int64(len(upload.data)):
+		return nil, service.ErrOffsetMismatch
+	case req.Length > 0 && upload.length > 0 && req.Length != upload.length:
+		return nil, service.ErrUploadLengthMismatch
+	}
+	if req.Length > 0 {
+		upload.length = req.Length
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	upload.data = append(upload.data, data...)
+	offset := int64(len(upload.data))
+
+	if (upload.length == 0 || offset < upload.length) && !req.Final {
+		return &dto.WriteUploadResult{Offset: offset}, nil
+	}
+	upload.done = true
+
+	if sum := sha256.Sum256(upload.data); req.Checksum != nil && !bytes.Equal(req.Checksum.Sum, sum[:]) {
+		return nil, service.ErrChecksumMismatch
+	}
+
+	return &dto.WriteUploadResult{Offset: offset, File: &dto.FileMetadata{ID: "f-" + req.UploadID, Name: upload.name, Size: offset}}, nil
+}
+
+func (m *fakeMedia) Upload(ctx context.Context, name string, body io.Reader) (*dto.FileMetadata, error) {
+	info, err := m.CreateUpload(ctx, &dto.CreateUploadRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	res, err := m.WriteUpload(ctx, &dto.WriteUploadRequest{UploadID: info.ID, Body: body, Final: true})
+	if err != nil {
+		return nil, err
+	}
+	if res.File.Size == 0 {
+		return nil, service.ErrEmptyBody
+	}
+
+	return res.File, nil
+}
+
+func (m *fakeMedia) DeleteFiles(_ context.Context, fileIDs ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deleted = append(m.deleted, fileIDs...)
+
+	return nil
+}
+
+func (m *fakeMedia) TerminateUploadSession(uploadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	upload, ok := m.uploads[uploadID]
+	switch {
+	case !ok:
+		return service.ErrSessionNotFound
+	case upload.done:
+		return service.ErrSessionDone
+	}
+	upload.done = true
+
+	return nil
+}
+
+// fakeThumbnails renders the request into the thumbnail data.
+type fakeThumbnails struct{}
+
+func (fakeThumbnails) Thumbnail(_ context.Context, req *dto.ThumbnailRequest) (*dto.Thumbnail, error) {
+	if req.Width == 0 && req.Height == 0 {
+		return nil, service.ErrInvalidThumbnailSize
+	}
+
+	return &dto.Thumbnail{
+		MimeType:   "image/jpeg",
+		Data:       fmt.Appendf(nil, "%d %dx%d %s", req.FileID, req.Width, req.Height, req.Fit),
+		SourceHash: "abc",
+	}, nil
+}
+
+// fakeLinks signs links with the token "FILE-DISPOSITION", valid for an hour.
+type fakeLinks struct{}
+
+func (fakeLinks) SignLink(_ context.Context, req *dto.SignLinkRequest) (*dto.SignedLink, error) {
+	disposition := cmp.Or(req.Disposition, dto.MediaDispositionInline)
+
+	return &dto.SignedLink{
+		FileID:      req.FileID,
+		Disposition: disposition,
+		ExpiresAt:   time.Now().Add(time.Hour),
+		Token:       fmt.Sprintf("%d-%s", req.FileID, disposition),
+	}, nil
+}
+
+func (fakeLinks) Authorize(ctx context.Context, fileID int64, token string) (context.Context, *dto.SignedLink, error) {
+	id, disposition, _ := strings.Cut(token, "-")
+	if id != strconv.FormatInt(fileID, 10) {
+		return nil, nil, service.ErrLinkInvalid
+	}
+
+	return ctx, &dto.SignedLink{
+		FileID:      fileID,
+		Disposition: dto.MediaDisposition(disposition),
+		ExpiresAt:   time.Now().Add(time.Hour),
+		Token:       token,
+	}, nil
+}
+
+func newMediaServer(t *testing.T, media service.Media) *httptest.Server {
+	t.Helper()
+
+	return serveMedia(t, newMediaHandler(media))
+}
+
+func newMediaHandler(media service.Media) *Handler {
+	return &Handler{
+		logger:        slog.New(slog.DiscardHandler),
+		media:         media,
+		thumbnails:    fakeThumbnails{},
+		links:         fakeLinks{},
+		sessions:      service.LocalSessions{},
+		maxUploadSize: 1 << 20,
+		cacheControl:  map[string]string{"image": "private, immutable", "default": "private, max-age=60"},
+		linkBaseURL:   "https://im.example.com",
+	}
+}
+
+func serveMedia(t *testing.T, h *Handler) *httptest.Server {
+	t.Helper()
+
+	noop := func(next http.Handler) http.Handler { return next }
+	mux := http.NewServeMux()
+	h.registerRoutes(mux, noop, noop)
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func TestUploadFile_Resumes(t *testing.T) {
+	media := newFakeMedia()
+	ts := newMediaServer(t, media)
+
+	info, _ := media.CreateUpload(t.Context(), &dto.CreateUploadRequest{Name: "notes.txt"})
+	url := ts.URL + "/media?uploadId=" + info.ID
+
+	put := func(url, contentRange, body string) (*http.Response, map[string]any) {
+		req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contentRange != "" {
+			req.Header.Set("Content-Range", contentRange)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+
+		return resp, out
+	}
+
+	// The first chunk leaves the total open.
+	resp, body := put(url, "bytes 0-4/*", "hello")
+	if resp.StatusCode != http.StatusAccepted || resp.Header.Get(uploadOffsetHeader) != "5" || body["size"] != 5.0 {
+		t.Fatalf("first chunk = %d %v", resp.StatusCode, body)
+	}
+
+	// A retry of the first chunk reports where to resume.
+	resp, _ = put(url, "bytes 0-4/*", "hello")
+	if resp.StatusCode != http.StatusConflict || resp.Header.Get(uploadOffsetHeader) != "5" {
+		t.Errorf("stale chunk = %d, offset %q", resp.StatusCode, resp.Header.Get(uploadOffsetHeader))
+	}
+
+	if resp, _ = put(url, "bytes 5-9/*", "hi"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("chunk shorter than its range = %d, want 400", resp.StatusCode)
+	}
+
+	// Declaring the total does not complete an upload that has not reached it.
+	if resp, _ = put(url, "bytes 5-11/17", " there "); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("second chunk = %d", resp.StatusCode)
+	}
+
+	resp, body = put(url+"&offset=12", "", "folks")
+	if resp.StatusCode != http.StatusOK || body["fileId"] == nil {
+		t.Fatalf("last chunk = %d %v", resp.StatusCode, body)
+	}
+	if data := string(media.uploads[info.ID].data); data != "hello there folks" {
+		t.Errorf("stored %q", data)
+	}
+}
+
+func TestUploadFile_Final(t *testing.T) {
+	media := newFakeMedia()
+	ts := newMediaServer(t, media)
+
+	info, _ := media.CreateUpload(t.Context(), &dto.CreateUploadRequest{Name: "log"})
+	for i, chunk := range []struct {
+		query string
+		code  int
+	}{
+		{"&offset=0", http.StatusAccepted},
+		{"&offset=3", http.StatusAccepted},
+		{"&offset=6&final=true", http.StatusOK},
+	} {
+		req, _ := http.NewRequest(http.MethodPut, ts.URL+"/media?uploadId="+info.ID+chunk.query, strings.NewReader("abc"))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != chunk.code {
+			t.Errorf("chunk %d = %d, want %d", i, resp.StatusCode, chunk.code)
+		}
+	}
+}
+
+func TestUploadFile_Checksum(t *testing.T) {
+	media := newFakeMedia()
+	ts := newMediaServer(t, media)
+
+	sum := sha256.Sum256([]byte("hello"))
+	for _, tc := range []struct {
+		name, header, value string
+		code                int
+	}{
+		{"sha256", fileChecksumHeader, "sha256 " + base64.StdEncoding.EncodeToString(sum[:]), http.StatusOK},
+		{"digest", "Digest", "md5=abc, SHA-256=" + base64.StdEncoding.EncodeToString(sum[:]), http.StatusOK},
+		{"hex digest", "Digest", "sha-256=" + hex.EncodeToString(sum[:]), http.StatusOK},
+		{"mismatch", fileChecksumHeader, "crc32c AAAAAA==", http.StatusUnprocessableEntity},
+		{"unsupported", fileChecksumHeader, "md5 XUFAKrxLKna5cZ2REBfFkg==", http.StatusBadRequest},
+		{"malformed", "Digest", "sha-256=aGVsbG8=", http.StatusBadRequest},
+		{"upload checksum", uploadChecksumHeader, "sha256 " + base64.StdEncoding.EncodeToString(sum[:]), http.StatusOK},
+		{"upload checksum mismatch", uploadChecksumHeader, "crc32c AAAAAA==", http.StatusUnprocessableEntity},
+	} {
+		info, _ := media.CreateUpload(t.Context(), &dto.CreateUploadRequest{Name: "notes.txt"})
+
+		req, _ := http.NewRequest(http.MethodPut, ts.URL+"/media?uploadId="+info.ID, strings.NewReader("hello"))
+		req.Header.Set(tc.header, tc.value)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.code {
+			t.Errorf("%s: status = %d, want %d", tc.name, resp.StatusCode, tc.code)
+		}
+	}
+
+	// Upload-Checksum of a chunk is that of the chunk, not of the file.
+	info, _ := media.CreateUpload(t.Context(), &dto.CreateUploadRequest{Name: "notes.txt"})
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/media?uploadId="+info.ID+"&offset=0&final=true", strings.NewReader("hello"))
+	req.Header.Set(uploadChecksumHeader, "crc32c AAAAAA==")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("chunk with Upload-Checksum: status = %d, want it ignored", resp.StatusCode)
+	}
+}
+
+func TestUploadMultipart(t *testing.T) {
+	media := newFakeMedia()
+	ts := newMediaServer(t, media)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	_ = form.WriteField("caption", "not a file")
+	for name, content := range map[string]string{"avatar.png": "png", "shot.jpg": "jpeg"} {
+		part, _ := form.CreateFormFile("file", name)
+		_, _ = part.Write([]byte(content))
+	}
+	_ = form.Close()
+
+	resp, err := http.Post(ts.URL+"/media/upload", form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var files []dto.SuccessfullyUploadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || len(files) != 2 {
+		t.Fatalf("POST /media/upload = %d %+v", resp.StatusCode, files)
+	}
+	for _, file := range files {
+		if file.FileID == "" || (file.Name == "avatar.png") != (file.Size == 3) {
+			t.Errorf("uploaded %+v", file)
+		}
+	}
+
+	if len(media.deleted) != 0 {
+		t.Errorf("deleted %v after a successful upload", media.deleted)
+	}
+
+	resp, err = http.Post(ts.URL+"/media/upload", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /media/upload without a form = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestUploadMultipart_DeletesStoredFilesOnFailure(t *testing.T) {
+	media := newFakeMedia()
+	ts := newMediaServer(t, media)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "avatar.png")
+	_, _ = part.Write([]byte("png"))
+	_, _ = form.CreateFormFile("file", "empty.txt")
+	_ = form.Close()
+
+	resp, err := http.Post(ts.URL+"/media/upload", form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		t.Fatal("POST /media/upload with an empty file succeeded")
+	}
+	if len(media.deleted) != 1 || media.deleted[0] != "f-u1" {
+		t.Errorf("deleted %v, want the file stored before the failure", media.deleted)
+	}
+}
+
+func TestStreamFile_Conditional(t *testing.T) {
+	media := newFakeMedia()
+	media.files[7] = "plain text file"
+	ts := newMediaServer(t, media)
+
+	get := func(path string, header http.Header) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return resp, string(body)
+	}
+
+	resp, _ := get("/media/7/download", http.Header{})
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(etag, `"`) || resp.Header.Get("Cache-Control") != "private, max-age=60" {
+		t.Fatalf("download = %d, ETag %q, Cache-Control %q", resp.StatusCode, etag, resp.Header.Get("Cache-Control"))
+	}
+
+	for _, path := range []string{"/media/7/download", "/media/7/stream"} {
+		resp, body := get(path, http.Header{"If-None-Match": {`"other", W/` + etag}})
+		if resp.StatusCode != http.StatusNotModified || body != "" || resp.Header.Get("ETag") != etag {
+			t.Errorf("%s with a matching If-None-Match = %d %q", path, resp.StatusCode, body)
+		}
+	}
+
+	resp, body := get("/media/7/stream", http.Header{"Range": {"bytes=6-9"}, "If-Range": {etag}})
+	if resp.StatusCode != http.StatusPartialContent || body != "text" {
+		t.Errorf("range of the current version = %d %q", resp.StatusCode, body)
+	}
+
+	resp, body = get("/media/7/stream", http.Header{"Range": {"bytes=6-9"}, "If-Range": {`"stale"`}})
+	if resp.StatusCode != http.StatusOK || body != "plain text file" {
+		t.Errorf("range of a stale version = %d %q, want the whole file", resp.StatusCode, body)
+	}
+}
+
+func TestStreamFile_Ranges(t *testing.T) {
+	media := newFakeMedia()
+	media.files[7] = "0123456789abcdefghij"
+	ts := newMediaServer(t, media)
+
+	get := func(rangeHeader string) (*http.Response, []byte) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/media/7/stream", nil)
+		req.Header.Set("Range", rangeHeader)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return resp, body
+	}
+
+	resp, body := get("bytes=-5")
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "fghij" || resp.Header.Get("Content-Range") != "bytes 15-19/20" {
+		t.Errorf("suffix range = %d %q %q", resp.StatusCode, body, resp.Header.Get("Content-Range"))
+	}
+
+	resp, body = get("bytes=12-13,2-3,-2")
+	mediaType, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode != http.StatusPartialContent || mediaType != "multipart/byteranges" {
+		t.Fatalf("multiple ranges = %d %s", resp.StatusCode, mediaType)
+	}
+	var parts []string
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		data, _ := io.ReadAll(part)
+		parts = append(parts, part.Header.Get("Content-Range")+" "+string(data))
+	}
+	if want := []string{"bytes 2-3/20 23", "bytes 12-13/20 cd", "bytes 18-19/20 ij"}; !slices.Equal(parts, want) {
+		t.Errorf("parts = %q, want %q", parts, want)
+	}
+
+	resp, _ = get("bytes=20-")
+	if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable || resp.Header.Get("Content-Range") != "bytes */20" {
+		t.Errorf("unsatisfiable range = %d %q", resp.StatusCode, resp.Header.Get("Content-Range"))
+	}
+
+	resp, body = get("bytes=oops")
+	if resp.StatusCode != http.StatusOK || len(body) != 20 {
+		t.Errorf("malformed range = %d %q, want the whole file", resp.StatusCode, body)
+	}
+}
+
+// closeCounter counts the Close calls of a download body.
+type closeCounter struct {
+	io.Reader
+
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+
+	return nil
+}
+
+func TestServeFile_ReopenFails(t *testing.T) {
+	h := newMediaHandler(newFakeMedia())
+
+	first := &closeCounter{Reader: strings.NewReader("world")}
+	calls := 0
+	download := func(int64) (*dto.FileDownloadResult, error) {
+		if calls++; calls > 1 {
+			return nil, errors.New("storage unavailable")
+		}
+
+		return &dto.FileDownloadResult{
+			Metadata: &dto.FileMetadata{ID: "1", Name: "hello.txt", MimeType: "text/plain", Size: 11, Hash: "abc"},
+			Body:     first,
+		}, nil
+	}
+
+	// The If-Range of another version sends the whole file, from a download
+	// reopened at its start.
+	req := httptest.NewRequest(http.MethodGet, "/media/1/stream", nil)
+	req.Header.Set("Range", "bytes=6-")
+	req.Header.Set("If-Range", `"other"`)
+	rec := httptest.NewRecorder()
+	h.serveFile(rec, req, download, nil)
+
+	if calls != 2 {
+		t.Fatalf("downloads = %d, want the file reopened", calls)
+	}
+	if rec.Code < http.StatusInternalServerError {
+		t.Errorf("status = %d, want the failed reopen reported", rec.Code)
+	}
+	if first.closed != 1 {
+		t.Errorf("first download closed %d times, want once", first.closed)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	ts := newMediaServer(t, newFakeMedia())
+
+	get := func(query string, header http.Header) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/media/7/thumbnail"+query, nil)
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return resp, string(body)
+	}
+
+	resp, body := get("?w=64&h=48&fit=cover", http.Header{})
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || body != "7 64x48 cover" || resp.Header.Get("Content-Type") != "image/jpeg" ||
+		resp.Header.Get("Cache-Control") != "private, immutable" {
+		t.Fatalf("thumbnail = %d %q %v", resp.StatusCode, body, resp.Header)
+	}
+
+	if resp, _ = get("?w=64&h=48&fit=cover", http.Header{"If-None-Match": {etag}}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("thumbnail with a matching If-None-Match = %d", resp.StatusCode)
+	}
+	if resp, _ = get("?w=32&h=48&fit=cover", http.Header{"If-None-Match": {etag}}); resp.StatusCode != http.StatusOK {
+		t.Errorf("ETag of another size matched: %d", resp.StatusCode)
+	}
+
+	for _, query := range []string{"?w=big", ""} {
+		if resp, _ = get(query, http.Header{}); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("thumbnail%s = %d, want 400", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestSignedLink(t *testing.T) {
+	media := newFakeMedia()
+	media.files[7] = "plain text file"
+	ts := newMediaServer(t, media)
+
+	resp, err := http.Post(ts.URL+"/media/7/link", "application/json", strings.NewReader(`{"disposition":"attachment"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var link dto.SignedLinkResponse
+	err = json.NewDecoder(resp.Body).Decode(&link)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK || link.URL != "https://im.example.com/media/signed/7/7-attachment" {
+		t.Fatalf("sign = %d %+v, %v", resp.StatusCode, link, err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+strings.TrimPrefix(link.URL, "https://im.example.com"), nil)
+	req.Header.Set("Range", "bytes=6-9")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "text" {
+		t.Errorf("signed range = %d %q", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename=file` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if got := resp.Header.Get("Cache-Control"); !strings.HasPrefix(got, "private, max-age=") || got == "private, max-age=0" {
+		t.Errorf("Cache-Control = %q, want private until the link expires", got)
+	}
+	if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q", got)
+	}
+
+	// Text is never shown inline, whatever the link says.
+	resp, err = http.Get(ts.URL + "/media/signed/7/7-inline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename=file` {
+		t.Errorf("Content-Disposition of an inline text link = %q", got)
+	}
+
+	resp, err = http.Get(ts.URL + "/media/signed/8/7-attachment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("link to another file = %d, want 403", resp.StatusCode)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/webitel/im-gateway-service/config"
 	"github.com/webitel/im-gateway-service/infra/auth"
 	httpmw "github.com/webitel/im-gateway-service/infra/server/http/middleware"
+	"github.com/webitel/im-gateway-service/internal/service"
 )
 
 var Module = fx.Module("http_handler",
@@ -22,6 +23,28 @@ var Module = fx.Module("http_handler",
 			},
 			fx.ResultTags(`name:"bodyLimitMW"`),
 		),
+		// The admin endpoints get a mux of their own, served on the internal
+		// admin listener only.
+		fx.Annotate(
+			func() *http.ServeMux { return http.NewServeMux() },
+			fx.ResultTags(`name:"adminMux"`),
+		),
+		fx.Annotate(
+			func(mux *http.ServeMux) http.Handler { return mux },
+			fx.ParamTags(`name:"adminMux"`),
+			fx.ResultTags(`name:"adminHandler"`),
+		),
+		fx.Annotate(
+			func(cfg *config.Config) (func(http.Handler) http.Handler, error) {
+				token, err := cfg.Service.Admin.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				return httpmw.WithAdminToken(token), nil
+			},
+			fx.ResultTags(`name:"adminMW"`),
+		),
 		func(cfg *config.Config, mux *http.ServeMux) http.Handler {
 			var h http.Handler = mux
 
@@ -29,9 +52,30 @@ var Module = fx.Module("http_handler",
 		},
 		fx.Annotate(
 			NewHandler,
-			fx.ParamTags(``, ``, ``, `name:"bodyLimitMW"`, ``),
+			fx.ParamTags(``, ``, ``, ``, ``, ``, ``, `name:"bodyLimitMW"`, ``),
+		),
+		NewUpdatesHandler,
+		NewBotHandler,
+		fx.Annotate(
+			NewQuotaHandler,
+			fx.ParamTags(``, ``, `name:"adminMux"`, `name:"adminMW"`),
+		),
+		NewGatewayHandler,
+		NewOpenAPIHandler,
+		// Close realtime streams as soon as the server starts shutting down,
+		// otherwise they would hold srv.Shutdown until its deadline.
+		fx.Annotate(
+			func(h *UpdatesHandler) func() { return h.Shutdown },
+			fx.ResultTags(`group:"http_on_shutdown"`),
+		),
+		// Refuse new uploads and cut off the others after the drain timeout,
+		// so that they do not hold srv.Shutdown past its deadline either.
+		fx.Annotate(
+			func(drainer service.UploadDrainer) func() { return drainer.Drain },
+			fx.ResultTags(`group:"http_on_shutdown"`),
 		),
 	),
 	// Force Handler instantiation so routes are registered on the mux.
-	fx.Invoke(func(*Handler) {}),
+	fx.Invoke(func(*Handler, *UpdatesHandler, *BotHandler, *GatewayHandler, *OpenAPIHandler) {}),
+	fx.Invoke(func(*QuotaHandler) {}),
 )
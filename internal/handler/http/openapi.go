@@ -0,0 +1,919 @@
+package http
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/webitel/im-gateway-service/config"
+	grpcsrv "github.com/webitel/im-gateway-service/infra/server/grpc"
+	httpmw "github.com/webitel/im-gateway-service/infra/server/http/middleware"
+	"github.com/webitel/im-gateway-service/internal/model"
+)
+
+const (
+	openAPIVersion = "3.0.3"
+	// openAPIPath and explorerPath are where the document and the API
+	// explorer are served, and explorerAssetsPath the files of the explorer.
+	openAPIPath        = "/openapi.json"
+	explorerPath       = "/explorer"
+	explorerAssetsPath = "/explorer/assets/"
+
+	apiErrorSchema = "apiError"
+)
+
+// explorerPage is a Swagger UI page for the document. Its assets are served
+// from the directory configured in service.http.explorer_assets.
+//
+//go:embed explorer.html
+var explorerPage []byte
+
+// OpenAPIHandler publishes the OpenAPI document of the HTTP API: the media
+// routes and the transcoded gateway RPCs. The RPC part is generated from the
+// proto descriptors once, at startup.
+type OpenAPIHandler struct {
+	logger   *slog.Logger
+	document []byte
+}
+
+func NewOpenAPIHandler(logger *slog.Logger, cfg *config.Config, mux *http.ServeMux) (*OpenAPIHandler, error) {
+	routes, err := gatewayRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := json.Marshal(buildOpenAPI(routes))
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	h := &OpenAPIHandler{logger: logger, document: document}
+	mux.HandleFunc("GET "+openAPIPath, h.serveDocument)
+	if cfg.Service.HTTP.Explorer {
+		mux.HandleFunc("GET "+explorerPath, h.serveExplorer)
+		mux.Handle("GET "+explorerAssetsPath, http.StripPrefix(explorerAssetsPath,
+			http.FileServerFS(os.DirFS(cfg.Service.HTTP.ExplorerAssets))))
+	}
+
+	return h, nil
+}
+
+func (h *OpenAPIHandler) serveDocument(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(h.document); err != nil {
+		h.logger.Debug("write openapi document", slog.String("error", err.Error()))
+	}
+}
+
+func (h *OpenAPIHandler) serveExplorer(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write(explorerPage); err != nil {
+		h.logger.Debug("write api explorer", slog.String("error", err.Error()))
+	}
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Security   []map[string][]string                   `json:"security"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]*openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `json:"type"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIBody                `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	// Security overrides the document security; empty for public routes.
+	Security []map[string][]string `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Headers     map[string]*openAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIHeader struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+func schemaRef(name string) *openAPISchema {
+	return &openAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func jsonContent(schema *openAPISchema) map[string]*openAPIMediaType {
+	return map[string]*openAPIMediaType{"application/json": {Schema: schema}}
+}
+
+// buildOpenAPI documents the routes served by hand, media, realtime updates
+// and bot webhooks, and the gateway routes.
+func buildOpenAPI(routes []*gatewayRoute) *openAPIDocument {
+	doc := &openAPIDocument{
+		OpenAPI:  openAPIVersion,
+		Info:     openAPIInfo{Title: "Webitel IM Gateway API", Version: model.Version},
+		Security: []map[string][]string{{"accessToken": {}}, {"bearer": {}}},
+		Paths:    make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			Schemas: map[string]*openAPISchema{
+				apiErrorSchema: {
+					Type: "object",
+					Properties: map[string]*openAPISchema{
+						"id":     {Type: "string", Description: "Stable error identifier, e.g. api.not_found."},
+						"code":   {Type: "integer", Format: "int32", Description: "HTTP status code."},
+						"detail": {Type: "string"},
+						"status": {Type: "string", Description: "HTTP status text."},
+					},
+				},
+			},
+			SecuritySchemes: map[string]*openAPISecurityScheme{
+				"accessToken": {Type: "apiKey", In: "header", Name: "X-Webitel-Access"},
+				"bearer":      {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	addMediaPaths(doc)
+	addUpdatesPaths(doc)
+	addBotPaths(doc)
+
+	schemas := &protoSchemas{schemas: doc.Components.Schemas}
+	for _, route := range routes {
+		path, op := gatewayOperation(route, schemas)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	return doc
+}
+
+// gatewayOperation documents route, returning its OpenAPI path.
+func gatewayOperation(route *gatewayRoute, schemas *protoSchemas) (string, *openAPIOperation) {
+	service := route.RPC.Parent().(protoreflect.ServiceDescriptor)
+	input := route.RPC.Input()
+
+	op := &openAPIOperation{
+		OperationID: fmt.Sprintf("%s_%s", service.Name(), route.RPC.Name()),
+		Tags:        []string{string(service.Name())},
+		Responses: map[string]*openAPIResponse{
+			"200": {Description: "OK", Content: jsonContent(schemas.ref(route.RPC.Output()))},
+			"default": {
+				Description: "Error",
+				Content:     jsonContent(schemaRef(apiErrorSchema)),
+			},
+		},
+	}
+	if comments := route.RPC.ParentFile().SourceLocations().ByDescriptor(route.RPC).LeadingComments; comments != "" {
+		op.Description = strings.TrimSpace(comments)
+	}
+	if grpcsrv.IsPublicMethod(route.FullMethod) {
+		op.Security = []map[string][]string{{}}
+	}
+	if route.responseField != nil {
+		op.Responses["200"].Content = jsonContent(schemas.field(route.responseField))
+	}
+
+	// OpenAPI has no "{name=pattern}" templates: keep the variable names.
+	path := pathVariable.ReplaceAllString(route.Path, "{$1}")
+	bound := make(map[string]bool)
+	for _, match := range pathVariable.FindAllStringSubmatch(route.Path, -1) {
+		name := match[1]
+		bound[strings.SplitN(name, ".", 2)[0]] = true
+		op.Parameters = append(op.Parameters, &openAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   pathParamSchema(input, name, schemas),
+		})
+	}
+
+	switch {
+	case route.Body == "*":
+		op.RequestBody = &openAPIBody{Required: true, Content: jsonContent(schemas.ref(input))}
+	case route.bodyField != nil:
+		bound[string(route.bodyField.Name())] = true
+		op.RequestBody = &openAPIBody{Required: true, Content: jsonContent(schemas.field(route.bodyField))}
+	}
+
+	if route.Body != "*" {
+		fields := input.Fields()
+		for i := range fields.Len() {
+			field := fields.Get(i)
+			if bound[string(field.Name())] || field.IsMap() || field.Message() != nil {
+				continue
+			}
+			op.Parameters = append(op.Parameters, &openAPIParameter{
+				Name:   field.JSONName(),
+				In:     "query",
+				Schema: schemas.field(field),
+			})
+		}
+	}
+
+	return path, op
+}
+
+// pathParamSchema returns the schema of the field at the dotted path name.
+func pathParamSchema(msg protoreflect.MessageDescriptor, name string, schemas *protoSchemas) *openAPISchema {
+	var field protoreflect.FieldDescriptor
+	for part := range strings.SplitSeq(name, ".") {
+		if msg == nil {
+			return &openAPISchema{Type: "string"}
+		}
+		if field = msg.Fields().ByName(protoreflect.Name(part)); field == nil {
+			return &openAPISchema{Type: "string"}
+		}
+		msg = field.Message()
+	}
+
+	return schemas.field(field)
+}
+
+// protoSchemas renders proto messages as schemas in their protojson form,
+// adding each message to the document components once.
+type protoSchemas struct {
+	schemas map[string]*openAPISchema
+}
+
+func (s *protoSchemas) ref(msg protoreflect.MessageDescriptor) *openAPISchema {
+	if schema := wellKnownSchema(msg); schema != nil {
+		return schema
+	}
+
+	name := string(msg.FullName())
+	if _, ok := s.schemas[name]; !ok {
+		schema := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+		// Registered before the fields, for recursive messages.
+		s.schemas[name] = schema
+
+		fields := msg.Fields()
+		for i := range fields.Len() {
+			schema.Properties[fields.Get(i).JSONName()] = s.field(fields.Get(i))
+		}
+	}
+
+	return schemaRef(name)
+}
+
+func (s *protoSchemas) field(field protoreflect.FieldDescriptor) *openAPISchema {
+	switch {
+	case field.IsMap():
+		return &openAPISchema{Type: "object", AdditionalProperties: s.value(field.MapValue())}
+	case field.IsList():
+		return &openAPISchema{Type: "array", Items: s.value(field)}
+	default:
+		return s.value(field)
+	}
+}
+
+// value returns the schema of a single value of field.
+func (s *protoSchemas) value(field protoreflect.FieldDescriptor) *openAPISchema {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return &openAPISchema{Type: "boolean"}
+	case protoreflect.StringKind:
+		return &openAPISchema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &openAPISchema{Type: "string", Format: "byte"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// protojson encodes 64-bit integers as strings.
+		return &openAPISchema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &openAPISchema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &openAPISchema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case protoreflect.EnumKind:
+		values := field.Enum().Values()
+		schema := &openAPISchema{Type: "string", Enum: make([]string, 0, values.Len())}
+		for i := range values.Len() {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+
+		return schema
+	default:
+		return s.ref(field.Message())
+	}
+}
+
+// wellKnownSchema returns the protojson form of the well-known types that do
+// not encode as objects of their fields.
+func wellKnownSchema(msg protoreflect.MessageDescriptor) *openAPISchema {
+	switch msg.FullName() {
+	case "google.protobuf.Timestamp":
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration", "google.protobuf.FieldMask":
+		return &openAPISchema{Type: "string"}
+	case "google.protobuf.Struct", "google.protobuf.Any", "google.protobuf.Empty":
+		return &openAPISchema{Type: "object"}
+	case "google.protobuf.Value":
+		return &openAPISchema{}
+	case "google.protobuf.ListValue":
+		return &openAPISchema{Type: "array", Items: &openAPISchema{}}
+	case "google.protobuf.StringValue":
+		return &openAPISchema{Type: "string"}
+	case "google.protobuf.BytesValue":
+		return &openAPISchema{Type: "string", Format: "byte"}
+	case "google.protobuf.BoolValue":
+		return &openAPISchema{Type: "boolean"}
+	case "google.protobuf.Int32Value":
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case "google.protobuf.UInt32Value":
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case "google.protobuf.Int64Value":
+		return &openAPISchema{Type: "string", Format: "int64"}
+	case "google.protobuf.UInt64Value":
+		return &openAPISchema{Type: "string", Format: "uint64"}
+	case "google.protobuf.FloatValue":
+		return &openAPISchema{Type: "number", Format: "float"}
+	case "google.protobuf.DoubleValue":
+		return &openAPISchema{Type: "number", Format: "double"}
+	default:
+		return nil
+	}
+}
+
+// addMediaPaths documents the media routes, which are not RPCs.
+func addMediaPaths(doc *openAPIDocument) {
+	schemas := doc.Components.Schemas
+	schemas["mediaCreateUploadSessionRequest"] = &openAPISchema{
+		Type:       "object",
+		Properties: map[string]*openAPISchema{"name": {Type: "string"}},
+	}
+	schemas["mediaCreateUploadSessionResponse"] = &openAPISchema{
+		Type:       "object",
+		Properties: map[string]*openAPISchema{"uploadId": {Type: "string"}},
+	}
+	schemas["mediaFileInfo"] = &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"uploadId": {Type: "string"},
+			"size":     {Type: "integer", Format: "int64", Description: "Bytes uploaded so far."},
+			"mimeType": {Type: "string"},
+			"name":     {Type: "string"},
+			"hash":     {Type: "string", Format: "byte"},
+		},
+	}
+	schemas["mediaSignLinkRequest"] = &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"ttlSeconds":  {Type: "integer", Format: "int64", Description: "Lifetime of the link; the configured default if omitted, capped at the configured max."},
+			"disposition": {Type: "string", Enum: []string{"inline", "attachment"}, Description: "inline by default"},
+		},
+	}
+	schemas["mediaSignedLink"] = &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"url":       {Type: "string", Description: "Absolute URL of the link."},
+			"expiresAt": {Type: "string", Format: "date-time"},
+		},
+	}
+	schemas["mediaFile"] = &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"fileId":   {Type: "string"},
+			"name":     {Type: "string"},
+			"mimeType": {Type: "string"},
+			"size":     {Type: "integer", Format: "int64"},
+			"hash":     {Type: "string"},
+		},
+	}
+
+	var (
+		fileID = &openAPIParameter{
+			Name: "id", In: "path", Required: true,
+			Schema: &openAPISchema{Type: "integer", Format: "int64"},
+		}
+		uploadID = &openAPIParameter{
+			Name: "uploadId", In: "query", Required: true,
+			Schema: &openAPISchema{Type: "string"},
+		}
+		binary       = map[string]*openAPIMediaType{"application/octet-stream": {Schema: &openAPISchema{Type: "string", Format: "binary"}}}
+		errorContent = jsonContent(schemaRef(apiErrorSchema))
+	)
+	responses := func(code, description string, content map[string]*openAPIMediaType) map[string]*openAPIResponse {
+		return map[string]*openAPIResponse{
+			code:      {Description: description, Content: content},
+			"default": {Description: "Error", Content: errorContent},
+		}
+	}
+
+	ifNoneMatch := &openAPIParameter{
+		Name: "If-None-Match", In: "header",
+		Schema: &openAPISchema{Type: "string", Description: "ETag of a cached copy"},
+	}
+	notModified := &openAPIResponse{Description: "The cached copy is current"}
+
+	download := responses("200", "File content", binary)
+	download["304"] = notModified
+	doc.Paths["/media/{id}/download"] = map[string]*openAPIOperation{
+		"get": {
+			OperationID: "Media_Download",
+			Tags:        []string{"Media"},
+			Summary:     "Download a whole file as an attachment.",
+			Parameters:  []*openAPIParameter{fileID, ifNoneMatch},
+			Responses:   download,
+		},
+	}
+
+	stream := responses("200", "File content", binary)
+	stream["304"] = notModified
+	stream["206"] = &openAPIResponse{
+		Description: "Requested range of the file",
+		Headers:     map[string]*openAPIHeader{"Content-Range": {Schema: &openAPISchema{Type: "string"}}},
+		Content: map[string]*openAPIMediaType{
+			"application/octet-stream": binary["application/octet-stream"],
+			"multipart/byteranges":     {Schema: &openAPISchema{Type: "string", Format: "binary"}},
+		},
+	}
+	stream["416"] = &openAPIResponse{Description: "Range not satisfiable", Content: errorContent}
+	doc.Paths["/media/{id}/stream"] = map[string]*openAPIOperation{
+		"get": {
+			OperationID: "Media_Stream",
+			Tags:        []string{"Media"},
+			Summary:     "Stream a file or ranges of it.",
+			Parameters: []*openAPIParameter{fileID, {
+				Name: "Range", In: "header",
+				Schema: &openAPISchema{Type: "string", Description: "bytes=START-END, START- or -SUFFIX; several comma-separated ranges are sent as multipart/byteranges"},
+			}, {
+				Name: "If-Range", In: "header",
+				Schema: &openAPISchema{Type: "string", Description: "ETag the range applies to; the whole file is sent if it changed"},
+			}, ifNoneMatch},
+			Responses: stream,
+		},
+	}
+
+	upload := responses("200", "Uploaded file", jsonContent(schemaRef("mediaFile")))
+	upload["202"] = &openAPIResponse{
+		Description: "Chunk stored, the upload goes on",
+		Headers:     map[string]*openAPIHeader{uploadOffsetHeader: {Schema: &openAPISchema{Type: "integer", Format: "int64"}}},
+		Content:     jsonContent(schemaRef("mediaFileInfo")),
+	}
+	upload["409"] = &openAPIResponse{
+		Description: "The offset does not match the upload, whose offset is in Upload-Offset",
+		Content:     errorContent,
+	}
+	unsupported := &openAPIResponse{
+		Description: "The upload policy of the domain does not allow the file: its type, extension or size",
+		Content:     errorContent,
+	}
+	infected := &openAPIResponse{Description: "Malware was found in the file, which is not stored", Content: errorContent}
+	overQuota := &openAPIResponse{Description: "The upload would exceed the quota of the domain", Content: errorContent}
+	upload["403"] = overQuota
+	upload["415"] = unsupported
+	upload["422"] = &openAPIResponse{
+		Description: "Malware was found in the file, or it does not match its checksum; it is not stored",
+		Content:     errorContent,
+	}
+	upload["410"] = &openAPIResponse{
+		Description: "The replica holding the upload is gone with it; start the upload over",
+		Content:     errorContent,
+	}
+	upload["502"] = &openAPIResponse{
+		Description: "Storage did not store the bytes sent, the file is discarded; or the replica holding the upload " +
+			"is unavailable for now, retry later",
+		Content: errorContent,
+	}
+	createUpload := responses("200", "Upload session", jsonContent(schemaRef("mediaCreateUploadSessionResponse")))
+	createUpload["201"] = &openAPIResponse{
+		Description: "tus upload created",
+		Headers: map[string]*openAPIHeader{
+			"Location":       {Schema: &openAPISchema{Type: "string", Description: "URL of the upload, relative to the creation URL."}},
+			"Upload-Expires": {Schema: &openAPISchema{Type: "string", Description: "When the upload is discarded."}},
+		},
+	}
+	doc.Paths["/media"] = map[string]*openAPIOperation{
+		"post": {
+			OperationID: "Media_CreateUploadSession",
+			Tags:        []string{"Media"},
+			Summary:     "Start an upload session.",
+			Description: "With " + tusResumableHeader + " the request creates a tus upload of Upload-Length bytes " +
+				"instead, without a body, and answers 201 with its Location.",
+			Parameters: []*openAPIParameter{{
+				Name: tusResumableHeader, In: "header",
+				Schema: &openAPISchema{Type: "string", Description: tusVersion + ", for a tus creation"},
+			}, {
+				Name: uploadLengthHeader, In: "header",
+				Schema: &openAPISchema{Type: "integer", Format: "int64", Description: "Length of the tus upload."},
+			}, {
+				Name: "Upload-Metadata", In: "header",
+				Schema: &openAPISchema{Type: "string", Description: "tus metadata; the filename or name key names the file."},
+			}},
+			RequestBody: &openAPIBody{Content: jsonContent(schemaRef("mediaCreateUploadSessionRequest"))},
+			Responses:   createUpload,
+		},
+		"get": {
+			OperationID: "Media_GetUploadInfo",
+			Tags:        []string{"Media"},
+			Summary:     "Get the progress of an upload session.",
+			Parameters:  []*openAPIParameter{uploadID},
+			Responses:   responses("200", "Upload progress", jsonContent(schemaRef("mediaFileInfo"))),
+		},
+		"put": {
+			OperationID: "Media_Upload",
+			Tags:        []string{"Media"},
+			Summary: "Upload the file content of a session: the whole file, or a chunk from an offset " +
+				"given by Content-Range or the offset parameter.",
+			Parameters: []*openAPIParameter{uploadID, {
+				Name: "offset", In: "query",
+				Schema: &openAPISchema{Type: "integer", Format: "int64", Description: "Bytes uploaded so far."},
+			}, {
+				Name: "final", In: "query",
+				Schema: &openAPISchema{Type: "boolean", Description: "The chunk ends a file of undeclared length."},
+			}, {
+				Name: "Content-Range", In: "header",
+				Schema: &openAPISchema{Type: "string", Description: "bytes START-END/TOTAL, TOTAL being * while unknown"},
+			}, {
+				Name: fileChecksumHeader, In: "header",
+				Schema: &openAPISchema{Type: "string", Description: "Checksum of the whole file: sha256 or crc32c, a space, then the base64 sum"},
+			}, {
+				Name: uploadChecksumHeader, In: "header",
+				Schema: &openAPISchema{Type: "string", Description: "As " + fileChecksumHeader + ", read only when the body is the whole file"},
+			}, {
+				Name: "Digest", In: "header",
+				Schema: &openAPISchema{Type: "string", Description: "sha-256=BASE64 or crc32c=BASE64, used without " + fileChecksumHeader + " or " + uploadChecksumHeader},
+			}},
+			RequestBody: &openAPIBody{Required: true, Content: binary},
+			Responses:   upload,
+		},
+		"delete": {
+			OperationID: "Media_TerminateUploadSession",
+			Tags:        []string{"Media"},
+			Summary:     "Abort an upload session.",
+			Parameters:  []*openAPIParameter{uploadID},
+			Responses:   responses("204", "Session terminated", nil),
+		},
+		"options": {
+			OperationID: "Media_TusOptions",
+			Tags:        []string{"Media"},
+			Summary:     "Describe the tus support: Tus-Version, Tus-Extension and Tus-Max-Size.",
+			Responses:   responses("204", "tus capabilities", nil),
+		},
+	}
+
+	thumbnail := responses("200", "Thumbnail image", map[string]*openAPIMediaType{
+		"image/jpeg": {Schema: &openAPISchema{Type: "string", Format: "binary"}},
+		"image/png":  {Schema: &openAPISchema{Type: "string", Format: "binary"}},
+	})
+	thumbnail["304"] = notModified
+	thumbnail["415"] = &openAPIResponse{Description: "Not a JPEG, PNG, GIF or WebP image", Content: errorContent}
+	thumbnail["422"] = &openAPIResponse{Description: "Image too large to decode", Content: errorContent}
+	dimension := func(name, description string) *openAPIParameter {
+		return &openAPIParameter{
+			Name: name, In: "query",
+			Schema: &openAPISchema{Type: "integer", Description: description},
+		}
+	}
+	doc.Paths["/media/{id}/thumbnail"] = map[string]*openAPIOperation{
+		"get": {
+			OperationID: "Media_Thumbnail",
+			Tags:        []string{"Media"},
+			Summary:     "Get a downscaled copy of an image file, as JPEG or PNG.",
+			Parameters: []*openAPIParameter{
+				fileID,
+				dimension("w", "Max width, up to 2048; follows the aspect ratio if omitted."),
+				dimension("h", "Max height, up to 2048; follows the aspect ratio if omitted."),
+				{
+					Name: "fit", In: "query",
+					Schema: &openAPISchema{Type: "string", Enum: []string{"contain", "cover", "fill"}, Description: "contain by default"},
+				},
+				ifNoneMatch,
+			},
+			Responses: thumbnail,
+		},
+	}
+
+	doc.Paths["/media/{id}/link"] = map[string]*openAPIOperation{
+		"post": {
+			OperationID: "Media_SignLink",
+			Tags:        []string{"Media"},
+			Summary:     "Issue a signed link to a file that opens without credentials until it expires.",
+			Parameters:  []*openAPIParameter{fileID},
+			RequestBody: &openAPIBody{Content: jsonContent(schemaRef("mediaSignLinkRequest"))},
+			Responses:   responses("200", "Signed link", jsonContent(schemaRef("mediaSignedLink"))),
+		},
+	}
+
+	signed := responses("200", "File content", binary)
+	signed["206"] = stream["206"]
+	signed["304"] = notModified
+	signed["403"] = &openAPIResponse{Description: "Invalid or expired link", Content: errorContent}
+	doc.Paths["/media/signed/{id}/{token}"] = map[string]*openAPIOperation{
+		"get": {
+			OperationID: "Media_SignedDownload",
+			Tags:        []string{"Media"},
+			Summary:     "Download a file through a signed link, ranges included.",
+			Parameters: []*openAPIParameter{fileID, {
+				Name: "token", In: "path", Required: true,
+				Schema: &openAPISchema{Type: "string"},
+			}, {
+				Name: "Range", In: "header",
+				Schema: &openAPISchema{Type: "string", Description: "As for /media/{id}/stream"},
+			}, ifNoneMatch},
+			Responses: signed,
+			Security:  []map[string][]string{{}},
+		},
+	}
+
+	multipartUpload := responses("200", "Uploaded files, in the order of the form", jsonContent(&openAPISchema{
+		Type: "array", Items: schemaRef("mediaFile"),
+	}))
+	multipartUpload["403"] = overQuota
+	multipartUpload["415"] = unsupported
+	multipartUpload["422"] = infected
+	doc.Paths["/media/upload"] = map[string]*openAPIOperation{
+		"post": {
+			OperationID: "Media_UploadMultipart",
+			Tags:        []string{"Media"},
+			Summary:     "Upload one or more files in a single request.",
+			RequestBody: &openAPIBody{Required: true, Content: map[string]*openAPIMediaType{
+				"multipart/form-data": {Schema: &openAPISchema{
+					Type: "object",
+					Properties: map[string]*openAPISchema{
+						"file": {Type: "array", Items: &openAPISchema{Type: "string", Format: "binary"}},
+					},
+				}},
+			}},
+			Responses: multipartUpload,
+		},
+	}
+
+	// tus 1.0: creation is POST /media with Tus-Resumable, the rest lives on
+	// /media/{id}.
+	tusResumable := &openAPIParameter{
+		Name: tusResumableHeader, In: "header", Required: true,
+		Schema: &openAPISchema{Type: "string", Description: tusVersion},
+	}
+	tusUpload := &openAPIParameter{
+		Name: "id", In: "path", Required: true,
+		Schema: &openAPISchema{Type: "string", Description: "Upload ID from the Location of the creation."},
+	}
+	header := func(description string) *openAPIHeader {
+		return &openAPIHeader{Schema: &openAPISchema{Type: "string", Description: description}}
+	}
+	gone := func(r map[string]*openAPIResponse) map[string]*openAPIResponse {
+		r["410"] = &openAPIResponse{Description: "Upload completed, terminated or expired", Content: errorContent}
+
+		return r
+	}
+
+	head := gone(responses("200", "Upload state", nil))
+	head["200"].Headers = map[string]*openAPIHeader{
+		uploadOffsetHeader: header("Bytes received so far."),
+		uploadLengthHeader: header("Declared length of the upload."),
+		"Upload-Expires":   header("When the upload is discarded."),
+	}
+	patch := gone(responses("204", "Chunk stored", nil))
+	patch["204"].Headers = map[string]*openAPIHeader{
+		uploadOffsetHeader: header("Bytes received so far."),
+		"Upload-Expires":   header("When the upload is discarded."),
+		fileIDHeader:       header("ID of the stored file, once the upload is complete."),
+	}
+	patch["409"] = &openAPIResponse{Description: "Upload-Offset does not match the upload", Content: errorContent}
+	patch["403"] = overQuota
+	patch["415"] = unsupported
+	patch["422"] = infected
+
+	doc.Paths["/media/{id}"] = map[string]*openAPIOperation{
+		"head": {
+			OperationID: "Media_TusHead",
+			Tags:        []string{"Media"},
+			Summary:     "Get the offset of a tus upload.",
+			Parameters:  []*openAPIParameter{tusUpload, tusResumable},
+			Responses:   head,
+		},
+		"patch": {
+			OperationID: "Media_TusPatch",
+			Tags:        []string{"Media"},
+			Summary:     "Continue a tus upload from Upload-Offset.",
+			Description: "The checksum extension of tus is not supported: Upload-Checksum is ignored. " +
+				"The checksum of the whole file may be declared with " + fileChecksumHeader + " on any PATCH.",
+			Parameters: []*openAPIParameter{tusUpload, tusResumable, {
+				Name: uploadOffsetHeader, In: "header", Required: true,
+				Schema: &openAPISchema{Type: "integer", Format: "int64"},
+			}, {
+				Name: fileChecksumHeader, In: "header",
+				Schema: &openAPISchema{Type: "string", Description: "Checksum of the whole file: sha256 or crc32c, a space, then the base64 sum"},
+			}},
+			RequestBody: &openAPIBody{Required: true, Content: map[string]*openAPIMediaType{
+				offsetOctetStream: {Schema: &openAPISchema{Type: "string", Format: "binary"}},
+			}},
+			Responses: patch,
+		},
+		"delete": {
+			OperationID: "Media_TusTerminate",
+			Tags:        []string{"Media"},
+			Summary:     "Terminate a tus upload.",
+			Parameters:  []*openAPIParameter{tusUpload, tusResumable},
+			Responses:   gone(responses("204", "Upload terminated", nil)),
+		},
+	}
+}
+
+// addUpdatesPaths documents the realtime update streams. OpenAPI cannot
+// describe the frames of a WebSocket: both streams carry the same JSON
+// updates.
+func addUpdatesPaths(doc *openAPIDocument) {
+	object := func(properties map[string]*openAPISchema) *openAPISchema {
+		return &openAPISchema{Type: "object", Properties: properties}
+	}
+	str := &openAPISchema{Type: "string"}
+	millis := &openAPISchema{Type: "integer", Format: "int64", Description: "Unix milliseconds."}
+
+	doc.Components.Schemas["update"] = object(map[string]*openAPISchema{
+		"id": str,
+		"type": {Type: "string", Enum: []string{
+			"message.new", "message.read", "member.added", "member.removed",
+			"variables.changed", "chat.action", "updates.reset",
+		}},
+		"threadId":  str,
+		"date":      millis,
+		"expiresAt": {Type: "integer", Format: "int64", Description: "Unix milliseconds after which an ephemeral update is dropped."},
+		"cursor":    {Type: "string", Description: "Position of the update, to resume the stream from."},
+		"message": object(map[string]*openAPISchema{
+			"id":       str,
+			"senderId": str,
+			"type":     str,
+			"body":     str,
+			"metadata": {Type: "object", AdditionalProperties: str},
+			"documents": {Type: "array", Items: object(map[string]*openAPISchema{
+				"fileId":   {Type: "integer", Format: "int64"},
+				"name":     str,
+				"mimeType": str,
+				"size":     {Type: "integer", Format: "int64"},
+			})},
+			"createdAt": millis,
+		}),
+		"read": object(map[string]*openAPISchema{
+			"messageId": str,
+			"contactId": str,
+			"readAt":    millis,
+		}),
+		"member": object(map[string]*openAPISchema{
+			"id":        str,
+			"contactId": str,
+			"role":      {Type: "integer", Format: "int32"},
+		}),
+		"variables": {Type: "object", AdditionalProperties: str},
+		"chatAction": object(map[string]*openAPISchema{
+			"contactId": str,
+			"action":    {Type: "string", Enum: []string{"typing", "uploading_document", "recording_voice"}},
+		}),
+	})
+
+	errorResponse := &openAPIResponse{Description: "Error", Content: jsonContent(schemaRef(apiErrorSchema))}
+	accessToken := &openAPIParameter{
+		Name: httpmw.AccessTokenQueryParam, In: "query",
+		Schema: &openAPISchema{Type: "string", Description: "Access token, for clients that cannot set headers."},
+	}
+
+	doc.Paths["/ws"] = map[string]*openAPIOperation{
+		"get": {
+			OperationID: "Updates_WebSocket",
+			Tags:        []string{"Updates"},
+			Summary:     "Stream the updates of the caller over a WebSocket, one JSON update per text frame.",
+			Parameters: []*openAPIParameter{{
+				Name: "after", In: "query",
+				Schema: &openAPISchema{Type: "string", Description: "Cursor of the last update received, to resume from."},
+			}, accessToken},
+			Responses: map[string]*openAPIResponse{
+				"101":     {Description: "Switching to the WebSocket protocol"},
+				"default": errorResponse,
+			},
+		},
+	}
+
+	doc.Paths["/events"] = map[string]*openAPIOperation{
+		"get": {
+			OperationID: "Updates_Events",
+			Tags:        []string{"Updates"},
+			Summary:     "Stream the updates of the caller as Server-Sent Events.",
+			Description: "Every event is named after the update type, carries the update as its data and its cursor as its id.",
+			Parameters: []*openAPIParameter{{
+				Name: "Last-Event-ID", In: "header",
+				Schema: &openAPISchema{Type: "string", Description: "Cursor of the last update received, to resume from."},
+			}, {
+				Name: "lastEventId", In: "query",
+				Schema: &openAPISchema{Type: "string", Description: "As Last-Event-ID, for a new EventSource."},
+			}, accessToken},
+			Responses: map[string]*openAPIResponse{
+				"200": {Description: "Event stream", Content: map[string]*openAPIMediaType{
+					"text/event-stream": {Schema: schemaRef("update")},
+				}},
+				"default": errorResponse,
+			},
+		},
+	}
+}
+
+// addBotPaths documents the bot webhook registration.
+func addBotPaths(doc *openAPIDocument) {
+	doc.Components.Schemas["botSetWebhookRequest"] = &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"url":    {Type: "string", Description: "Absolute https URL the messages addressed to the bot are posted to."},
+			"secret": {Type: "string", Description: "Signing secret of at least 16 characters; a random one is generated if omitted."},
+		},
+	}
+	doc.Components.Schemas["botWebhook"] = &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"botId":     {Type: "string"},
+			"url":       {Type: "string"},
+			"secret":    {Type: "string", Description: "Returned only when the webhook is set."},
+			"createdAt": {Type: "integer", Format: "int64", Description: "Unix milliseconds."},
+		},
+	}
+
+	botID := &openAPIParameter{
+		Name: "id", In: "path", Required: true,
+		Schema: &openAPISchema{Type: "string"},
+	}
+	errorContent := jsonContent(schemaRef(apiErrorSchema))
+	responses := func(code, description string, content map[string]*openAPIMediaType) map[string]*openAPIResponse {
+		return map[string]*openAPIResponse{
+			code:      {Description: description, Content: content},
+			"403":     {Description: "Neither the bot nor the contact that created it", Content: errorContent},
+			"default": {Description: "Error", Content: errorContent},
+		}
+	}
+
+	doc.Paths["/bots/{id}/webhook"] = map[string]*openAPIOperation{
+		"put": {
+			OperationID: "Bots_SetWebhook",
+			Tags:        []string{"Bots"},
+			Summary:     "Register or replace the webhook messages addressed to the bot are posted to.",
+			Parameters:  []*openAPIParameter{botID},
+			RequestBody: &openAPIBody{Required: true, Content: jsonContent(schemaRef("botSetWebhookRequest"))},
+			Responses:   responses("200", "Webhook, with its signing secret", jsonContent(schemaRef("botWebhook"))),
+		},
+		"delete": {
+			OperationID: "Bots_DeleteWebhook",
+			Tags:        []string{"Bots"},
+			Summary:     "Remove the webhook of the bot.",
+			Parameters:  []*openAPIParameter{botID},
+			Responses:   responses("204", "Webhook removed", nil),
+		},
+	}
+}
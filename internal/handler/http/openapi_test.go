@@ -0,0 +1,97 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/webitel/im-gateway-service/config"
+)
+
+func TestBuildOpenAPI(t *testing.T) {
+	routes, err := gatewayRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := buildOpenAPI(routes)
+
+	for _, path := range []string{
+		"/media", "/media/{id}", "/media/{id}/download", "/media/{id}/stream",
+		"/ws", "/events", "/bots/{id}/webhook",
+		"/v1/bots/{bot_id}/deliveries", "/v1/threads/{thread_id}/read_positions",
+	} {
+		if doc.Paths[path] == nil {
+			t.Errorf("path %s is not documented", path)
+		}
+	}
+	if doc.Components.Schemas[apiErrorSchema] == nil {
+		t.Error("apiError schema is missing")
+	}
+
+	deleteBot := doc.Paths["/v1/bots/{id}"]["delete"]
+	if deleteBot == nil {
+		t.Fatal("DELETE /v1/bots/{id} is not documented")
+	}
+	if len(deleteBot.Parameters) != 1 || deleteBot.Parameters[0].In != "path" || deleteBot.Parameters[0].Name != "id" {
+		t.Errorf("DELETE /v1/bots/{id} parameters = %+v", deleteBot.Parameters)
+	}
+	if ref := deleteBot.Responses["200"].Content["application/json"].Schema.Ref; ref != "#/components/schemas/webitel.im.api.gateway.v1.Bot" {
+		t.Errorf("DELETE /v1/bots/{id} response = %q", ref)
+	}
+
+	// The RPCs of the gateway's own package join the published services.
+	chatAction := doc.Paths["/v1/threads/{thread_id}/chat_action"]["post"]
+	if chatAction == nil || chatAction.OperationID != "Message_SendChatAction" || chatAction.Tags[0] != "Message" {
+		t.Errorf("POST /v1/threads/{thread_id}/chat_action = %+v, want Message.SendChatAction", chatAction)
+	}
+
+	token := doc.Paths["/v1/auth/token"]["post"]
+	if token == nil || len(token.Security) != 1 || len(token.Security[0]) != 0 {
+		t.Errorf("POST /v1/auth/token must be public, got %+v", token)
+	}
+
+	// Every reference resolves.
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, match := range regexp.MustCompile(`"\$ref":"([^"]+)"`).FindAllStringSubmatch(string(data), -1) {
+		name := strings.TrimPrefix(match[1], "#/components/schemas/")
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("dangling reference %s", match[1])
+		}
+	}
+}
+
+func TestOpenAPIHandler_ServesExplorerAssets(t *testing.T) {
+	assets := t.TempDir()
+	if err := os.WriteFile(filepath.Join(assets, "swagger-ui.css"), []byte("body{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Service.HTTP.Explorer = true
+	cfg.Service.HTTP.ExplorerAssets = assets
+	mux := http.NewServeMux()
+	if _, err := NewOpenAPIHandler(slog.New(slog.DiscardHandler), cfg, mux); err != nil {
+		t.Fatal(err)
+	}
+
+	page := httptest.NewRecorder()
+	mux.ServeHTTP(page, httptest.NewRequest(http.MethodGet, explorerPath, nil))
+	if strings.Contains(page.Body.String(), "https://") {
+		t.Error("explorer page loads assets from another origin")
+	}
+
+	css := httptest.NewRecorder()
+	mux.ServeHTTP(css, httptest.NewRequest(http.MethodGet, explorerAssetsPath+"swagger-ui.css", nil))
+	if css.Code != http.StatusOK || css.Body.String() != "body{}" {
+		t.Errorf("GET swagger-ui.css = %d %q", css.Code, css.Body)
+	}
+}
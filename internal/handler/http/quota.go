@@ -0,0 +1,83 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/webitel/im-gateway-service/internal/service"
+)
+
+// QuotaHandler serves the admin endpoints of media usage on the internal admin
+// listener, guarded by the admin token rather than by a contact identity: the
+// authorizer only identifies contacts (users, bots and providers) and knows
+// no admin role or permission, so behind it any contact could read and reset
+// the usage of its domain. They are not RPCs for the same reason: the gRPC
+// server only serves contacts, through that authorizer.
+type QuotaHandler struct {
+	logger *slog.Logger
+	quotas service.MediaQuotas
+}
+
+func NewQuotaHandler(
+	logger *slog.Logger,
+	quotas service.MediaQuotas,
+	adminMux *http.ServeMux,
+	adminMW func(http.Handler) http.Handler,
+) *QuotaHandler {
+	h := &QuotaHandler{
+		logger: logger,
+		quotas: quotas,
+	}
+	h.registerRoutes(adminMux, adminMW)
+
+	return h
+}
+
+func (h *QuotaHandler) registerRoutes(mux *http.ServeMux, adminMW func(http.Handler) http.Handler) {
+	mux.Handle("GET /admin/domains/{domain}/media-usage", adminMW(http.HandlerFunc(h.getUsage)))
+	mux.Handle("DELETE /admin/domains/{domain}/media-usage", adminMW(http.HandlerFunc(h.resetUsage)))
+}
+
+// getUsage returns what the domain has uploaded, against its limits.
+func (h *QuotaHandler) getUsage(w http.ResponseWriter, r *http.Request) {
+	domainID, err := strconv.ParseInt(r.PathValue("domain"), 10, 64)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid domain id")
+
+		return
+	}
+
+	usage, err := h.quotas.Usage(r.Context(), domainID)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(usage); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// resetUsage zeroes the usage of the domain, e.g. after files were deleted
+// from storage.
+func (h *QuotaHandler) resetUsage(w http.ResponseWriter, r *http.Request) {
+	domainID, err := strconv.ParseInt(r.PathValue("domain"), 10, 64)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid domain id")
+
+		return
+	}
+
+	if err := h.quotas.ResetUsage(r.Context(), domainID); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
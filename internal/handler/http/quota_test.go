@@ -0,0 +1,72 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpmw "github.com/webitel/im-gateway-service/infra/server/http/middleware"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// fakeQuotas reports 10 bytes of usage for every domain until reset.
+type fakeQuotas struct {
+	reset map[int64]bool
+}
+
+func (f *fakeQuotas) Usage(_ context.Context, domainID int64) (*dto.MediaUsage, error) {
+	usage := &dto.MediaUsage{DomainID: domainID, MaxBytes: 100}
+	if !f.reset[domainID] {
+		usage.Bytes = 10
+	}
+
+	return usage, nil
+}
+
+func (f *fakeQuotas) ResetUsage(_ context.Context, domainID int64) error {
+	f.reset[domainID] = true
+
+	return nil
+}
+
+func TestQuotaHandler(t *testing.T) {
+	quotas := &fakeQuotas{reset: make(map[int64]bool)}
+	h := &QuotaHandler{logger: slog.New(slog.DiscardHandler), quotas: quotas}
+	mux := http.NewServeMux()
+	h.registerRoutes(mux, httpmw.WithAdminToken("s3cret"))
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	do := func(method, token string) *http.Response {
+		req, _ := http.NewRequest(method, ts.URL+"/admin/domains/7/media-usage", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+
+		return resp
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if resp := do(http.MethodGet, token); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("usage with token %q = %d, want 401", token, resp.StatusCode)
+		}
+	}
+
+	var usage dto.MediaUsage
+	resp := do(http.MethodGet, "s3cret")
+	if err := json.NewDecoder(resp.Body).Decode(&usage); err != nil || usage.DomainID != 7 || usage.Bytes != 10 {
+		t.Fatalf("usage = %d %+v, %v", resp.StatusCode, usage, err)
+	}
+
+	if resp := do(http.MethodDelete, "s3cret"); resp.StatusCode != http.StatusNoContent || !quotas.reset[7] {
+		t.Errorf("reset = %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxRanges bounds the parts of a multipart/byteranges response, counted
+	// after overlapping ranges are merged. Requests for more get the whole
+	// file.
+	maxRanges = 32
+	// rangeSkipLimit is how far ahead of the storage stream the first
+	// requested byte may be for the bytes in between to be read and dropped;
+	// beyond it the download is reopened at that byte.
+	rangeSkipLimit = 64 << 10
+)
+
+// rangeSpec is one byte-range-spec of a Range header (RFC 9110, section
+// 14.1.1): FIRST-LAST, FIRST- with last -1, or a suffix -LENGTH with first -1
+// and last the suffix length.
+type rangeSpec struct {
+	first, last int64
+}
+
+// byteRange is a satisfiable range of a file, end inclusive.
+type byteRange struct {
+	start, end int64
+}
+
+func (r byteRange) length() int64 {
+	return r.end - r.start + 1
+}
+
+// parseRange parses a Range header of the bytes unit. It reports false for
+// headers that are absent or malformed, which are to be ignored.
+func parseRange(header string) ([]rangeSpec, bool) {
+	set, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, false
+	}
+
+	var specs []rangeSpec
+	for spec := range strings.SplitSeq(set, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			// Empty list elements are allowed.
+			continue
+		}
+
+		first, last, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, false
+		}
+
+		if first == "" {
+			length, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || length < 0 {
+				return nil, false
+			}
+			specs = append(specs, rangeSpec{first: -1, last: length})
+
+			continue
+		}
+
+		start, err := strconv.ParseInt(first, 10, 64)
+		if err != nil || start < 0 {
+			return nil, false
+		}
+		end := int64(-1)
+		if last != "" {
+			if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+				return nil, false
+			}
+		}
+		specs = append(specs, rangeSpec{first: start, last: end})
+	}
+
+	return specs, len(specs) > 0
+}
+
+// rangeOffset is where to open a download for specs before the size of the
+// file is known: the lowest first byte requested, or 0 for suffix ranges.
+func rangeOffset(specs []rangeSpec) int64 {
+	offset := int64(-1)
+	for _, spec := range specs {
+		if spec.first < 0 {
+			return 0
+		}
+		if offset < 0 || spec.first < offset {
+			offset = spec.first
+		}
+	}
+
+	return max(offset, 0)
+}
+
+// resolveRanges returns the satisfiable ranges of specs for a file of size
+// bytes, in file order with overlapping and adjacent ranges merged, so the
+// file can be read in one forward pass. None are satisfiable if it is empty.
+func resolveRanges(specs []rangeSpec, size int64) []byteRange {
+	ranges := make([]byteRange, 0, len(specs))
+	for _, spec := range specs {
+		var r byteRange
+
+		switch {
+		case spec.first < 0:
+			if spec.last == 0 || size == 0 {
+				continue
+			}
+			r = byteRange{start: max(size-spec.last, 0), end: size - 1}
+		case spec.first >= size:
+			continue
+		case spec.last < 0 || spec.last >= size:
+			r = byteRange{start: spec.first, end: size - 1}
+		default:
+			r = byteRange{start: spec.first, end: spec.last}
+		}
+		ranges = append(ranges, r)
+	}
+
+	slices.SortFunc(ranges, func(a, b byteRange) int {
+		return cmp.Compare(a.start, b.start)
+	})
+
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.start <= merged[n-1].end+1 {
+			merged[n-1].end = max(merged[n-1].end, r.end)
+
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResolveRanges(t *testing.T) {
+	for _, tt := range []struct {
+		header string
+		size   int64
+		want   []byteRange
+		ok     bool
+	}{
+		{header: "bytes=0-99", size: 1000, want: []byteRange{{0, 99}}, ok: true},
+		{header: "bytes=900-", size: 1000, want: []byteRange{{900, 999}}, ok: true},
+		{header: "bytes=-500", size: 1000, want: []byteRange{{500, 999}}, ok: true},
+		{header: "bytes=-5000", size: 1000, want: []byteRange{{0, 999}}, ok: true},
+		{header: "bytes=990-1500", size: 1000, want: []byteRange{{990, 999}}, ok: true},
+		{header: "bytes=500-599, 0-99", size: 1000, want: []byteRange{{0, 99}, {500, 599}}, ok: true},
+		// Overlapping and adjacent ranges are merged.
+		{header: "bytes=0-99,50-149,150-199,-100", size: 1000, want: []byteRange{{0, 199}, {900, 999}}, ok: true},
+		// Unsatisfiable ranges are dropped.
+		{header: "bytes=2000-,0-9", size: 1000, want: []byteRange{{0, 9}}, ok: true},
+		{header: "bytes=1000-", size: 1000, want: []byteRange{}, ok: true},
+		{header: "bytes=-0", size: 1000, want: []byteRange{}, ok: true},
+		{header: "bytes=-10", size: 0, want: []byteRange{}, ok: true},
+		// Malformed headers are ignored.
+		{header: "bytes=9-1", size: 1000},
+		{header: "bytes=a-b", size: 1000},
+		{header: "bytes=", size: 1000},
+		{header: "items=0-1", size: 1000},
+	} {
+		specs, ok := parseRange(tt.header)
+		if ok != tt.ok {
+			t.Errorf("parseRange(%q) ok = %v", tt.header, ok)
+
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if got := resolveRanges(specs, tt.size); !slices.Equal(got, tt.want) {
+			t.Errorf("resolveRanges(%q, %d) = %v, want %v", tt.header, tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package http
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// tus 1.0 resumable uploads (https://tus.io/protocols/resumable-upload), core
+// protocol with the creation, termination and expiration extensions, over the
+// upload sessions of service.Media. The checksum extension is not served: its
+// Upload-Checksum is the checksum of the body of one PATCH, which reaches
+// storage before it could be verified and discarded. Clients declare the
+// checksum of the whole file with fileChecksumHeader instead.
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+
+	tusResumableHeader = "Tus-Resumable"
+	uploadOffsetHeader = "Upload-Offset"
+	uploadLengthHeader = "Upload-Length"
+	// uploadChecksumHeader is only read from a PUT /media body holding the
+	// whole file, where the checksum of the body is that of the file.
+	uploadChecksumHeader = "Upload-Checksum"
+
+	// fileIDHeader carries the ID of the stored file on the PATCH that
+	// completes an upload: tus itself has no way to return it.
+	fileIDHeader = "X-Webitel-File-Id"
+	// fileChecksumHeader carries the checksum of the whole file, on any write
+	// of an upload: "sha256 <base64>" or "crc32c <base64>".
+	fileChecksumHeader = "X-Webitel-File-Checksum"
+
+	offsetOctetStream = "application/offset+octet-stream"
+)
+
+// withTus rejects requests of another tus version and marks every response
+// with the version served.
+func withTus(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(tusResumableHeader, tusVersion)
+
+		if r.Header.Get(tusResumableHeader) != tusVersion {
+			w.Header().Set("Tus-Version", tusVersion)
+			renderError(w, http.StatusPreconditionFailed, "api.unsupported_version", "unsupported tus version")
+
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// tusOptions describes the tus support of the server.
+func (h *Handler) tusOptions(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(tusResumableHeader, tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	if h.maxUploadSize > 0 {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(h.maxUploadSize, 10))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tusCreate starts an upload of Upload-Length bytes. The file name is taken
+// from the filename (or name) key of Upload-Metadata.
+func (h *Handler) tusCreate(w http.ResponseWriter, r *http.Request) {
+	length, err := strconv.ParseInt(r.Header.Get(uploadLengthHeader), 10, 64)
+	if err != nil || length < 0 {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid or missing Upload-Length")
+
+		return
+	}
+	if length == 0 {
+		writeError(w, service.ErrEmptyBody)
+
+		return
+	}
+	if h.maxUploadSize > 0 && length > h.maxUploadSize {
+		renderError(w, http.StatusRequestEntityTooLarge, "api.request_too_large", "Upload-Length exceeds Tus-Max-Size")
+
+		return
+	}
+
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid Upload-Metadata")
+
+		return
+	}
+	name := metadata["filename"]
+	if name == "" {
+		name = metadata["name"]
+	}
+
+	info, err := h.media.CreateUpload(r.Context(), &dto.CreateUploadRequest{Name: name, Length: length})
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	// Relative to the request URL, so that it holds behind a proxy serving
+	// the gateway under a path prefix.
+	w.Header().Set("Location", "media/"+info.ID)
+	w.Header().Set("Upload-Expires", uploadExpires(info.ExpiresAt))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// tusHead reports how much of an upload the server has.
+func (h *Handler) tusHead(w http.ResponseWriter, r *http.Request) {
+	info, err := h.media.GetUpload(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeTusError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(info.Offset, 10))
+	if info.Length > 0 {
+		w.Header().Set(uploadLengthHeader, strconv.FormatInt(info.Length, 10))
+	}
+	w.Header().Set("Upload-Expires", uploadExpires(info.ExpiresAt))
+	w.WriteHeader(http.StatusOK)
+}
+
+// tusPatch continues an upload from Upload-Offset. The checksum of the whole
+// file may be declared with any PATCH; Upload-Checksum is ignored, as by
+// servers without the checksum extension.
+func (h *Handler) tusPatch(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != offsetOctetStream {
+		renderError(w, http.StatusUnsupportedMediaType, "api.unsupported_media", "Content-Type must be "+offsetOctetStream)
+
+		return
+	}
+
+	checksum, err := parseChecksum(r.Header, false)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "api.bad_args", err.Error())
+
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		renderError(w, http.StatusBadRequest, "api.bad_args", "invalid or missing Upload-Offset")
+
+		return
+	}
+
+	res, err := h.media.WriteUpload(r.Context(), &dto.WriteUploadRequest{
+		UploadID: r.PathValue("id"),
+		Offset:   offset,
+		Body:     r.Body,
+		Checksum: checksum,
+	})
+	if err != nil {
+		writeTusError(w, err)
+
+		return
+	}
+
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(res.Offset, 10))
+	if res.File != nil {
+		w.Header().Set(fileIDHeader, res.File.ID)
+	} else if info, err := h.media.GetUpload(r.Context(), r.PathValue("id")); err == nil {
+		w.Header().Set("Upload-Expires", uploadExpires(info.ExpiresAt))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tusTerminate discards an upload.
+func (h *Handler) tusTerminate(w http.ResponseWriter, r *http.Request) {
+	if err := h.media.TerminateUploadSession(r.PathValue("id")); err != nil {
+		writeTusError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTusError is writeError, but with uploads that are over, complete or
+// not, reported gone as tus expects.
+func writeTusError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrSessionDone) {
+		renderError(w, http.StatusGone, "api.gone", err.Error())
+
+		return
+	}
+
+	writeError(w, err)
+}
+
+// parseUploadMetadata decodes Upload-Metadata: comma-separated pairs of a key
+// and an optional base64 value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for pair := range strings.SplitSeq(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
+
+// uploadExpires formats t for the Upload-Expires header.
+func uploadExpires(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func tus(t *testing.T, method, url string, header http.Header, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header = header
+	req.Header.Set(tusResumableHeader, tusVersion)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return resp
+}
+
+func TestTus_Upload(t *testing.T) {
+	media := newFakeMedia()
+	ts := newMediaServer(t, media)
+
+	// "report.txt"
+	resp := tus(t, http.MethodPost, ts.URL+"/media", http.Header{
+		uploadLengthHeader: {"11"},
+		"Upload-Metadata":  {"filename cmVwb3J0LnR4dA==,private"},
+	}, "")
+	location := resp.Header.Get("Location")
+	if resp.StatusCode != http.StatusCreated || !strings.HasPrefix(location, "media/") || resp.Header.Get("Upload-Expires") == "" {
+		t.Fatalf("create = %d, Location %q, want one relative to /media", resp.StatusCode, location)
+	}
+	if resp.Header.Get(tusResumableHeader) != tusVersion {
+		t.Errorf("create did not report %s", tusResumableHeader)
+	}
+
+	chunk := func(offset, body string) *http.Response {
+		return tus(t, http.MethodPatch, ts.URL+"/"+location, http.Header{
+			"Content-Type":     {offsetOctetStream},
+			uploadOffsetHeader: {offset},
+		}, body)
+	}
+
+	if resp = chunk("0", "hello "); resp.StatusCode != http.StatusNoContent || resp.Header.Get(uploadOffsetHeader) != "6" {
+		t.Fatalf("first PATCH = %d, offset %q", resp.StatusCode, resp.Header.Get(uploadOffsetHeader))
+	}
+
+	resp = tus(t, http.MethodHead, ts.URL+"/"+location, http.Header{}, "")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(uploadOffsetHeader) != "6" || resp.Header.Get(uploadLengthHeader) != "11" {
+		t.Errorf("HEAD = %d, offset %q, length %q", resp.StatusCode, resp.Header.Get(uploadOffsetHeader), resp.Header.Get(uploadLengthHeader))
+	}
+
+	if resp = chunk("3", "world"); resp.StatusCode != http.StatusConflict {
+		t.Errorf("PATCH at a stale offset = %d, want 409", resp.StatusCode)
+	}
+
+	resp = chunk("6", "world")
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get(fileIDHeader) == "" {
+		t.Fatalf("last PATCH = %d, file %q", resp.StatusCode, resp.Header.Get(fileIDHeader))
+	}
+	if upload := media.uploads[strings.TrimPrefix(location, "media/")]; string(upload.data) != "hello world" || upload.name != "report.txt" {
+		t.Errorf("stored %q as %q", upload.data, upload.name)
+	}
+
+	if resp = tus(t, http.MethodHead, ts.URL+"/"+location, http.Header{}, ""); resp.StatusCode != http.StatusGone {
+		t.Errorf("HEAD of a complete upload = %d, want 410", resp.StatusCode)
+	}
+}
+
+func TestTus_FileChecksum(t *testing.T) {
+	ts := newMediaServer(t, newFakeMedia())
+
+	resp := tus(t, http.MethodPost, ts.URL+"/media", http.Header{uploadLengthHeader: {"5"}}, "")
+	resp = tus(t, http.MethodPatch, ts.URL+"/"+resp.Header.Get("Location"), http.Header{
+		"Content-Type":     {offsetOctetStream},
+		uploadOffsetHeader: {"0"},
+		fileChecksumHeader: {"crc32c AAAAAA=="},
+	}, "hello")
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("PATCH completing a file that does not match its checksum = %d, want 422", resp.StatusCode)
+	}
+}
+
+func TestTus_Negotiation(t *testing.T) {
+	ts := newMediaServer(t, newFakeMedia())
+
+	req, _ := http.NewRequest(http.MethodOptions, ts.URL+"/media", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Tus-Extension") != tusExtensions || resp.Header.Get("Tus-Max-Size") != "1048576" {
+		t.Errorf("OPTIONS = %d %v", resp.StatusCode, resp.Header)
+	}
+
+	header := http.Header{}
+	header.Set(tusResumableHeader, "0.2.2")
+	req, _ = http.NewRequest(http.MethodHead, ts.URL+"/media/u1", nil)
+	req.Header = header
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("HEAD with tus 0.2.2 = %d, want 412", resp.StatusCode)
+	}
+
+	if resp = tus(t, http.MethodPost, ts.URL+"/media", http.Header{uploadLengthHeader: {"2097152"}}, ""); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("create over Tus-Max-Size = %d, want 413", resp.StatusCode)
+	}
+}
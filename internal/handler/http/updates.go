@@ -0,0 +1,305 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"go.uber.org/fx"
+
+	"github.com/webitel/im-gateway-service/config"
+	httpmw "github.com/webitel/im-gateway-service/infra/server/http/middleware"
+	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+const (
+	// streamKeepaliveInterval is how often a WebSocket is pinged and an event
+	// stream gets a heartbeat comment, so proxies do not drop idle streams.
+	streamKeepaliveInterval = 30 * time.Second
+	// streamWriteTimeout bounds a single frame write or ping round trip.
+	streamWriteTimeout = 10 * time.Second
+	// sseRetry is the reconnection delay advertised to EventSource clients.
+	sseRetry = 3 * time.Second
+)
+
+// UpdatesHandler streams realtime updates of the authenticated identity to
+// browser clients over WebSocket or, where WebSockets are blocked, over
+// Server-Sent Events.
+type UpdatesHandler struct {
+	logger         *slog.Logger
+	updater        service.Updater
+	presence       service.Presence
+	originPatterns []string
+
+	// done is closed when the HTTP server starts shutting down; conns tracks
+	// the streams still open so OnStop can wait for them to say goodbye.
+	done     chan struct{}
+	doneOnce sync.Once
+	conns    sync.WaitGroup
+}
+
+func NewUpdatesHandler(
+	logger *slog.Logger,
+	cfg *config.Config,
+	updater service.Updater,
+	presence service.Presence,
+	authMW func(http.Handler) http.Handler,
+	mux *http.ServeMux,
+	lc fx.Lifecycle,
+) *UpdatesHandler {
+	h := &UpdatesHandler{
+		logger:         logger,
+		updater:        updater,
+		presence:       presence,
+		originPatterns: originPatterns(cfg.Service.HTTP.CORS.AllowedOrigins),
+		done:           make(chan struct{}),
+	}
+	h.registerRoutes(mux, authMW)
+
+	lc.Append(fx.StopHook(func(ctx context.Context) error {
+		h.Shutdown()
+
+		return h.wait(ctx)
+	}))
+
+	return h
+}
+
+func (h *UpdatesHandler) registerRoutes(mux *http.ServeMux, authMW func(http.Handler) http.Handler) {
+	mux.Handle("GET /ws", httpmw.WithQueryToken(authMW(http.HandlerFunc(h.serveWebSocket))))
+	mux.Handle("GET /events", httpmw.WithQueryToken(authMW(http.HandlerFunc(h.serveEvents))))
+}
+
+// Shutdown tells every open stream to close. It does not wait for them.
+func (h *UpdatesHandler) Shutdown() {
+	h.doneOnce.Do(func() { close(h.done) })
+}
+
+func (h *UpdatesHandler) wait(ctx context.Context) error {
+	waitCh := make(chan struct{})
+	go func() {
+		h.conns.Wait()
+		close(waitCh)
+	}()
+
+	select {
+	case <-waitCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// serveWebSocket upgrades the request and writes every update as a JSON text
+// frame. Client frames are ignored, except for control frames. Every frame
+// carries the update cursor; a reconnecting client passes the last one it
+// received as the after query parameter to resume from the replay buffer.
+func (h *UpdatesHandler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-h.done:
+		writeError(w, service.ErrUpdaterClosed)
+
+		return
+	default:
+	}
+
+	sub, err := h.updater.Subscribe(r.Context(), &dto.SubscribeRequest{After: r.URL.Query().Get("after")})
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+	defer sub.Unsubscribe()
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: h.originPatterns,
+	})
+	if err != nil {
+		// Accept has already written the HTTP error.
+		h.logger.Debug("websocket upgrade failed", "err", err)
+
+		return
+	}
+
+	h.conns.Add(1)
+	defer h.conns.Done()
+	defer h.presence.Track(r.Context())()
+
+	// CloseRead handles pongs and close frames; ctx is canceled once the
+	// client goes away.
+	ctx := conn.CloseRead(r.Context())
+
+	ticker := time.NewTicker(streamKeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			conn.CloseNow()
+
+			return
+		case <-h.done:
+			conn.Close(websocket.StatusGoingAway, "server is shutting down")
+
+			return
+		case update, ok := <-sub.Updates():
+			if !ok {
+				h.closeWithReason(conn, sub.Err())
+
+				return
+			}
+
+			writeCtx, cancel := context.WithTimeout(ctx, streamWriteTimeout)
+			err := wsjson.Write(writeCtx, conn, update)
+			cancel()
+			if err != nil {
+				h.logger.Debug("websocket write failed", "err", err)
+				conn.CloseNow()
+
+				return
+			}
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, streamWriteTimeout)
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				h.logger.Debug("websocket ping failed", "err", err)
+				conn.CloseNow()
+
+				return
+			}
+		}
+	}
+}
+
+// serveEvents streams updates as Server-Sent Events for clients that cannot
+// use WebSockets. Every event carries the update cursor as its id, so a
+// reconnecting EventSource resumes through Last-Event-ID from the replay
+// buffer. The lastEventId query parameter serves clients that reconnect by
+// opening a fresh EventSource.
+func (h *UpdatesHandler) serveEvents(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-h.done:
+		writeError(w, service.ErrUpdaterClosed)
+
+		return
+	default:
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	sub, err := h.updater.Subscribe(r.Context(), &dto.SubscribeRequest{After: lastEventID})
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+	defer sub.Unsubscribe()
+
+	h.conns.Add(1)
+	defer h.conns.Done()
+	defer h.presence.Track(r.Context())()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stop nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// write sends one chunk and flushes it, giving up on a stalled client.
+	write := func(chunk []byte) error {
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+
+		return rc.Flush()
+	}
+
+	if err := write(fmt.Appendf(nil, "retry: %d\n\n", sseRetry.Milliseconds())); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(streamKeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case update, ok := <-sub.Updates():
+			if !ok {
+				// A slow consumer is dropped here and resumes from the
+				// replay buffer once EventSource reconnects.
+				return
+			}
+
+			data, err := json.Marshal(update)
+			if err != nil {
+				h.logger.Error("marshal update", "update_id", update.ID, "err", err)
+
+				continue
+			}
+
+			if err := write(fmt.Appendf(nil, "id: %s\nevent: %s\ndata: %s\n\n", update.Cursor, update.Type, data)); err != nil {
+				h.logger.Debug("event stream write failed", "err", err)
+
+				return
+			}
+		case <-ticker.C:
+			if err := write([]byte(": keepalive\n\n")); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *UpdatesHandler) closeWithReason(conn *websocket.Conn, reason error) {
+	switch {
+	case errors.Is(reason, service.ErrSlowConsumer):
+		conn.Close(websocket.StatusPolicyViolation, "too slow to keep up with updates")
+	case errors.Is(reason, service.ErrUpdaterClosed):
+		conn.Close(websocket.StatusGoingAway, "server is shutting down")
+	default:
+		conn.Close(websocket.StatusNormalClosure, "")
+	}
+}
+
+// originPatterns converts the CORS allowed origins into the host patterns the
+// WebSocket handshake checks the Origin header against.
+func originPatterns(allowedOrigins string) []string {
+	if allowedOrigins == "" {
+		return []string{"*"}
+	}
+
+	var patterns []string
+	for _, origin := range strings.Split(allowedOrigins, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		if u, err := url.Parse(origin); err == nil && u.Host != "" {
+			origin = u.Host
+		}
+		patterns = append(patterns, origin)
+	}
+
+	return patterns
+}
@@ -0,0 +1,259 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/infra/pubsub"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory/amqp"
+	"github.com/webitel/im-gateway-service/internal/model"
+	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+const (
+	// cloudEventsSpecVersion is the CloudEvents version of the envelope.
+	cloudEventsSpecVersion = "1.0"
+	// eventTypePrefix and eventTypeVersion wrap the domain event type into
+	// the envelope type, e.g. "com.webitel.im.gateway.message.sent.v1".
+	// Bump the version when the data of an event changes incompatibly.
+	eventTypePrefix  = "com.webitel.im.gateway."
+	eventTypeVersion = ".v1"
+)
+
+var _ service.EventEmitter = (*EventPublisher)(nil)
+
+// cloudEvent is the CloudEvents 1.0 JSON envelope of a domain event.
+// domainid is an extension attribute, so consumers can filter by domain
+// without decoding data.
+type cloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	DomainID        int64     `json:"domainid"`
+	Data            eventData `json:"data"`
+}
+
+type eventData struct {
+	DomainID int64             `json:"domain_id"`
+	Actor    eventActor        `json:"actor"`
+	Objects  map[string]string `json:"objects,omitempty"`
+}
+
+type eventActor struct {
+	ContactID string `json:"contact_id"`
+}
+
+// pendingEvent is an event waiting to be published.
+type pendingEvent struct {
+	routingKey string
+	msg        *message.Message
+}
+
+// EventPublisher publishes domain events to the events exchange, routed by
+// their type. Emit only queues the event: a single worker publishes the queue
+// in order and, while the broker refuses, retries the oldest event with
+// exponential backoff. The queue is bounded; when it overflows the oldest
+// events are dropped.
+type EventPublisher struct {
+	logger    *slog.Logger
+	publisher message.Publisher
+	cfg       config.EventsConfig
+
+	mu      sync.Mutex
+	pending []*pendingEvent
+	closed  bool
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewEventPublisher(logger *slog.Logger, cfg *config.Config, provider pubsub.Provider) (*EventPublisher, error) {
+	publisher, err := provider.GetFactory().BuildPublisher(&factory.PublisherConfig{
+		Exchange: factory.ExchangeConfig{
+			Name:    cfg.Service.Events.Exchange,
+			Type:    amqp.TopicExchangeType,
+			Durable: true,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newEventPublisher(logger, publisher, cfg.Service.Events), nil
+}
+
+func newEventPublisher(logger *slog.Logger, publisher message.Publisher, cfg config.EventsConfig) *EventPublisher {
+	return &EventPublisher{
+		logger:    logger,
+		publisher: publisher,
+		cfg:       cfg,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start runs the publishing worker.
+func (p *EventPublisher) Start() {
+	go p.run()
+}
+
+// Close stops the worker after one last attempt to publish what is queued,
+// and releases the broker channel. Events still queued are lost.
+func (p *EventPublisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	close(p.stop)
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if n := p.pendingCount(); n > 0 {
+		p.logger.Warn("domain events lost on shutdown", "count", n)
+	}
+
+	return p.publisher.Close()
+}
+
+// Emit implements service.EventEmitter.
+func (p *EventPublisher) Emit(_ context.Context, event *dto.DomainEvent) {
+	envelope := toCloudEvent(event)
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		p.logger.Error("marshal domain event", "type", event.Type, "err", err)
+
+		return
+	}
+
+	msg := message.NewMessage(envelope.ID, payload)
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.logger.Warn("domain event emitted after shutdown, dropped", "type", event.Type, "subject", event.Subject)
+
+		return
+	}
+	if len(p.pending) >= p.cfg.BufferSize {
+		dropped := p.pending[0]
+		p.pending[0] = nil
+		p.pending = p.pending[1:]
+		p.logger.Warn("domain events buffer is full, dropping the oldest event",
+			"routing_key", dropped.routingKey,
+			"event_id", dropped.msg.UUID,
+		)
+	}
+	p.pending = append(p.pending, &pendingEvent{routingKey: string(event.Type), msg: msg})
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *EventPublisher) run() {
+	defer close(p.done)
+
+	var (
+		backoff time.Duration
+		retry   <-chan time.Time
+	)
+	for {
+		select {
+		case <-p.stop:
+			_ = p.flush()
+
+			return
+		case <-p.wake:
+			if retry != nil {
+				// Already backing off: the new event waits for the retry.
+				continue
+			}
+		case <-retry:
+		}
+
+		if err := p.flush(); err != nil {
+			backoff = min(max(2*backoff, p.cfg.RetryInterval), max(p.cfg.MaxRetryBackoff, p.cfg.RetryInterval))
+			retry = time.After(backoff)
+			p.logger.Warn("publish domain events, will retry",
+				"pending", p.pendingCount(),
+				"retry_in", backoff,
+				"err", err,
+			)
+
+			continue
+		}
+		backoff, retry = 0, nil
+	}
+}
+
+// flush publishes queued events oldest first, stopping at the first failure.
+func (p *EventPublisher) flush() error {
+	for {
+		p.mu.Lock()
+		if len(p.pending) == 0 {
+			p.mu.Unlock()
+
+			return nil
+		}
+		head := p.pending[0]
+		p.mu.Unlock()
+
+		if err := p.publisher.Publish(head.routingKey, head.msg); err != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		// The head may have been dropped by an overflow while publishing.
+		if len(p.pending) > 0 && p.pending[0] == head {
+			p.pending[0] = nil
+			p.pending = p.pending[1:]
+		}
+		p.mu.Unlock()
+	}
+}
+
+func (p *EventPublisher) pendingCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.pending)
+}
+
+func toCloudEvent(event *dto.DomainEvent) *cloudEvent {
+	return &cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              uuid.NewString(),
+		Source:          model.ServiceName,
+		Type:            eventTypePrefix + string(event.Type) + eventTypeVersion,
+		Subject:         event.Subject,
+		Time:            event.Time,
+		DataContentType: "application/json",
+		DomainID:        event.DomainID,
+		Data: eventData{
+			DomainID: event.DomainID,
+			Actor:    eventActor{ContactID: event.ActorID},
+			Objects:  event.Objects,
+		},
+	}
+}
@@ -0,0 +1,123 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// flakyPublisher refuses the first failures publish calls.
+type flakyPublisher struct {
+	mu        sync.Mutex
+	failures  int
+	published []*message.Message
+	keys      []string
+}
+
+func (p *flakyPublisher) Publish(topic string, messages ...*message.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures > 0 {
+		p.failures--
+
+		return errors.New("broker unavailable")
+	}
+	p.keys = append(p.keys, topic)
+	p.published = append(p.published, messages...)
+
+	return nil
+}
+
+func (p *flakyPublisher) Close() error { return nil }
+
+func (p *flakyPublisher) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.published)
+}
+
+func testEventsConfig(bufferSize int) config.EventsConfig {
+	return config.EventsConfig{
+		BufferSize:      bufferSize,
+		RetryInterval:   time.Millisecond,
+		MaxRetryBackoff: 5 * time.Millisecond,
+	}
+}
+
+func TestEventPublisher_RetriesInOrder(t *testing.T) {
+	broker := &flakyPublisher{failures: 3}
+	publisher := newEventPublisher(slog.Default(), broker, testEventsConfig(10))
+	publisher.Start()
+	t.Cleanup(func() { _ = publisher.Close(context.Background()) })
+
+	for _, subject := range []string{"m1", "m2", "m3"} {
+		publisher.Emit(context.Background(), &dto.DomainEvent{
+			Type:     dto.EventMessageSent,
+			DomainID: 1,
+			ActorID:  "alice",
+			Subject:  subject,
+			Objects:  map[string]string{"message_id": subject},
+			Time:     time.Now(),
+		})
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for broker.count() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("published %d events, want 3", broker.count())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for i, want := range []string{"m1", "m2", "m3"} {
+		var envelope cloudEvent
+		if err := json.Unmarshal(broker.published[i].Payload, &envelope); err != nil {
+			t.Fatal(err)
+		}
+		if envelope.Subject != want {
+			t.Errorf("event %d subject = %q, want %q", i, envelope.Subject, want)
+		}
+		if envelope.Type != "com.webitel.im.gateway.message.sent.v1" || envelope.Data.Actor.ContactID != "alice" {
+			t.Errorf("unexpected envelope %+v", envelope)
+		}
+		if broker.keys[i] != string(dto.EventMessageSent) {
+			t.Errorf("routing key = %q, want %q", broker.keys[i], dto.EventMessageSent)
+		}
+	}
+}
+
+func TestEventPublisher_DropsOldestWhenFull(t *testing.T) {
+	broker := &flakyPublisher{}
+	// Not started: events stay queued.
+	publisher := newEventPublisher(slog.Default(), broker, testEventsConfig(2))
+
+	for _, subject := range []string{"b1", "b2", "b3"} {
+		publisher.Emit(context.Background(), &dto.DomainEvent{Type: dto.EventBotCreated, Subject: subject})
+	}
+
+	if n := publisher.pendingCount(); n != 2 {
+		t.Fatalf("pending = %d, want 2", n)
+	}
+	if err := publisher.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var envelope cloudEvent
+	if err := json.Unmarshal(broker.published[0].Payload, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Subject != "b2" {
+		t.Errorf("oldest kept event = %q, want b2", envelope.Subject)
+	}
+}
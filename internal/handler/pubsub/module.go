@@ -0,0 +1,53 @@
+package pubsub
+
+import (
+	"context"
+	"log/slog"
+
+	"go.uber.org/fx"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/infra/pubsub"
+	"github.com/webitel/im-gateway-service/internal/service"
+)
+
+var Module = fx.Module("pubsub_handler",
+	fx.Provide(
+		fx.Annotate(
+			func(cfg *config.Config, provider pubsub.Provider, lc fx.Lifecycle) (*UpdatePublisher, error) {
+				publisher, err := NewUpdatePublisher(cfg, provider)
+				if err != nil {
+					return nil, err
+				}
+				lc.Append(fx.StopHook(func(context.Context) error { return publisher.Close() }))
+
+				return publisher, nil
+			},
+			fx.As(new(service.UpdatePublisher)),
+		),
+		fx.Annotate(
+			func(logger *slog.Logger, cfg *config.Config, provider pubsub.Provider, lc fx.Lifecycle) (*EventPublisher, error) {
+				publisher, err := NewEventPublisher(logger, cfg, provider)
+				if err != nil {
+					return nil, err
+				}
+				lc.Append(fx.Hook{
+					OnStart: func(context.Context) error {
+						publisher.Start()
+
+						return nil
+					},
+					OnStop: publisher.Close,
+				})
+
+				return publisher, nil
+			},
+			fx.As(new(service.EventEmitter)),
+		),
+	),
+	fx.Invoke(
+		RegisterUpdatesHandler,
+		RegisterWebhooksHandler,
+		RegisterPushHandler,
+	),
+)
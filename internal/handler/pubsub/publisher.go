@@ -0,0 +1,93 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/infra/pubsub"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory/amqp"
+	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// updatesRoutingPrefix prefixes the update type in the routing key of
+// published updates, matching the default updates queue binding.
+const updatesRoutingPrefix = "updates."
+
+var _ service.UpdatePublisher = (*UpdatePublisher)(nil)
+
+// UpdatePublisher publishes gateway updates to the gateway updates exchange
+// in the same envelope im-thread uses. Messages are transient and expire
+// after service.updates.publish_ttl: a late realtime update is worthless.
+type UpdatePublisher struct {
+	publisher message.Publisher
+}
+
+func NewUpdatePublisher(cfg *config.Config, provider pubsub.Provider) (*UpdatePublisher, error) {
+	publisher, err := provider.GetFactory().BuildPublisher(&factory.PublisherConfig{
+		Exchange: factory.ExchangeConfig{
+			Name:    cfg.Service.Updates.GatewayExchange,
+			Type:    amqp.TopicExchangeType,
+			Durable: true,
+		},
+		Transient: true,
+		TTL:       cfg.Service.Updates.PublishTTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UpdatePublisher{publisher: publisher}, nil
+}
+
+// Publish implements service.UpdatePublisher.
+func (p *UpdatePublisher) Publish(ctx context.Context, u *dto.Update) error {
+	payload, err := json.Marshal(fromUpdate(u))
+	if err != nil {
+		return err
+	}
+
+	msg := message.NewMessage(u.ID, payload)
+	msg.SetContext(ctx)
+
+	return p.publisher.Publish(updatesRoutingPrefix+string(u.Type), msg)
+}
+
+// Close releases the broker channel of the publisher.
+func (p *UpdatePublisher) Close() error {
+	return p.publisher.Close()
+}
+
+// fromUpdate is the inverse of toUpdate for the update types the gateway
+// publishes itself.
+func fromUpdate(u *dto.Update) *threadEvent {
+	event := &threadEvent{
+		ID:         u.ID,
+		Type:       u.Type,
+		DomainID:   u.DomainID,
+		ThreadID:   u.ThreadID,
+		Date:       u.Date,
+		ExpiresAt:  u.ExpiresAt,
+		Recipients: u.Recipients,
+	}
+
+	if u.Read != nil {
+		event.Read = &threadRead{
+			MessageID: u.Read.MessageID,
+			ContactID: u.Read.ContactID,
+			ReadAt:    u.Read.ReadAt,
+		}
+	}
+	if u.ChatAction != nil {
+		event.ChatAction = &threadChatAction{
+			ContactID: u.ChatAction.ContactID,
+			Action:    string(u.ChatAction.Action),
+		}
+	}
+
+	return event
+}
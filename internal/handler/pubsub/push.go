@@ -0,0 +1,172 @@
+package pubsub
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/webitel/im-gateway-service/config"
+	authv1 "github.com/webitel/im-gateway-service/gen/go/auth/v1"
+	"github.com/webitel/im-gateway-service/infra/pubsub"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory/amqp"
+	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+const (
+	pushHandlerName    = "push_notifications"
+	devicesHandlerName = "push_devices"
+)
+
+// RegisterPushHandler consumes new messages and device registrations from
+// queues shared by all replicas, so each notification is sent once.
+func RegisterPushHandler(
+	cfg *config.Config,
+	logger *slog.Logger,
+	provider pubsub.Provider,
+	pusher service.Pusher,
+	devices service.PushDevices,
+) error {
+	pushConfig := cfg.Service.Push
+	if !pushConfig.Enabled {
+		return nil
+	}
+
+	messages, err := provider.GetFactory().BuildSubscriber(pushHandlerName, &factory.SubscriberConfig{
+		Exchange: factory.ExchangeConfig{
+			Name:    cfg.Service.Updates.Exchange,
+			Type:    amqp.TopicExchangeType,
+			Durable: true,
+		},
+		Queue:      pushConfig.Queue,
+		RoutingKey: pushConfig.RoutingKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	deviceUpdates, err := provider.GetFactory().BuildSubscriber(devicesHandlerName, &factory.SubscriberConfig{
+		Exchange: factory.ExchangeConfig{
+			Name:    pushConfig.DevicesExchange,
+			Type:    amqp.TopicExchangeType,
+			Durable: true,
+		},
+		Queue:      pushConfig.DevicesQueue,
+		RoutingKey: pushConfig.DevicesRoutingKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	h := &pushHandler{logger: logger, pusher: pusher, devices: devices}
+	router := provider.GetRouter()
+	router.AddConsumerHandler(pushHandlerName, cfg.Service.Updates.Exchange, messages, h.handleMessage)
+	router.AddConsumerHandler(devicesHandlerName, pushConfig.DevicesExchange, deviceUpdates, h.handleDevice)
+
+	return nil
+}
+
+type pushHandler struct {
+	logger  *slog.Logger
+	pusher  service.Pusher
+	devices service.PushDevices
+}
+
+func (h *pushHandler) handleMessage(msg *message.Message) error {
+	update, err := decodeUpdate(msg)
+	if err != nil {
+		h.logger.Warn("drop thread event", "message_uuid", msg.UUID, "err", err)
+		return nil
+	}
+
+	return h.pusher.Notify(msg.Context(), update)
+}
+
+// handleDevice applies an im-auth device update. They all carry the session
+// authorization and differ only in the routing key: updates.device.register,
+// updates.device.unregister or updates.device.logout.
+func (h *pushHandler) handleDevice(msg *message.Message) error {
+	routingKey := msg.Metadata.Get(factory.RoutingKeyMetadata)
+	action := routingKey[strings.LastIndex(routingKey, ".")+1:]
+
+	var (
+		authorization *authv1.Authorization
+		err           error
+	)
+	switch action {
+	case "register":
+		var update authv1.UpdateDeviceRegister
+		err = unmarshalDeviceUpdate(msg.Payload, &update)
+		authorization = update.GetAuthorization()
+	case "unregister":
+		var update authv1.UpdateDeviceUnregister
+		err = unmarshalDeviceUpdate(msg.Payload, &update)
+		authorization = update.GetAuthorization()
+	case "logout":
+		var update authv1.UpdateDeviceLogout
+		err = unmarshalDeviceUpdate(msg.Payload, &update)
+		authorization = update.GetAuthorization()
+	default:
+		h.logger.Debug("skip device update", "routing_key", routingKey)
+		return nil
+	}
+	if err != nil {
+		h.logger.Warn("drop device update", "message_uuid", msg.UUID, "routing_key", routingKey, "err", err)
+		return nil
+	}
+
+	domainID := authorization.GetDc()
+	contactID := authorization.GetContact().GetId()
+	if contactID == "" || authorization.GetId() == "" {
+		h.logger.Warn("drop device update without session or contact", "message_uuid", msg.UUID)
+		return nil
+	}
+
+	if action != "register" {
+		return h.devices.Unregister(msg.Context(), domainID, contactID, authorization.GetId())
+	}
+
+	device, err := toPushDevice(authorization)
+	if err != nil {
+		h.logger.Warn("drop device update", "message_uuid", msg.UUID, "err", err)
+		return nil
+	}
+
+	return h.devices.Register(msg.Context(), domainID, contactID, device)
+}
+
+// unmarshalDeviceUpdate decodes the protojson encoding im-auth publishes.
+func unmarshalDeviceUpdate(payload []byte, update proto.Message) error {
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(payload, update)
+}
+
+func toPushDevice(authorization *authv1.Authorization) (*dto.PushDevice, error) {
+	push := authorization.GetDevice().GetPush()
+	device := &dto.PushDevice{
+		SessionID: authorization.GetId(),
+		Secret:    push.GetSecret(),
+	}
+
+	switch token := push.GetToken().(type) {
+	case *authv1.PUSHSubscription_Fcm:
+		device.Provider, device.Token = dto.PushProviderFCM, token.Fcm
+	case *authv1.PUSHSubscription_Apn:
+		device.Provider, device.Token = dto.PushProviderAPNs, token.Apn
+	case *authv1.PUSHSubscription_Web:
+		device.Provider, device.Token = dto.PushProviderWeb, token.Web.GetEndpoint()
+		device.P256dh = token.Web.GetKey().GetP256Dh()
+		device.Auth = token.Web.GetKey().GetAuth()
+	default:
+		return nil, fmt.Errorf("no push token in session %s", authorization.GetId())
+	}
+	if device.Token == "" {
+		return nil, fmt.Errorf("empty push token in session %s", authorization.GetId())
+	}
+
+	return device, nil
+}
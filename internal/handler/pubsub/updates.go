@@ -0,0 +1,269 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/infra/pubsub"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory/amqp"
+	"github.com/webitel/im-gateway-service/internal/model"
+	"github.com/webitel/im-gateway-service/internal/service"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+const (
+	updatesHandlerName        = "thread_updates"
+	gatewayUpdatesHandlerName = "gateway_updates"
+	// membersLookupTimeout bounds reading the members of a thread an event
+	// is about; the event goes to its listed recipients when it runs out.
+	membersLookupTimeout = 5 * time.Second
+)
+
+// threadEvent is the envelope im-thread publishes for every realtime event.
+type threadEvent struct {
+	ID         string            `json:"id"`
+	Type       dto.UpdateType    `json:"type"`
+	DomainID   int64             `json:"domain_id"`
+	ThreadID   string            `json:"thread_id"`
+	Date       int64             `json:"date"`
+	ExpiresAt  int64             `json:"expires_at,omitempty"`
+	Recipients []string          `json:"recipients"`
+	Message    *threadMessage    `json:"message,omitempty"`
+	Read       *threadRead       `json:"read,omitempty"`
+	Member     *threadMember     `json:"member,omitempty"`
+	Variables  map[string]string `json:"variables,omitempty"`
+	ChatAction *threadChatAction `json:"chat_action,omitempty"`
+}
+
+type threadMessage struct {
+	ID        string            `json:"id"`
+	SenderID  string            `json:"sender_id"`
+	Type      string            `json:"type"`
+	Body      string            `json:"body"`
+	Metadata  map[string]string `json:"metadata"`
+	Documents []*threadDocument `json:"documents"`
+	CreatedAt int64             `json:"created_at"`
+}
+
+type threadDocument struct {
+	FileID   int64  `json:"file_id"`
+	Name     string `json:"name"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+}
+
+type threadRead struct {
+	MessageID string `json:"message_id"`
+	ContactID string `json:"contact_id"`
+	ReadAt    int64  `json:"read_at,omitempty"`
+}
+
+type threadMember struct {
+	ID        string `json:"id"`
+	ContactID string `json:"contact_id"`
+	Role      int32  `json:"role"`
+}
+
+type threadChatAction struct {
+	ContactID string `json:"contact_id"`
+	Action    string `json:"action"`
+}
+
+// RegisterUpdatesHandler binds per-instance queues to the im-thread events
+// exchange and to the gateway updates exchange, and forwards every event to
+// the updates service. Every replica needs its own copy of the streams, so
+// the queues are exclusive and removed together with the consumer.
+func RegisterUpdatesHandler(
+	cfg *config.Config,
+	logger *slog.Logger,
+	provider pubsub.Provider,
+	updater service.Updater,
+	members service.ThreadMembers,
+) error {
+	updatesConfig := cfg.Service.Updates
+
+	h := &updatesHandler{logger: logger, updater: updater, members: members}
+
+	for _, source := range []struct {
+		handlerName string
+		exchange    string
+		queue       string
+	}{
+		{updatesHandlerName, updatesConfig.Exchange, "updates"},
+		{gatewayUpdatesHandlerName, updatesConfig.GatewayExchange, "gateway_updates"},
+	} {
+		subscriber, err := provider.GetFactory().BuildSubscriber(source.handlerName, &factory.SubscriberConfig{
+			Exchange: factory.ExchangeConfig{
+				Name:    source.exchange,
+				Type:    amqp.TopicExchangeType,
+				Durable: true,
+			},
+			Queue:             fmt.Sprintf("%s.%s.%s", model.ServiceName, source.queue, uuid.NewString()),
+			RoutingKey:        updatesConfig.RoutingKey,
+			ExclusiveConsumer: true,
+			AutoDelete:        true,
+		})
+		if err != nil {
+			return err
+		}
+		provider.GetRouter().AddConsumerHandler(source.handlerName, source.exchange, subscriber, h.handle)
+	}
+
+	return nil
+}
+
+type updatesHandler struct {
+	logger  *slog.Logger
+	updater service.Updater
+	members service.ThreadMembers
+}
+
+// handle never returns an error: a malformed event cannot become valid on
+// redelivery, so it is logged and acked.
+func (h *updatesHandler) handle(msg *message.Message) error {
+	update, err := decodeUpdate(msg)
+	if err != nil {
+		h.logger.Warn("drop thread event", "message_uuid", msg.UUID, "err", err)
+		return nil
+	}
+	if update.ExpiresAt != 0 && update.ExpiresAt < time.Now().UnixMilli() {
+		return nil
+	}
+
+	h.resolveRecipients(msg.Context(), update)
+	h.updater.Dispatch(update)
+
+	return nil
+}
+
+// resolveRecipients addresses the update to the current members of its
+// thread: the recipients listed by the event are only as fresh as the
+// publisher's view of the thread, and stand only if the members cannot be
+// read. A removed member still learns of its removal, and members are not
+// told of their own chat actions.
+func (h *updatesHandler) resolveRecipients(ctx context.Context, update *dto.Update) {
+	if update.ThreadID == "" {
+		return
+	}
+	if update.Type == dto.UpdateMemberAdded || update.Type == dto.UpdateMemberRemoved {
+		h.members.Forget(update.DomainID, update.ThreadID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, membersLookupTimeout)
+	defer cancel()
+
+	recipients := update.Recipients
+	if members, err := h.members.Members(ctx, update.DomainID, update.ThreadID); err != nil {
+		h.logger.Warn("resolving thread members failed, using the event recipients",
+			slog.String("thread_id", update.ThreadID), slog.String("error", err.Error()))
+	} else {
+		recipients = members
+	}
+
+	recipients = slices.Clone(recipients)
+	switch {
+	case update.Member != nil && update.Type == dto.UpdateMemberRemoved:
+		if !slices.Contains(recipients, update.Member.ContactID) {
+			recipients = append(recipients, update.Member.ContactID)
+		}
+	case update.ChatAction != nil:
+		recipients = slices.DeleteFunc(recipients, func(contactID string) bool {
+			return contactID == update.ChatAction.ContactID
+		})
+	}
+	update.Recipients = recipients
+}
+
+// decodeUpdate parses an im-thread event message into an update.
+func decodeUpdate(msg *message.Message) (*dto.Update, error) {
+	var event threadEvent
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return nil, fmt.Errorf("malformed thread event: %w", err)
+	}
+
+	update, err := toUpdate(&event)
+	if err != nil {
+		return nil, fmt.Errorf("thread event %q: %w", event.Type, err)
+	}
+	if update.ID == "" {
+		update.ID = msg.UUID
+	}
+
+	return update, nil
+}
+
+func toUpdate(event *threadEvent) (*dto.Update, error) {
+	update := &dto.Update{
+		ID:         event.ID,
+		Type:       event.Type,
+		DomainID:   event.DomainID,
+		ThreadID:   event.ThreadID,
+		Date:       event.Date,
+		ExpiresAt:  event.ExpiresAt,
+		Recipients: event.Recipients,
+	}
+
+	switch event.Type {
+	case dto.UpdateNewMessage:
+		if event.Message == nil {
+			return nil, fmt.Errorf("missing message payload")
+		}
+		update.Message = &dto.UpdateMessage{
+			ID:        event.Message.ID,
+			SenderID:  event.Message.SenderID,
+			Type:      event.Message.Type,
+			Body:      event.Message.Body,
+			Metadata:  event.Message.Metadata,
+			CreatedAt: event.Message.CreatedAt,
+		}
+		for _, doc := range event.Message.Documents {
+			update.Message.Documents = append(update.Message.Documents, &dto.UpdateDocument{
+				FileID:   doc.FileID,
+				Name:     doc.Name,
+				MimeType: doc.MimeType,
+				Size:     doc.Size,
+			})
+		}
+	case dto.UpdateReadMessage:
+		if event.Read == nil {
+			return nil, fmt.Errorf("missing read payload")
+		}
+		update.Read = &dto.UpdateRead{
+			MessageID: event.Read.MessageID,
+			ContactID: event.Read.ContactID,
+			ReadAt:    event.Read.ReadAt,
+		}
+	case dto.UpdateMemberAdded, dto.UpdateMemberRemoved:
+		if event.Member == nil {
+			return nil, fmt.Errorf("missing member payload")
+		}
+		update.Member = &dto.UpdateMember{
+			ID:        event.Member.ID,
+			ContactID: event.Member.ContactID,
+			Role:      event.Member.Role,
+		}
+	case dto.UpdateVariablesChanged:
+		update.Variables = event.Variables
+	case dto.UpdateChatAction:
+		if event.ChatAction == nil {
+			return nil, fmt.Errorf("missing chat action payload")
+		}
+		update.ChatAction = &dto.UpdateAction{
+			ContactID: event.ChatAction.ContactID,
+			Action:    dto.ChatAction(event.ChatAction.Action),
+		}
+	default:
+		return nil, fmt.Errorf("unknown update type %q", event.Type)
+	}
+
+	return update, nil
+}
@@ -0,0 +1,81 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// fakeMembers holds the members of every thread, or fails with err.
+type fakeMembers struct {
+	members   map[string][]string
+	err       error
+	forgotten []string
+}
+
+func (m *fakeMembers) Members(_ context.Context, _ int64, threadID string) ([]string, error) {
+	return m.members[threadID], m.err
+}
+
+func (m *fakeMembers) Forget(_ int64, threadID string) {
+	m.forgotten = append(m.forgotten, threadID)
+}
+
+func TestResolveRecipients(t *testing.T) {
+	members := &fakeMembers{members: map[string][]string{"t1": {"a", "b", "c"}}}
+	h := &updatesHandler{logger: slog.New(slog.DiscardHandler), members: members}
+
+	tests := []struct {
+		name   string
+		update *dto.Update
+		want   []string
+	}{
+		{
+			name:   "stale recipients",
+			update: &dto.Update{Type: dto.UpdateNewMessage, ThreadID: "t1", Recipients: []string{"a", "x"}},
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name: "removed member",
+			update: &dto.Update{Type: dto.UpdateMemberRemoved, ThreadID: "t1",
+				Member: &dto.UpdateMember{ContactID: "d"}},
+			want: []string{"a", "b", "c", "d"},
+		},
+		{
+			name: "chat action",
+			update: &dto.Update{Type: dto.UpdateChatAction, ThreadID: "t1", Recipients: []string{"b", "c"},
+				ChatAction: &dto.UpdateAction{ContactID: "a"}},
+			want: []string{"b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h.resolveRecipients(t.Context(), tt.update)
+			got := slices.Sorted(slices.Values(tt.update.Recipients))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("recipients = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if !slices.Equal(members.forgotten, []string{"t1"}) {
+		t.Errorf("forgotten threads = %v, want the one whose members changed", members.forgotten)
+	}
+
+	// The event recipients stand when the members cannot be read.
+	members.err = errors.New("im-thread unavailable")
+	update := &dto.Update{Type: dto.UpdateNewMessage, ThreadID: "t1", Recipients: []string{"a"}}
+	h.resolveRecipients(t.Context(), update)
+	if !slices.Equal(update.Recipients, []string{"a"}) {
+		t.Errorf("recipients after a failed lookup = %v", update.Recipients)
+	}
+	update = &dto.Update{Type: dto.UpdateChatAction, ThreadID: "t1", Recipients: []string{"a", "b"},
+		ChatAction: &dto.UpdateAction{ContactID: "a"}}
+	h.resolveRecipients(t.Context(), update)
+	if !slices.Equal(update.Recipients, []string{"b"}) {
+		t.Errorf("chat action recipients after a failed lookup = %v", update.Recipients)
+	}
+}
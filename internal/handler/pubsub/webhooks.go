@@ -0,0 +1,68 @@
+package pubsub
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/infra/pubsub"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory"
+	"github.com/webitel/im-gateway-service/infra/pubsub/factory/amqp"
+	"github.com/webitel/im-gateway-service/internal/service"
+)
+
+const webhooksHandlerName = "bot_webhooks"
+
+// RegisterWebhooksHandler consumes im-thread events from a durable queue
+// shared by all replicas, so every message is handed to exactly one of them
+// for webhook delivery. A message is acked once delivered, which may take
+// retries: every replica runs one consumer per configured worker, so that
+// slow webhooks do not hold up the others.
+func RegisterWebhooksHandler(
+	cfg *config.Config,
+	logger *slog.Logger,
+	provider pubsub.Provider,
+	webhooker service.Webhooker,
+) error {
+	updatesConfig := cfg.Service.Updates
+
+	subscriber, err := provider.GetFactory().BuildSubscriber(webhooksHandlerName, &factory.SubscriberConfig{
+		Exchange: factory.ExchangeConfig{
+			Name:    updatesConfig.Exchange,
+			Type:    amqp.TopicExchangeType,
+			Durable: true,
+		},
+		Queue:      cfg.Service.Webhooks.Queue,
+		RoutingKey: updatesConfig.RoutingKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	h := &webhooksHandler{logger: logger, webhooker: webhooker}
+	for i := range cfg.Service.Webhooks.Workers {
+		provider.GetRouter().AddConsumerHandler(fmt.Sprintf("%s_%d", webhooksHandlerName, i), updatesConfig.Exchange, subscriber, h.handle)
+	}
+
+	return nil
+}
+
+type webhooksHandler struct {
+	logger    *slog.Logger
+	webhooker service.Webhooker
+}
+
+// handle acks once the deliveries succeeded or were dead-lettered; errors
+// reaching the webhook store or a shutdown in progress nack the message for
+// redelivery.
+func (h *webhooksHandler) handle(msg *message.Message) error {
+	update, err := decodeUpdate(msg)
+	if err != nil {
+		h.logger.Warn("drop thread event", "message_uuid", msg.UUID, "err", err)
+		return nil
+	}
+
+	return h.webhooker.Deliver(msg.Context(), update)
+}
@@ -31,6 +31,8 @@ type Accounter interface {
 type AccountService struct {
 	client        *imauth.Client
 	contactClient *imcontact.Client
+	devices       PushDevices
+	events        EventEmitter
 }
 
 func (s *AccountService) GetAuthorizations(ctx context.Context, request *impb.AccountGetAuthorizationsRequest) (*impb.AccountGetAuthorizationsResponse, error) {
@@ -54,8 +56,8 @@ func (s *AccountService) GetAuthorizations(ctx context.Context, request *impb.Ac
 	return parsed, nil
 }
 
-func NewAccountService(client *imauth.Client, contactClient *imcontact.Client) *AccountService {
-	return &AccountService{client: client, contactClient: contactClient}
+func NewAccountService(client *imauth.Client, contactClient *imcontact.Client, devices PushDevices, events EventEmitter) *AccountService {
+	return &AccountService{client: client, contactClient: contactClient, devices: devices, events: events}
 }
 
 func (s *AccountService) Inspect(ctx context.Context, headers metadata.MD) (*dto.Authorization, error) {
@@ -110,8 +112,21 @@ func (s *AccountService) Logout(ctx context.Context, headers metadata.MD) error
 	}
 
 	outCtx := metadata.NewOutgoingContext(ctx, headers)
+	// The session can no longer be inspected once it is logged out.
+	session, err := s.client.Inspect(outCtx)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Logout(outCtx); err != nil {
+		return err
+	}
+	if err := s.forgetDevice(ctx, session.Id); err != nil {
+		return err
+	}
+
+	s.emit(ctx, dto.EventLoggedOut)
 
-	return s.client.Logout(outCtx)
+	return nil
 }
 
 func (s *AccountService) RegisterDevice(ctx context.Context, headers metadata.MD, request *dto.RegisterDeviceRequest) error {
@@ -119,7 +134,32 @@ func (s *AccountService) RegisterDevice(ctx context.Context, headers metadata.MD
 		return errors.New("headers required for register device")
 	}
 	outCtx := metadata.NewOutgoingContext(ctx, headers)
-	return s.client.RegisterDevice(outCtx, request)
+	session, err := s.client.Inspect(outCtx)
+	if err != nil {
+		return err
+	}
+	if err := s.client.RegisterDevice(outCtx, request); err != nil {
+		return err
+	}
+
+	device, err := toPushDevice(session.Id, request.Push)
+	if err != nil {
+		return err
+	}
+	identity, ok := stdauth.GetIdentityFromContext(ctx)
+	if !ok {
+		return stdauth.IdentityNotFoundErr
+	}
+	if err := s.devices.Register(ctx, identity.GetDomainID(), identity.GetContactID(), device); err != nil {
+		return errors.Internal("record push device",
+			errors.WithID("service.account.register_device"),
+			errors.WithCause(err),
+		)
+	}
+
+	s.emit(ctx, dto.EventDeviceRegistered)
+
+	return nil
 }
 
 func (s *AccountService) UnregisterDevice(ctx context.Context, headers metadata.MD, request *dto.UnregisterDeviceRequest) error {
@@ -127,5 +167,66 @@ func (s *AccountService) UnregisterDevice(ctx context.Context, headers metadata.
 		return errors.New("headers required for unregister device")
 	}
 	outCtx := metadata.NewOutgoingContext(ctx, headers)
-	return s.client.UnregisterDevice(outCtx, request)
+	session, err := s.client.Inspect(outCtx)
+	if err != nil {
+		return err
+	}
+	if err := s.client.UnregisterDevice(outCtx, request); err != nil {
+		return err
+	}
+	if err := s.forgetDevice(ctx, session.Id); err != nil {
+		return err
+	}
+
+	s.emit(ctx, dto.EventDeviceUnregistered)
+
+	return nil
+}
+
+// forgetDevice stops push notifications to the device of the session.
+func (s *AccountService) forgetDevice(ctx context.Context, sessionID string) error {
+	identity, ok := stdauth.GetIdentityFromContext(ctx)
+	if !ok {
+		return stdauth.IdentityNotFoundErr
+	}
+	if err := s.devices.Unregister(ctx, identity.GetDomainID(), identity.GetContactID(), sessionID); err != nil {
+		return errors.Internal("forget push device",
+			errors.WithID("service.account.forget_device"),
+			errors.WithCause(err),
+		)
+	}
+
+	return nil
+}
+
+// toPushDevice converts the subscription a session registered.
+func toPushDevice(sessionID string, push *dto.PUSHSubscription) (*dto.PushDevice, error) {
+	if push == nil || push.Token == "" {
+		return nil, errors.InvalidArgument("push token is required",
+			errors.WithID("service.account.push_token"),
+		)
+	}
+
+	device := &dto.PushDevice{
+		SessionID: sessionID,
+		Provider:  dto.PushProvider(push.Provider),
+		Token:     push.Token,
+	}
+	device.P256dh, _ = push.Parameters["p256dh"].([]byte)
+	device.Auth, _ = push.Parameters["auth"].([]byte)
+	device.Secret, _ = push.Parameters["secret"].([]byte)
+
+	return device, nil
+}
+
+// emit announces a change of the account of the identity found in ctx.
+func (s *AccountService) emit(ctx context.Context, eventType dto.EventType) {
+	identity, ok := stdauth.GetIdentityFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	s.events.Emit(ctx, newDomainEvent(eventType, identity, identity.GetContactID(),
+		"contact_id", identity.GetContactID(),
+	))
 }
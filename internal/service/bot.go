@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 
 	"github.com/webitel/webitel-go-kit/pkg/errors"
@@ -11,6 +13,7 @@ import (
 	contactv1 "github.com/webitel/im-gateway-service/gen/go/contact/v1"
 	"github.com/webitel/im-gateway-service/infra/auth"
 	imcontact "github.com/webitel/im-gateway-service/infra/client/im-contact"
+	"github.com/webitel/im-gateway-service/internal/model"
 	"github.com/webitel/im-gateway-service/internal/service/dto"
 )
 
@@ -21,6 +24,9 @@ const (
 	BotIssuer      = "bot"
 )
 
+var ErrNotBotOwner = errors.Forbidden("only the bot and the contact that created it may manage it",
+	errors.WithID("service.bot.not_owner"))
+
 type Botter interface {
 	CreateBot(ctx context.Context, in *dto.CreateBotRequest) (*dto.Bot, error)
 	UpdateBot(ctx context.Context, in *dto.UpdateBotRequest) (*dto.Bot, error)
@@ -30,12 +36,16 @@ type Botter interface {
 type BotService struct {
 	logger        *slog.Logger
 	contactClient *imcontact.Client
+	redis         *redis.Client
+	events        EventEmitter
 }
 
-func NewBotService(logger *slog.Logger, contactClient *imcontact.Client) *BotService {
+func NewBotService(logger *slog.Logger, contactClient *imcontact.Client, redisClient *redis.Client, events EventEmitter) *BotService {
 	return &BotService{
 		logger:        logger,
 		contactClient: contactClient,
+		redis:         redisClient,
+		events:        events,
 	}
 }
 
@@ -59,6 +69,19 @@ func (m *BotService) CreateBot(ctx context.Context, in *dto.CreateBotRequest) (*
 		return nil, err
 	}
 
+	// The contact service keeps no creator: without it the webhook of the
+	// bot could only be managed by the bot itself.
+	if err := m.redis.Set(ctx, botOwnerKey(identity.GetDomainID(), resp.GetId()), identity.GetContactID(), 0).Err(); err != nil {
+		m.logger.Error("failed to record the bot owner",
+			slog.String("bot_id", resp.GetId()),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	m.events.Emit(ctx, newDomainEvent(dto.EventBotCreated, identity, resp.GetId(),
+		"bot_id", resp.GetId(),
+	))
+
 	return m.toBot(resp), nil
 }
 
@@ -82,6 +105,10 @@ func (m *BotService) UpdateBot(ctx context.Context, in *dto.UpdateBotRequest) (*
 		return nil, err
 	}
 
+	m.events.Emit(ctx, newDomainEvent(dto.EventBotUpdated, identity, resp.GetId(),
+		"bot_id", resp.GetId(),
+	))
+
 	return m.toBot(resp), nil
 }
 
@@ -91,13 +118,42 @@ func (m *BotService) DeleteBot(ctx context.Context, in *dto.DeleteBotRequest) (*
 		return nil, auth.IdentityNotFoundErr
 	}
 
+	bot, err := findBot(ctx, m.contactClient, identity.GetDomainID(), in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.contactClient.DeleteContact(ctx, &contactv1.DeleteContactRequest{
+		Id: bot.GetId(),
+		DomainId: bot.GetDomainId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.redis.Del(ctx, botOwnerKey(identity.GetDomainID(), bot.GetId())).Err(); err != nil {
+		m.logger.Warn("failed to forget the bot owner",
+			slog.String("bot_id", bot.GetId()),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	m.events.Emit(ctx, newDomainEvent(dto.EventBotDeleted, identity, resp.GetId(),
+		"bot_id", resp.GetId(),
+	))
+
+	return m.toBot(resp), nil
+}
+
+// findBot looks up the bot contact with the given id in the domain.
+func findBot(ctx context.Context, contactClient *imcontact.Client, domainID int64, id string) (*contactv1.Contact, error) {
 	onlyBots := true
-	contacts, err := m.contactClient.SearchContact(ctx, &contactv1.SearchContactRequest{
+	contacts, err := contactClient.SearchContact(ctx, &contactv1.SearchContactRequest{
 		Size:     2,
 		IssId:    []string{BotIssuer},
 		Type:     []string{BotContactType},
-		Ids:      []string{in.ID},
-		DomainId: int32(identity.GetDomainID()),
+		Ids:      []string{id},
+		DomainId: int32(domainID),
 		OnlyBots: &onlyBots,
 	})
 	if err != nil {
@@ -111,17 +167,31 @@ func (m *BotService) DeleteBot(ctx context.Context, in *dto.DeleteBotRequest) (*
 		return nil, errors.Internal("too many bots found")
 	}
 
-	bot := contacts.GetContacts()[0]
+	return contacts.GetContacts()[0], nil
+}
 
-	resp, err := m.contactClient.DeleteContact(ctx, &contactv1.DeleteContactRequest{
-		Id: bot.GetId(),
-		DomainId: bot.GetDomainId(),
-	})
-	if err != nil {
-		return nil, err
+// authorizeBot checks that identity may manage the webhook of the bot with
+// the given id: only the bot itself and the contact that created it may. The
+// authorizer knows no administrators, so nobody else is let through.
+func authorizeBot(ctx context.Context, redisClient *redis.Client, identity auth.Identifier, botID string) error {
+	if identity.GetContactID() == botID {
+		return nil
 	}
 
-	return m.toBot(resp), nil
+	owner, err := redisClient.Get(ctx, botOwnerKey(identity.GetDomainID(), botID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	if owner == "" || owner != identity.GetContactID() {
+		return ErrNotBotOwner
+	}
+
+	return nil
+}
+
+// botOwnerKey holds the id of the contact that created the bot.
+func botOwnerKey(domainID int64, botID string) string {
+	return fmt.Sprintf("%s:bot:%d:%s:owner", model.ServiceName, domainID, botID)
 }
 
 // --- Internal Mappers ---
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+	threadv1 "github.com/webitel/im-gateway-service/gen/go/thread/v1"
+	"github.com/webitel/im-gateway-service/infra/auth"
+	"github.com/webitel/im-gateway-service/internal/model"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+var (
+	ErrUnknownChatAction   = errors.InvalidArgument("unknown chat action", errors.WithID("service.chat_action.unknown"))
+	ErrNotThreadMember     = errors.Forbidden("not a member of the thread", errors.WithID("service.thread.not_member"))
+	ErrChatActionThrottled = errors.New("chat actions are sent too often",
+		errors.WithCode(codes.ResourceExhausted),
+		errors.WithID("service.chat_action.throttled"),
+	)
+)
+
+var _ ChatActioner = (*ChatActionService)(nil)
+
+// ChatActioner broadcasts ephemeral "typing…"-like indicators to thread members.
+type ChatActioner interface {
+	SendChatAction(ctx context.Context, in *dto.SendChatActionRequest) error
+}
+
+type ChatActionService struct {
+	logger       *slog.Logger
+	threadClient threadGetter
+	redis        *redis.Client
+	publisher    UpdatePublisher
+	cfg          config.ChatActionsConfig
+}
+
+func NewChatActionService(
+	logger *slog.Logger,
+	threadClient threadGetter,
+	redisClient *redis.Client,
+	publisher UpdatePublisher,
+	cfg config.ChatActionsConfig,
+) *ChatActionService {
+	return &ChatActionService{
+		logger:       logger,
+		threadClient: threadClient,
+		redis:        redisClient,
+		publisher:    publisher,
+		cfg:          cfg,
+	}
+}
+
+// SendChatAction implements ChatActioner. The action is never persisted: it
+// reaches the members connected right now and expires after the configured
+// TTL. A member may send one action per thread every configured interval;
+// clients are expected to repeat it while the action lasts.
+func (s *ChatActionService) SendChatAction(ctx context.Context, in *dto.SendChatActionRequest) error {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return auth.IdentityNotFoundErr
+	}
+
+	if !in.Action.Valid() {
+		return ErrUnknownChatAction
+	}
+
+	thread, err := s.threadClient.Get(ctx, &threadv1.GetThreadRequest{
+		Id:       in.ThreadID,
+		DomainId: int32(identity.GetDomainID()),
+	})
+	if err != nil {
+		return err
+	}
+
+	var recipients []string
+	isMember := false
+	for _, member := range thread.GetMembers() {
+		if member.GetContactId() == identity.GetContactID() {
+			isMember = true
+			continue
+		}
+		recipients = append(recipients, member.GetContactId())
+	}
+	if !isMember {
+		return ErrNotThreadMember
+	}
+
+	// Only members are throttled, so that others cannot hold the slots of
+	// the members.
+	if s.cfg.Interval > 0 {
+		key := fmt.Sprintf("%s:chat_action:%d:%s:%s", model.ServiceName, identity.GetDomainID(), in.ThreadID, identity.GetContactID())
+		allowed, err := s.redis.SetNX(ctx, key, 1, s.cfg.Interval).Result()
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrChatActionThrottled
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	return s.publisher.Publish(ctx, &dto.Update{
+		ID:         uuid.NewString(),
+		Type:       dto.UpdateChatAction,
+		ThreadID:   in.ThreadID,
+		Date:       now.UnixMilli(),
+		ExpiresAt:  now.Add(s.cfg.TTL).UnixMilli(),
+		DomainID:   identity.GetDomainID(),
+		Recipients: recipients,
+		ChatAction: &dto.UpdateAction{
+			ContactID: identity.GetContactID(),
+			Action:    in.Action,
+		},
+	})
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+	threadv1 "github.com/webitel/im-gateway-service/gen/go/thread/v1"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// fakeThreads holds a single thread with the given members.
+type fakeThreads struct {
+	members []string
+}
+
+func (f *fakeThreads) Get(_ context.Context, req *threadv1.GetThreadRequest) (*threadv1.Thread, error) {
+	thread := &threadv1.Thread{Id: req.GetId()}
+	for _, contactID := range f.members {
+		thread.Members = append(thread.Members, &threadv1.ThreadMember{ContactId: contactID})
+	}
+
+	return thread, nil
+}
+
+type recordingPublisher struct {
+	published []*dto.Update
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, u *dto.Update) error {
+	p.published = append(p.published, u)
+
+	return nil
+}
+
+func TestChatActionService_SendChatAction(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	publisher := &recordingPublisher{}
+	s := NewChatActionService(slog.New(slog.DiscardHandler), &fakeThreads{members: []string{"alice", "bob"}}, client, publisher,
+		config.ChatActionsConfig{TTL: 5 * time.Second, Interval: time.Minute})
+	req := &dto.SendChatActionRequest{ThreadID: "t1", Action: dto.ChatActionTyping}
+
+	// Outsiders are refused before they are throttled, so that they cannot
+	// take the slot of a member.
+	if err := s.SendChatAction(identityContext(t, 1, "mallory"), req); !errors.Is(err, ErrNotThreadMember) {
+		t.Errorf("action of an outsider = %v, want ErrNotThreadMember", err)
+	}
+	if n, _ := client.DBSize(t.Context()).Result(); n != 0 {
+		t.Errorf("the refused action left %d throttling keys", n)
+	}
+
+	if err := s.SendChatAction(identityContext(t, 1, "alice"), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher.published) != 1 || len(publisher.published[0].Recipients) != 1 || publisher.published[0].Recipients[0] != "bob" {
+		t.Errorf("published %+v, want the action sent to bob", publisher.published)
+	}
+	if err := s.SendChatAction(identityContext(t, 1, "alice"), req); !errors.Is(err, ErrChatActionThrottled) {
+		t.Errorf("repeated action = %v, want ErrChatActionThrottled", err)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"strings"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+var (
+	ErrChecksumMismatch = errors.New("upload: checksum does not match the content")
+	// ErrStorageChecksumMismatch means storage holds other bytes than the
+	// gateway sent it. The stored file is deleted.
+	ErrStorageChecksumMismatch = errors.New("upload: stored file does not match the content")
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// uploadDigest hashes the content of an upload as it is sent to storage.
+// Both sums are kept, since a client may declare its checksum with any write.
+type uploadDigest struct {
+	sha256 hash.Hash
+	crc32c hash.Hash32
+}
+
+func newUploadDigest() *uploadDigest {
+	return &uploadDigest{sha256: sha256.New(), crc32c: crc32.New(crc32cTable)}
+}
+
+func (d *uploadDigest) Write(p []byte) (int, error) {
+	d.sha256.Write(p)
+	d.crc32c.Write(p)
+
+	return len(p), nil
+}
+
+// verify compares the content with the checksum the client declared, if
+// any.
+func (d *uploadDigest) verify(expected *dto.Checksum) error {
+	if expected == nil {
+		return nil
+	}
+
+	var sum []byte
+	switch expected.Algorithm {
+	case dto.ChecksumSHA256:
+		sum = d.sha256.Sum(nil)
+	case dto.ChecksumCRC32C:
+		sum = d.crc32c.Sum(nil)
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrChecksumMismatch, expected.Algorithm)
+	}
+
+	if !bytes.Equal(sum, expected.Sum) {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, expected.Algorithm)
+	}
+
+	return nil
+}
+
+// verifyStored compares the content with the sha256 storage computed of the
+// file, hex or base64 encoded. Storage versions that do not report it are
+// trusted.
+func (d *uploadDigest) verifyStored(stored string) error {
+	if stored == "" {
+		return nil
+	}
+
+	sum := d.sha256.Sum(nil)
+	if strings.EqualFold(stored, hex.EncodeToString(sum)) || stored == base64.StdEncoding.EncodeToString(sum) {
+		return nil
+	}
+
+	return ErrStorageChecksumMismatch
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+func TestUploadDigest(t *testing.T) {
+	digest := newUploadDigest()
+	for _, chunk := range []string{"hello ", "there"} {
+		_, _ = digest.Write([]byte(chunk))
+	}
+
+	// sha256("hello there")
+	sha, _ := hex.DecodeString("12998c017066eb0d2a70b94e6ed3192985855ce390f321bbdb832022888bd251")
+	crc := binary.BigEndian.AppendUint32(nil, crc32.Checksum([]byte("hello there"), crc32.MakeTable(crc32.Castagnoli)))
+
+	for _, expected := range []*dto.Checksum{
+		nil,
+		{Algorithm: dto.ChecksumSHA256, Sum: sha},
+		{Algorithm: dto.ChecksumCRC32C, Sum: crc},
+	} {
+		if err := digest.verify(expected); err != nil {
+			t.Errorf("verify %v = %v", expected, err)
+		}
+	}
+	if err := digest.verify(&dto.Checksum{Algorithm: dto.ChecksumCRC32C, Sum: []byte{0, 0, 0, 0}}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("verify of another checksum = %v, want ErrChecksumMismatch", err)
+	}
+
+	for _, stored := range []string{"", hex.EncodeToString(sha), strings.ToUpper(hex.EncodeToString(sha)), base64.StdEncoding.EncodeToString(sha)} {
+		if err := digest.verifyStored(stored); err != nil {
+			t.Errorf("verifyStored %q = %v", stored, err)
+		}
+	}
+	if err := digest.verifyStored(strings.Repeat("0", 64)); !errors.Is(err, ErrStorageChecksumMismatch) {
+		t.Errorf("verifyStored of another file = %v, want ErrStorageChecksumMismatch", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	contactv1 "github.com/webitel/im-gateway-service/gen/go/contact/v1"
 	"github.com/webitel/im-gateway-service/infra/auth"
 	imcontact "github.com/webitel/im-gateway-service/infra/client/im-contact"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
 )
 
 // Interface guard
@@ -21,12 +22,14 @@ type Contacter interface {
 type ContactService struct {
 	logger        *slog.Logger
 	contactClient *imcontact.Client
+	events        EventEmitter
 }
 
-func NewContactService(logger *slog.Logger, contactClient *imcontact.Client) *ContactService {
+func NewContactService(logger *slog.Logger, contactClient *imcontact.Client, events EventEmitter) *ContactService {
 	return &ContactService{
 		logger:        logger,
 		contactClient: contactClient,
+		events:        events,
 	}
 }
 
@@ -45,7 +48,17 @@ func (m *ContactService) CreateContact(ctx context.Context, in *contactv1.Create
 		return nil, auth.IdentityNotFoundErr
 	}
 	in.DomainId = int32(identity.GetDomainID())
-	return m.contactClient.CreateContact(ctx, in)
+
+	contact, err := m.contactClient.CreateContact(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	m.events.Emit(ctx, newDomainEvent(dto.EventContactCreated, identity, contact.GetId(),
+		"contact_id", contact.GetId(),
+	))
+
+	return contact, nil
 }
 
 func (m *ContactService) Locate(ctx context.Context, in *contactv1.LocateContactRequest) (*contactv1.LocateContactResponse, error) {
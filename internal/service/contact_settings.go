@@ -9,6 +9,7 @@ import (
 	gtwperm "github.com/webitel/im-gateway-service/gen/go/gateway/v1"
 	"github.com/webitel/im-gateway-service/infra/auth"
 	imcontact "github.com/webitel/im-gateway-service/infra/client/im-contact"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
 	"github.com/webitel/webitel-go-kit/pkg/errors"
 )
 
@@ -19,12 +20,14 @@ type ContactSettingsManager interface {
 type ContactSettingsService struct {
 	logger        *slog.Logger
 	contactClient *imcontact.ContactSettingsClient
+	events        EventEmitter
 }
 
-func NewContactSettingsService(logger *slog.Logger, contact *imcontact.ContactSettingsClient) *ContactSettingsService {
+func NewContactSettingsService(logger *slog.Logger, contact *imcontact.ContactSettingsClient, events EventEmitter) *ContactSettingsService {
 	return &ContactSettingsService{
 		logger:        logger,
 		contactClient: contact,
+		events:        events,
 	}
 }
 
@@ -86,5 +89,9 @@ func (s *ContactSettingsService) Update(ctx context.Context, req *gtwperm.Update
 	if err != nil {
 		return nil, err
 	}
+
+	s.events.Emit(ctx, newDomainEvent(dto.EventSettingsUpdated, identity, resp.GetContactId(),
+		"contact_id", resp.GetContactId(),
+	))
 	return s.convertToContactSettings(resp)
 }
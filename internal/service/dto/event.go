@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+// EventType names a domain event. Consumers rely on these values, so an
+// existing type must never change its meaning: add a new one instead.
+type EventType string
+
+const (
+	EventMessageSent        EventType = "message.sent"
+	EventMessageRead        EventType = "message.read"
+	EventThreadCreated      EventType = "thread.created"
+	EventMemberAdded        EventType = "thread.member.added"
+	EventMemberRemoved      EventType = "thread.member.removed"
+	EventMemberTransferred  EventType = "thread.member.transferred"
+	EventVariablesSet       EventType = "thread.variables.set"
+	EventVariablesFlushed   EventType = "thread.variables.flushed"
+	EventPermissionsUpdated EventType = "thread.permissions.updated"
+	EventContactCreated     EventType = "contact.created"
+	EventSettingsUpdated    EventType = "contact.settings.updated"
+	EventBotCreated         EventType = "bot.created"
+	EventBotUpdated         EventType = "bot.updated"
+	EventBotDeleted         EventType = "bot.deleted"
+	EventFileUploaded       EventType = "file.uploaded"
+
+	EventInteractiveCallback EventType = "message.interactive_callback.sent"
+	EventViaCreated          EventType = "contact.via.created"
+	EventViaUpdated          EventType = "contact.via.updated"
+	EventDeviceRegistered    EventType = "account.device.registered"
+	EventDeviceUnregistered  EventType = "account.device.unregistered"
+	EventLoggedOut           EventType = "account.logged_out"
+)
+
+// DomainEvent records a successful change made through the gateway.
+type DomainEvent struct {
+	Type     EventType
+	DomainID int64
+	// ActorID is the contact that made the change.
+	ActorID string
+	// Subject is the ID of the object the event is about.
+	Subject string
+	// Objects holds the IDs of every object involved, keyed by kind,
+	// e.g. "thread_id" or "message_id".
+	Objects map[string]string
+	Time    time.Time
+}
@@ -3,6 +3,7 @@ package dto
 import (
 	"io"
 	"net/url"
+	"time"
 )
 
 // MediaDownloadRequest is the service-layer request to download a file.
@@ -42,6 +43,59 @@ type CreateUploadSessionRequest struct {
 	Name string `json:"name"`
 }
 
+// CreateUploadRequest starts an upload of a file of Length bytes. Length is
+// zero when the size is not known up front.
+type CreateUploadRequest struct {
+	Name   string
+	Length int64
+}
+
+// UploadInfo describes an upload session in progress.
+type UploadInfo struct {
+	ID        string
+	Offset    int64
+	Length    int64
+	ExpiresAt time.Time
+}
+
+// WriteUploadRequest appends Body to an upload. Offset must be the number of
+// bytes uploaded so far. Length declares the size of the file if it was not
+// known at creation, zero if still unknown. Final marks Body as the end of the
+// file, for uploads of unknown length. Checksum, if set, is the checksum of
+// the whole file the client expects to be stored; the last one declared
+// applies.
+type WriteUploadRequest struct {
+	UploadID string
+	Offset   int64
+	Length   int64
+	Body     io.Reader
+	Final    bool
+	Checksum *Checksum
+}
+
+// ChecksumAlgorithm is a hash a client may declare the checksum of an upload
+// with.
+type ChecksumAlgorithm string
+
+const (
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+	ChecksumCRC32C ChecksumAlgorithm = "crc32c"
+)
+
+// Checksum is the digest of a file: 32 bytes for sha256, 4 big-endian bytes
+// for crc32c.
+type Checksum struct {
+	Algorithm ChecksumAlgorithm
+	Sum       []byte
+}
+
+// WriteUploadResult is the upload offset after a write, and the stored file
+// once the upload is complete.
+type WriteUploadResult struct {
+	Offset int64
+	File   *FileMetadata
+}
+
 // CreateUploadSessionResponse is returned after a session is successfully created.
 type CreateUploadSessionResponse struct {
 	UploadID string `json:"uploadId,omitempty"`
@@ -54,3 +108,86 @@ type SuccessfullyUploadResponse struct {
 	Size     int64  `json:"size,omitempty"`
 	Hash     string `json:"hash,omitempty"`
 }
+
+// ThumbnailFit is how an image is fitted into the requested box.
+type ThumbnailFit string
+
+const (
+	// ThumbnailFitContain scales the image to fit inside the box.
+	ThumbnailFitContain ThumbnailFit = "contain"
+	// ThumbnailFitCover scales the image to cover the box and crops the
+	// overflow around the center.
+	ThumbnailFitCover ThumbnailFit = "cover"
+	// ThumbnailFitFill stretches the image to the box.
+	ThumbnailFitFill ThumbnailFit = "fill"
+)
+
+// ThumbnailRequest asks for a preview of an image file at most Width by
+// Height pixels. A zero dimension follows from the other one and the aspect
+// ratio of the image.
+type ThumbnailRequest struct {
+	FileID int64
+	Width  int
+	Height int
+	Fit    ThumbnailFit
+}
+
+// Thumbnail is an encoded preview of an image file.
+type Thumbnail struct {
+	MimeType string
+	Data     []byte
+	Width    int
+	Height   int
+	// SourceHash is the SHA-256 of the original file.
+	SourceHash string
+}
+
+// MediaDisposition is how a browser presents a file opened through a link.
+type MediaDisposition string
+
+const (
+	MediaDispositionInline     MediaDisposition = "inline"
+	MediaDispositionAttachment MediaDisposition = "attachment"
+)
+
+// SignLinkRequest asks for a link to a file that works without credentials
+// until it expires after TTL (the configured default if zero).
+type SignLinkRequest struct {
+	FileID      int64
+	TTL         time.Duration
+	Disposition MediaDisposition
+}
+
+// SignedLink is a signed, expiring grant to download a file of a domain.
+type SignedLink struct {
+	FileID      int64
+	DomainID    int64
+	Disposition MediaDisposition
+	ExpiresAt   time.Time
+	Token       string
+}
+
+// SignLinkBody is the optional JSON body of POST /media/{id}/link.
+type SignLinkBody struct {
+	TTLSeconds  int64            `json:"ttlSeconds,omitempty"`
+	Disposition MediaDisposition `json:"disposition,omitempty"`
+}
+
+// SignedLinkResponse is returned after a link is signed. URL is absolute, on
+// the public base URL of the gateway.
+type SignedLinkResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// MediaUsage is what a domain has uploaded, against its limits; zero limits
+// are unlimited.
+type MediaUsage struct {
+	DomainID      int64 `json:"domainId"`
+	Bytes         int64 `json:"bytes"`
+	Files         int64 `json:"files"`
+	DailyBytes    int64 `json:"dailyBytes"`
+	MaxBytes      int64 `json:"maxBytes,omitempty"`
+	MaxFiles      int64 `json:"maxFiles,omitempty"`
+	MaxDailyBytes int64 `json:"maxDailyBytes,omitempty"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+// PushProvider is the push service a device token belongs to.
+type PushProvider string
+
+const (
+	PushProviderFCM  PushProvider = "fcm"
+	PushProviderAPNs PushProvider = "apns"
+	PushProviderWeb  PushProvider = "web"
+)
+
+// PushDevice is a push subscription registered by one session of a contact.
+type PushDevice struct {
+	// SessionID is the authorization the device registered with; a contact
+	// has at most one device per session.
+	SessionID string       `json:"sessionId"`
+	Provider  PushProvider `json:"provider"`
+	// Token is the FCM registration token, the APNs device token or the
+	// Web Push endpoint URL.
+	Token string `json:"token"`
+	// P256dh and Auth are the Web Push encryption keys.
+	P256dh []byte `json:"p256dh,omitempty"`
+	Auth   []byte `json:"auth,omitempty"`
+	// Secret is the optional key FCM and APNs VoIP payloads are encrypted with.
+	Secret []byte `json:"secret,omitempty"`
+}
+
+// PushMessage is a notification ready to be handed to the push provider of
+// Device. Payload is in the provider's format: an FCM HTTP v1 message, an
+// APNs payload or the Web Push message data before encryption.
+type PushMessage struct {
+	Device  *PushDevice
+	Payload []byte
+	// CollapseKey groups notifications the provider may replace by newer ones.
+	CollapseKey string
+}
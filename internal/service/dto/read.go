@@ -4,3 +4,20 @@ type ReadMessageRequest struct {
 	MessageID string `json:"message_id"`
 	ThreadID  string `json:"thread_id"`
 }
+
+// ReadPosition is how far a thread member has read: up to MessageID, at ReadAt.
+// MessageDate is the creation time of that message, which orders positions.
+type ReadPosition struct {
+	ContactID   string `json:"contactId"`
+	MessageID   string `json:"messageId"`
+	MessageDate int64  `json:"messageDate"`
+	ReadAt      int64  `json:"readAt"`
+}
+
+type ListReadPositionsRequest struct {
+	ThreadID string
+}
+
+type ListReadPositionsResponse struct {
+	Items []*ReadPosition `json:"items"`
+}
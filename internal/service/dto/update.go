@@ -0,0 +1,119 @@
+package dto
+
+// UpdateType identifies the kind of realtime event carried by an Update.
+type UpdateType string
+
+const (
+	UpdateNewMessage       UpdateType = "message.new"
+	UpdateReadMessage      UpdateType = "message.read"
+	UpdateMemberAdded      UpdateType = "member.added"
+	UpdateMemberRemoved    UpdateType = "member.removed"
+	UpdateVariablesChanged UpdateType = "variables.changed"
+	UpdateChatAction       UpdateType = "chat.action"
+	// UpdateReset is sent instead of the replayed updates to a subscriber
+	// whose cursor is no longer known: the updates since are lost and the
+	// client has to refetch its threads. Its Cursor resumes the stream.
+	UpdateReset UpdateType = "updates.reset"
+)
+
+// ChatAction is what a member is currently doing in a thread.
+type ChatAction string
+
+const (
+	ChatActionTyping            ChatAction = "typing"
+	ChatActionUploadingDocument ChatAction = "uploading_document"
+	ChatActionRecordingVoice    ChatAction = "recording_voice"
+)
+
+// Valid reports whether a is one of the known chat actions.
+func (a ChatAction) Valid() bool {
+	switch a {
+	case ChatActionTyping, ChatActionUploadingDocument, ChatActionRecordingVoice:
+		return true
+	}
+
+	return false
+}
+
+// Update is a single realtime event about a thread. Exactly one of the typed
+// payload fields is set, according to Type.
+type Update struct {
+	ID       string     `json:"id"`
+	Type     UpdateType `json:"type"`
+	ThreadID string     `json:"threadId"`
+	Date     int64      `json:"date"`
+	// ExpiresAt, when set, marks an ephemeral update: it is dropped once
+	// expired and never replayed to reconnecting clients.
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+
+	// Cursor is the opaque position of the update in this replica's stream,
+	// assigned on dispatch. Clients hand it back to resume a stream.
+	Cursor string `json:"cursor,omitempty"`
+
+	// DomainID and Recipients scope the update: it is delivered only to
+	// subscribers of that domain whose contact ID is listed in Recipients.
+	// The updates consumer adds the current members of the thread to the
+	// recipients named by the event.
+	DomainID   int64    `json:"-"`
+	Recipients []string `json:"-"`
+
+	Message    *UpdateMessage    `json:"message,omitempty"`
+	Read       *UpdateRead       `json:"read,omitempty"`
+	Member     *UpdateMember     `json:"member,omitempty"`
+	Variables  map[string]string `json:"variables,omitempty"`
+	ChatAction *UpdateAction     `json:"chatAction,omitempty"`
+}
+
+// UpdateMessage is the payload of an UpdateNewMessage.
+type UpdateMessage struct {
+	ID        string            `json:"id"`
+	SenderID  string            `json:"senderId"`
+	Type      string            `json:"type,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Documents []*UpdateDocument `json:"documents,omitempty"`
+	CreatedAt int64             `json:"createdAt"`
+}
+
+// UpdateDocument describes a file attached to an UpdateMessage.
+type UpdateDocument struct {
+	FileID   int64  `json:"fileId"`
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+// UpdateRead is the payload of an UpdateReadMessage.
+type UpdateRead struct {
+	MessageID string `json:"messageId"`
+	ContactID string `json:"contactId"`
+	ReadAt    int64  `json:"readAt,omitempty"`
+}
+
+// UpdateMember is the payload of UpdateMemberAdded and UpdateMemberRemoved.
+type UpdateMember struct {
+	ID        string `json:"id"`
+	ContactID string `json:"contactId"`
+	Role      int32  `json:"role,omitempty"`
+}
+
+// UpdateAction is the payload of an UpdateChatAction.
+type UpdateAction struct {
+	ContactID string     `json:"contactId"`
+	Action    ChatAction `json:"action"`
+}
+
+// SendChatActionRequest tells the other members of a thread what the caller
+// is doing.
+type SendChatActionRequest struct {
+	ThreadID string
+	Action   ChatAction
+}
+
+// SubscribeRequest opens a stream of updates for the caller's identity.
+type SubscribeRequest struct {
+	// After is the Cursor of the last update the client has seen. When it is
+	// still held in the replay buffer, the updates dispatched after it are
+	// delivered before any live one; otherwise an UpdateReset is.
+	After string
+}
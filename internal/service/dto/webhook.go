@@ -0,0 +1,42 @@
+package dto
+
+// Webhook is the HTTPS endpoint messages addressed to a bot are posted to.
+type Webhook struct {
+	BotID     string `json:"botId"`
+	URL       string `json:"url"`
+	Secret    string `json:"secret,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// SetWebhookRequest registers or replaces the webhook of a bot.
+type SetWebhookRequest struct {
+	BotID string `json:"-"`
+	URL   string `json:"url"`
+	// Secret signs every delivery. A random one is generated when empty.
+	Secret string `json:"secret,omitempty"`
+}
+
+type DeleteWebhookRequest struct {
+	BotID string
+}
+
+type ListDeliveriesRequest struct {
+	BotID string
+	Size  int64
+}
+
+// Delivery is the dead-letter record of a webhook message that could not be
+// delivered.
+type Delivery struct {
+	ID         string `json:"id"`
+	BotID      string `json:"botId"`
+	UpdateID   string `json:"updateId"`
+	ThreadID   string `json:"threadId"`
+	URL        string `json:"url"`
+	Attempts   int    `json:"attempts"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error"`
+	CreatedAt  int64  `json:"createdAt"`
+	FailedAt   int64  `json:"failedAt"`
+	Payload    string `json:"payload"`
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/webitel/im-gateway-service/infra/auth"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// EventEmitter announces successful changes to downstream consumers.
+// Emit never blocks on the broker and never fails the change itself:
+// delivery is the emitter's business.
+type EventEmitter interface {
+	Emit(ctx context.Context, event *dto.DomainEvent)
+}
+
+// newDomainEvent builds an event about subject made by identity. objects
+// lists pairs of object kind and ID, e.g. "thread_id", id.
+func newDomainEvent(eventType dto.EventType, identity auth.Identifier, subject string, objects ...string) *dto.DomainEvent {
+	event := &dto.DomainEvent{
+		Type:     eventType,
+		DomainID: identity.GetDomainID(),
+		ActorID:  identity.GetContactID(),
+		Subject:  subject,
+		Objects:  make(map[string]string, len(objects)/2),
+		Time:     time.Now().UTC(),
+	}
+	for i := 0; i+1 < len(objects); i += 2 {
+		if objects[i+1] != "" {
+			event.Objects[objects[i]] = objects[i+1]
+		}
+	}
+
+	return event
+}
@@ -3,9 +3,10 @@ package service
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
-	"net/http"
+	"maps"
 	"strconv"
 	"sync"
 	"time"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/webitel/webitel-go-kit/pkg/errors"
 
+	"github.com/webitel/im-gateway-service/config"
 	storagev1 "github.com/webitel/im-gateway-service/gen/go/storage/v1"
 	"github.com/webitel/im-gateway-service/infra/auth"
 	storageclient "github.com/webitel/im-gateway-service/infra/client/storage"
@@ -26,35 +28,91 @@ type Media interface {
 	AppendContent(ctx context.Context, uploadID string, body io.Reader) (*dto.FileMetadata, error)
 	TerminateUploadSession(uploadID string) error
 	GetUploadFileInfo(ctx context.Context, uploadID string) (int64, error)
+
+	// CreateUpload starts an upload that may span several WriteUpload calls.
+	CreateUpload(ctx context.Context, req *dto.CreateUploadRequest) (*dto.UploadInfo, error)
+	GetUpload(ctx context.Context, uploadID string) (*dto.UploadInfo, error)
+	// WriteUpload continues an upload from req.Offset. The upload is complete
+	// once its declared length is reached, or after a final write.
+	WriteUpload(ctx context.Context, req *dto.WriteUploadRequest) (*dto.WriteUploadResult, error)
+	// Upload stores a whole file read from body in one call.
+	Upload(ctx context.Context, name string, body io.Reader) (*dto.FileMetadata, error)
+	// DeleteFiles removes files stored by uploads of a request that failed
+	// as a whole.
+	DeleteFiles(ctx context.Context, fileIDs ...string) error
+}
+
+// UploadDrainer ends the uploads in progress when the server shuts down.
+type UploadDrainer interface {
+	Drain()
 }
 
 var (
-	ErrSessionNotFound = errors.New("upload session not found")
-	ErrSessionConflict = errors.New("upload already in progress for this session")
-	ErrSessionDone     = errors.New("upload session already complete or canceled")
-	ErrEmptyBody       = errors.New("upload: empty body")
+	_ Media         = (*MediaService)(nil)
+	_ UploadDrainer = (*MediaService)(nil)
+)
+
+var (
+	ErrSessionNotFound      = errors.New("upload session not found")
+	ErrSessionConflict      = errors.New("upload already in progress for this session")
+	ErrSessionDone          = errors.New("upload session already complete or canceled")
+	ErrEmptyBody            = errors.New("upload: empty body")
+	ErrOffsetMismatch       = errors.New("upload: offset does not match the uploaded size")
+	ErrUploadLengthExceeded = errors.New("upload: body exceeds the declared length")
+	ErrUploadIncomplete     = errors.New("upload: final write ends before the declared length")
+	ErrUploadLengthMismatch = errors.New("upload: length differs from the declared length")
+	ErrDraining             = errors.New("upload: the server is shutting down")
 )
 
 const (
-	uploadMaxTTL = 10 * time.Minute
-	sniffSize    = 512
+	sniffSize = 512
+
+	// storageCleanupTimeout bounds the deletion of a file a failed upload
+	// left in storage, which outlives the request.
+	storageCleanupTimeout = 5 * time.Second
 )
 
 type MediaService struct {
 	logger        *slog.Logger
 	storageClient *storageclient.Client
 	chunkSize     int
+	events        EventEmitter
+	policies      *uploadPolicies
+	// scanner is nil if uploads are not scanned.
+	scanner Scanner
+	quotas  *QuotaService
+	// registry shares the sessions held here with the other replicas.
+	registry SessionRegistry
+	// uploadTTL is how long a session is kept after its last write.
+	uploadTTL time.Duration
+	// drainTimeout is how long writes in progress may go on once Drain is
+	// called.
+	drainTimeout time.Duration
+	drainOnce    sync.Once
 
 	mu       sync.Mutex
 	sessions map[string]*uploadSession
+	// draining is set by Drain; writing counts the writes in progress, and
+	// idle is closed once none is left while draining.
+	draining bool
+	writing  int
+	idle     chan struct{}
 }
 
-func NewMediaService(logger *slog.Logger, storageClient *storageclient.Client, chunkSize int) Media {
+func NewMediaService(logger *slog.Logger, storageClient *storageclient.Client, chunkSize int, events EventEmitter, cfg config.MediaConfig, scanner Scanner, quotas *QuotaService, registry SessionRegistry) *MediaService {
 	return &MediaService{
 		logger:        logger,
 		storageClient: storageClient,
 		chunkSize:     chunkSize,
+		events:        events,
+		policies:      newUploadPolicies(cfg),
+		scanner:       scanner,
+		quotas:        quotas,
+		registry:      registry,
+		uploadTTL:     cfg.UploadTTL,
+		drainTimeout:  cfg.DrainTimeout,
 		sessions:      make(map[string]*uploadSession),
+		idle:          make(chan struct{}),
 	}
 }
 
@@ -103,6 +161,7 @@ func (s *MediaService) Download(ctx context.Context, req *dto.MediaDownloadReque
 			Name:     meta.GetName(),
 			MimeType: meta.GetMimeType(),
 			Size:     meta.GetSize(),
+			Hash:     meta.GetSha256Sum(),
 		},
 		Body: &streamReader{
 			stream:   stream,
@@ -116,35 +175,90 @@ func (s *MediaService) Download(ctx context.Context, req *dto.MediaDownloadReque
 // lazily on the first chunk of AppendContent so the mime type can be sniffed
 // from real bytes before the Metadata frame is sent.
 func (s *MediaService) CreateUploadSession(ctx context.Context, name string) (string, error) {
-	if _, ok := auth.GetIdentityFromContext(ctx); !ok {
-		return "", auth.IdentityNotFoundErr
+	info, err := s.CreateUpload(ctx, &dto.CreateUploadRequest{Name: name})
+	if err != nil {
+		return "", err
+	}
+
+	return info.ID, nil
+}
+
+// CreateUpload allocates an upload session like CreateUploadSession, for a
+// file of a known length if req.Length is set. Names the upload policy of the
+// domain does not allow, and uploads over its quota, are rejected here,
+// before any content is sent.
+func (s *MediaService) CreateUpload(ctx context.Context, req *dto.CreateUploadRequest) (*dto.UploadInfo, error) {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return nil, auth.IdentityNotFoundErr
+	}
+	if err := s.policies.forDomain(identity.GetDomainID()).checkName(req.Name); err != nil {
+		s.logger.Debug("upload rejected", slog.String("name", req.Name), slog.String("error", err.Error()))
+
+		return nil, err
 	}
 
 	id := uuid.NewString()
-	sess := newUploadSession(name)
+	lease, err := s.quotas.open(ctx, identity.GetDomainID(), id, req.Length)
+	if err != nil {
+		return nil, err
+	}
+	sess := newUploadSession(req.Name, req.Length, s.uploadTTL)
+	sess.lease = lease
 	log := s.logger.With(slog.String("upload_id", id))
 
+	// A session other replicas cannot find would fail behind a load
+	// balancer.
+	if err := s.registry.Register(ctx, id, s.uploadTTL); err != nil {
+		log.Error("upload session could not be registered", slog.String("error", err.Error()))
+		lease.release()
+
+		return nil, err
+	}
+
 	s.mu.Lock()
+	if s.draining {
+		s.mu.Unlock()
+		s.registry.Unregister(id)
+		lease.release()
+
+		return nil, ErrDraining
+	}
 	s.sessions[id] = sess
 	s.mu.Unlock()
 
-	log.Debug("upload session created")
+	log.Debug("upload session created", slog.Int64("length", req.Length))
 
 	go func() {
-		select {
-		case <-sess.terminateChan:
-		case <-time.After(uploadMaxTTL):
-			sess.terminate()
-		}
+		sess.expire()
 
-		s.mu.Lock()
-		delete(s.sessions, id)
-		s.mu.Unlock()
+		s.forget(id)
 
 		log.Debug("upload session removed")
 	}()
 
-	return id, nil
+	return &dto.UploadInfo{ID: id, Length: req.Length, ExpiresAt: sess.expiry()}, nil
+}
+
+// GetUpload returns the progress of an active upload session.
+func (s *MediaService) GetUpload(_ context.Context, uploadID string) (*dto.UploadInfo, error) {
+	s.mu.Lock()
+	sess, found := s.sessions[uploadID]
+	s.mu.Unlock()
+
+	if !found {
+		return nil, ErrSessionNotFound
+	}
+	if !sess.isActive() {
+		return nil, ErrSessionDone
+	}
+
+	return &dto.UploadInfo{
+		ID:        uploadID,
+		Offset:    sess.offset(),
+		Length:    sess.declaredLength(),
+		ExpiresAt: sess.expiry(),
+	}, nil
 }
 
 // GetUploadFileInfo returns the number of bytes uploaded so far for the given
@@ -171,11 +285,113 @@ func (s *MediaService) GetUploadFileInfo(ctx context.Context, uploadID string) (
 	return s.storageClient.GetUploadInfo(ctx, storageID)
 }
 
-// AppendContent streams body chunks to storage. On the first call for a session
-// the body is peeked to detect the mime type, then the SafeUploadFile stream is
-// opened and the Metadata frame is sent with the sniffed mime. Subsequent bytes
-// (including the peeked ones) are forwarded chunk-by-chunk.
+// AppendContent streams body chunks to storage and finalizes the upload once
+// body is exhausted. On the first call for a session the body is peeked to
+// detect the mime type, then the SafeUploadFile stream is opened and the
+// Metadata frame is sent with the sniffed mime. Subsequent bytes (including the
+// peeked ones) are forwarded chunk-by-chunk.
 func (s *MediaService) AppendContent(ctx context.Context, uploadID string, body io.Reader) (*dto.FileMetadata, error) {
+	if err := s.beginWrite(); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
+
+	sess, err := s.lockSession(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.writeLock.Unlock()
+	s.touch(ctx, uploadID, sess)
+
+	res, err := s.write(ctx, uploadID, sess, body, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.File, nil
+}
+
+// WriteUpload implements Media.
+func (s *MediaService) WriteUpload(ctx context.Context, req *dto.WriteUploadRequest) (*dto.WriteUploadResult, error) {
+	if err := s.beginWrite(); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
+
+	sess, err := s.lockSession(req.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.writeLock.Unlock()
+	s.touch(ctx, req.UploadID, sess)
+	defer s.touch(ctx, req.UploadID, sess)
+
+	if offset := sess.offset(); req.Offset != offset {
+		s.logger.Debug("write upload: offset mismatch",
+			slog.String("upload_id", req.UploadID),
+			slog.Int64("offset", req.Offset),
+			slog.Int64("uploaded", offset))
+
+		return nil, ErrOffsetMismatch
+	}
+	if req.Length > 0 {
+		if err := sess.declareLength(req.Length); err != nil {
+			return nil, err
+		}
+	}
+	if req.Checksum != nil {
+		sess.checksum = req.Checksum
+	}
+
+	return s.write(ctx, req.UploadID, sess, req.Body, req.Final)
+}
+
+// touch keeps the session, here and in the registry, for the upload TTL from
+// now, unless it is over.
+func (s *MediaService) touch(ctx context.Context, uploadID string, sess *uploadSession) {
+	if !sess.isActive() {
+		return
+	}
+	sess.extend()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sessionRegistryTimeout)
+	defer cancel()
+
+	if err := s.registry.Refresh(ctx, uploadID, s.uploadTTL); err != nil {
+		s.logger.Warn("upload session could not be refreshed",
+			slog.String("upload_id", uploadID), slog.String("error", err.Error()))
+	}
+	if err := sess.lease.extend(ctx); err != nil {
+		s.logger.Warn("upload quota reservation could not be refreshed",
+			slog.String("upload_id", uploadID), slog.String("error", err.Error()))
+	}
+}
+
+// beginWrite counts a write in progress, unless the service is draining.
+func (s *MediaService) beginWrite() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.draining {
+		return ErrDraining
+	}
+	s.writing++
+
+	return nil
+}
+
+func (s *MediaService) endWrite() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writing--
+	if s.draining && s.writing == 0 {
+		close(s.idle)
+	}
+}
+
+// lockSession returns the active session uploadID with its writeLock held.
+func (s *MediaService) lockSession(uploadID string) (*uploadSession, error) {
 	log := s.logger.With(slog.String("upload_id", uploadID))
 
 	s.mu.Lock()
@@ -189,17 +405,33 @@ func (s *MediaService) AppendContent(ctx context.Context, uploadID string, body
 	}
 
 	sess.writeLock.Lock()
-	defer sess.writeLock.Unlock()
 
 	if !sess.isActive() {
+		sess.writeLock.Unlock()
 		log.Debug("append content: session already inactive")
 
 		return nil, ErrSessionDone
 	}
 
+	return sess, nil
+}
+
+// write forwards body to storage, opening the storage stream on the first
+// write, and finalizes the upload when final is set or the declared length
+// is reached. The caller holds sess.writeLock.
+func (s *MediaService) write(ctx context.Context, uploadID string, sess *uploadSession, body io.Reader, final bool) (*dto.WriteUploadResult, error) {
+	log := s.logger.With(slog.String("upload_id", uploadID))
+
 	log.Debug("append content started")
 
-	reader := bufio.NewReaderSize(body, s.chunkSize)
+	// Never read past the declared length: what follows it is checked for
+	// below.
+	length := sess.declaredLength()
+	src := body
+	if length > 0 {
+		src = io.LimitReader(body, length-sess.offset())
+	}
+	reader := bufio.NewReaderSize(src, s.chunkSize)
 
 	if sess.stream == nil {
 		if err := s.startStorageStream(ctx, sess, reader); err != nil {
@@ -213,7 +445,48 @@ func (s *MediaService) AppendContent(ctx context.Context, uploadID string, body
 		return nil, err
 	}
 
-	log.Debug("append content: body fully read, finalizing", slog.Int64("bytes_streamed", sess.offset()))
+	offset := sess.offset()
+	if length > 0 {
+		var extra [1]byte
+		if n, _ := io.ReadFull(body, extra[:]); n > 0 {
+			log.Debug("append content: body exceeds declared length", slog.Int64("length", length))
+
+			sess.terminate()
+
+			return nil, ErrUploadLengthExceeded
+		}
+
+		if offset < length {
+			if final {
+				return nil, ErrUploadIncomplete
+			}
+
+			return &dto.WriteUploadResult{Offset: offset}, nil
+		}
+	} else if !final {
+		return &dto.WriteUploadResult{Offset: offset}, nil
+	}
+
+	// Storage keeps nothing of an upload that is not finalized.
+	if err := sess.digest.verify(sess.checksum); err != nil {
+		log.Debug("append content: checksum mismatch", slog.String("error", err.Error()))
+
+		sess.terminate()
+
+		return nil, err
+	}
+
+	if sess.scan != nil {
+		if err := sess.scan.Verdict(ctx); err != nil {
+			log.Warn("append content: upload rejected by the malware scan", slog.String("error", err.Error()))
+
+			sess.terminate()
+
+			return nil, err
+		}
+	}
+
+	log.Debug("append content: upload complete, finalizing", slog.Int64("bytes_streamed", offset))
 
 	meta, err := sess.finalize()
 	if err != nil {
@@ -224,6 +497,22 @@ func (s *MediaService) AppendContent(ctx context.Context, uploadID string, body
 		return nil, err
 	}
 
+	// The file is not counted against the quota: terminate releases it.
+	if err := sess.digest.verifyStored(meta.Hash); err != nil {
+		log.Error("append content: stored file does not match the content sent",
+			slog.String("file_id", meta.ID),
+			slog.String("sha256", meta.Hash))
+
+		s.deleteFile(ctx, meta.ID, log)
+		sess.terminate()
+
+		return nil, err
+	}
+
+	if err := sess.lease.commit(ctx, meta.Size); err != nil {
+		log.Warn("append content: accounting the upload failed", slog.String("error", err.Error()))
+	}
+
 	log.Debug("append content: upload finalized",
 		slog.String("file_id", meta.ID),
 		slog.Int64("size", meta.Size),
@@ -231,9 +520,73 @@ func (s *MediaService) AppendContent(ctx context.Context, uploadID string, body
 
 	sess.terminate()
 
+	if identity, ok := auth.GetIdentityFromContext(ctx); ok {
+		s.events.Emit(ctx, newDomainEvent(dto.EventFileUploaded, identity, meta.ID,
+			"file_id", meta.ID,
+		))
+	}
+
+	return &dto.WriteUploadResult{Offset: offset, File: meta}, nil
+}
+
+// deleteFile removes a stored file the upload must not leave behind. Failures
+// are logged only: the upload has failed either way.
+func (s *MediaService) deleteFile(ctx context.Context, fileID string, log *slog.Logger) {
+	id, err := strconv.ParseInt(fileID, 10, 64)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), storageCleanupTimeout)
+		defer cancel()
+
+		err = s.storageClient.DeleteFiles(ctx, id)
+	}
+	if err != nil {
+		log.Error("append content: deleting the stored file failed",
+			slog.String("file_id", fileID), slog.String("error", err.Error()))
+	}
+}
+
+// Upload stores body as the file name through a session of its own, which
+// does not outlive the call: it is terminated if the upload fails.
+func (s *MediaService) Upload(ctx context.Context, name string, body io.Reader) (*dto.FileMetadata, error) {
+	info, err := s.CreateUpload(ctx, &dto.CreateUploadRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := s.AppendContent(ctx, info.ID, body)
+	if err != nil {
+		_ = s.TerminateUploadSession(info.ID)
+
+		return nil, err
+	}
+
 	return meta, nil
 }
 
+// DeleteFiles implements Media. The deletion outlives ctx, which usually
+// belongs to a request that is failing.
+func (s *MediaService) DeleteFiles(ctx context.Context, fileIDs ...string) error {
+	ids := make([]int64, 0, len(fileIDs))
+	for _, fileID := range fileIDs {
+		id, err := strconv.ParseInt(fileID, 10, 64)
+		if err != nil {
+			return errors.InvalidArgument("invalid file id",
+				errors.WithID("service.media.delete_files"),
+				errors.WithCause(err),
+			)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), storageCleanupTimeout)
+	defer cancel()
+
+	return s.storageClient.DeleteFiles(ctx, ids...)
+}
+
 // TerminateUploadSession cancels an in-progress upload session, marking it
 // inactive and aborting the underlying storage stream if one was attached. The
 // partial upload is discarded rather than finalized, and the session is removed
@@ -255,8 +608,95 @@ func (s *MediaService) TerminateUploadSession(uploadID string) error {
 	return nil
 }
 
+// Drain ends the uploads of a replica that shuts down: new sessions and
+// writes are refused, the writes in progress get the drain timeout to
+// complete, then every session left is terminated, so that storage discards
+// what it received of them, and removed with its quota reservation and its
+// registry entry. Later calls wait for the first one to return.
+func (s *MediaService) Drain() {
+	s.drainOnce.Do(s.drain)
+}
+
+func (s *MediaService) drain() {
+	s.mu.Lock()
+	s.draining = true
+	writing := s.writing
+	if writing == 0 {
+		close(s.idle)
+	}
+	s.mu.Unlock()
+
+	s.logger.Info("media: draining uploads", slog.Int("writes", writing), slog.Duration("timeout", s.drainTimeout))
+
+	timer := time.NewTimer(s.drainTimeout)
+	select {
+	case <-s.idle:
+	case <-timer.C:
+	}
+	timer.Stop()
+
+	s.mu.Lock()
+	writing = s.writing
+	all := maps.Clone(s.sessions)
+	s.mu.Unlock()
+
+	// Leases are released and sessions unregistered before the drain
+	// returns: the janitors of the sessions may not run before the process
+	// exits.
+	sessions := make(map[string]*uploadSession, len(all))
+	var uploaded int64
+	for id, sess := range all {
+		if sess.isActive() {
+			sessions[id] = sess
+			uploaded += sess.offset()
+			s.logger.Debug("media: upload cut off by shutdown",
+				slog.String("upload_id", id),
+				slog.String("name", sess.name),
+				slog.Int64("offset", sess.offset()))
+		}
+
+		sess.terminate()
+		s.forget(id)
+	}
+
+	if len(sessions) == 0 {
+		s.logger.Info("media: uploads drained")
+
+		return
+	}
+	s.logger.Warn("media: uploads cut off by shutdown",
+		slog.Int("sessions", len(sessions)),
+		slog.Int("writes", writing),
+		slog.Int64("bytes", uploaded))
+}
+
+// forget removes the session from the service and the registry.
+func (s *MediaService) forget(uploadID string) {
+	s.mu.Lock()
+	delete(s.sessions, uploadID)
+	s.mu.Unlock()
+
+	s.registry.Unregister(uploadID)
+}
+
+// Shutdown drains the uploads, unless ctx ends first.
+func (s *MediaService) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.Drain()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // streamChunks forwards body from reader to the storage stream chunk-by-chunk,
-// updating the session offset and pinging the heartbeat after each chunk. It
+// updating the session offset and extending its expiry after each chunk. It
 // returns nil when the body is exhausted, or the first send/read/context error.
 // A send error terminates the session (the storage stream is broken); a read
 // error leaves the session resumable.
@@ -274,6 +714,32 @@ func (s *MediaService) streamChunks(ctx context.Context, sess *uploadSession, re
 
 		n, readErr := reader.Read(buf)
 		if n > 0 {
+			if sess.maxSize > 0 && sess.offset()+int64(n) > sess.maxSize {
+				log.Debug("append content: file exceeds the max size of its type", slog.Int64("max_size", sess.maxSize))
+
+				sess.terminate()
+
+				return fmt.Errorf("%w: file exceeds the %d bytes allowed for its type", ErrUnsupportedMedia, sess.maxSize)
+			}
+
+			if err := sess.lease.reserve(ctx, sess.offset()+int64(n)); err != nil {
+				log.Debug("append content: quota reservation failed", slog.String("error", err.Error()))
+
+				if errors.Is(err, ErrQuotaExceeded) {
+					sess.terminate()
+				}
+
+				return err
+			}
+
+			if sess.scan != nil {
+				if _, err := sess.scan.Write(buf[:n]); err != nil {
+					sess.terminate()
+
+					return err
+				}
+			}
+
 			if sendErr := sess.stream.Send(&storagev1.SafeUploadFileRequest{
 				Data: &storagev1.SafeUploadFileRequest_Chunk{Chunk: buf[:n]},
 			}); sendErr != nil {
@@ -285,12 +751,10 @@ func (s *MediaService) streamChunks(ctx context.Context, sess *uploadSession, re
 				return sendErr
 			}
 
-			// Signal the heartbeat goroutine that the session is still active.
-			select {
-			case sess.aliveChan <- struct{}{}:
-			default:
-			}
+			// Keep the session from expiring in the middle of a long write.
+			sess.extend()
 
+			_, _ = sess.digest.Write(buf[:n])
 			sess.addOffset(n)
 		}
 
@@ -330,16 +794,43 @@ func (s *MediaService) startStorageStream(ctx context.Context, sess *uploadSessi
 		return ErrEmptyBody
 	}
 
-	mime := http.DetectContentType(sniff)
+	mimeType := sniffContentType(sniff)
 
 	log.Debug("start storage stream: sniffed mime type",
-		slog.String("mime_type", mime), slog.Int("sniff_bytes", len(sniff)))
+		slog.String("mime_type", mimeType), slog.Int("sniff_bytes", len(sniff)))
+
+	maxSize, err := s.policies.forDomain(identity.GetDomainID()).checkContent(sess.name, mimeType)
+	if err == nil && maxSize > 0 && sess.declaredLength() > maxSize {
+		err = fmt.Errorf("%w: %s files may not exceed %d bytes", ErrUnsupportedMedia, mediaType(mimeType), maxSize)
+	}
+	if err != nil {
+		log.Debug("start storage stream: upload rejected", slog.String("error", err.Error()))
+
+		// Nothing more can be sent to this session.
+		sess.terminate()
+
+		return err
+	}
+	sess.maxSize = maxSize
+
+	var scan FileScan
+	if s.scanner != nil {
+		if scan, err = s.scanner.NewScan(ctx); err != nil {
+			return err
+		}
+	}
+	abortScan := func() {
+		if scan != nil {
+			scan.Abort()
+		}
+	}
 
 	streamCtx, cancelFn := context.WithCancel(context.Background())
 
 	stream, releaseFn, err := s.storageClient.SafeUploadFile(streamCtx)
 	if err != nil {
 		cancelFn()
+		abortScan()
 
 		return err
 	}
@@ -349,12 +840,13 @@ func (s *MediaService) startStorageStream(ctx context.Context, sess *uploadSessi
 			Metadata: &storagev1.SafeUploadFileRequest_Metadata{
 				DomainId: identity.GetDomainID(),
 				Name:     sess.name,
-				MimeType: mime,
+				MimeType: mimeType,
 			},
 		},
 	}); err != nil {
 		cancelFn()
 		releaseFn()
+		abortScan()
 
 		return err
 	}
@@ -363,6 +855,7 @@ func (s *MediaService) startStorageStream(ctx context.Context, sess *uploadSessi
 	if err != nil {
 		cancelFn()
 		releaseFn()
+		abortScan()
 
 		return err
 	}
@@ -371,11 +864,12 @@ func (s *MediaService) startStorageStream(ctx context.Context, sess *uploadSessi
 	if part == nil || part.GetUploadId() == "" {
 		cancelFn()
 		releaseFn()
+		abortScan()
 
 		return errors.New("storage: expected non-empty Part as first stream response")
 	}
 
-	if !sess.attachStream(stream, cancelFn, releaseFn, part.GetUploadId()) {
+	if !sess.attachStream(stream, cancelFn, releaseFn, part.GetUploadId(), scan) {
 		log.Debug("start storage stream: session terminated before attach",
 			slog.String("storage_upload_id", part.GetUploadId()))
 
@@ -383,7 +877,7 @@ func (s *MediaService) startStorageStream(ctx context.Context, sess *uploadSessi
 	}
 
 	log.Debug("start storage stream: storage session opened",
-		slog.String("storage_upload_id", part.GetUploadId()), slog.String("mime_type", mime))
+		slog.String("storage_upload_id", part.GetUploadId()), slog.String("mime_type", mimeType))
 
 	return nil
 }
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/infra/auth"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+var (
+	ErrLinksDisabled = errors.New("signed media links are not configured")
+	ErrLinkInvalid   = errors.New("media link: invalid signature")
+	ErrLinkExpired   = errors.New("media link: expired")
+
+	ErrInvalidLinkDisposition = errors.InvalidArgument("link disposition must be inline or attachment",
+		errors.WithID("service.media_link.disposition"))
+)
+
+// linkIssuer is the issuer of the identities of signed link downloads.
+const linkIssuer = "signed-link"
+
+var _ MediaLinker = (*MediaLinkService)(nil)
+
+// MediaLinker issues and checks signed links, which let anyone holding them
+// download one file of a domain until they expire.
+type MediaLinker interface {
+	SignLink(ctx context.Context, req *dto.SignLinkRequest) (*dto.SignedLink, error)
+	// Authorize checks the token of a link to fileID. It returns the link and
+	// a context to download the file with through Media.
+	Authorize(ctx context.Context, fileID int64, token string) (context.Context, *dto.SignedLink, error)
+}
+
+// MediaLinkService signs links with HMAC-SHA256 over the file ID, domain,
+// expiry and disposition. Links are stateless: they cannot be revoked before
+// they expire, short of changing the secret.
+type MediaLinkService struct {
+	logger *slog.Logger
+	cfg    config.MediaConfig
+}
+
+func NewMediaLinkService(logger *slog.Logger, cfg config.MediaConfig) *MediaLinkService {
+	return &MediaLinkService{logger: logger, cfg: cfg}
+}
+
+// SignLink implements MediaLinker. The TTL is capped at the configured max.
+func (s *MediaLinkService) SignLink(ctx context.Context, req *dto.SignLinkRequest) (*dto.SignedLink, error) {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return nil, auth.IdentityNotFoundErr
+	}
+	if s.cfg.LinkSecret == "" {
+		return nil, ErrLinksDisabled
+	}
+
+	disposition := req.Disposition
+	switch disposition {
+	case "":
+		disposition = dto.MediaDispositionInline
+	case dto.MediaDispositionInline, dto.MediaDispositionAttachment:
+	default:
+		return nil, ErrInvalidLinkDisposition
+	}
+
+	ttl := req.TTL
+	if ttl <= 0 {
+		ttl = s.cfg.LinkTTL
+	}
+	ttl = min(ttl, s.cfg.LinkMaxTTL)
+
+	link := &dto.SignedLink{
+		FileID:      req.FileID,
+		DomainID:    identity.GetDomainID(),
+		Disposition: disposition,
+		ExpiresAt:   time.Now().Add(ttl).Truncate(time.Second),
+	}
+	link.Token = fmt.Sprintf("%d.%d.%s.%s", link.DomainID, link.ExpiresAt.Unix(), disposition, s.sign(link))
+
+	s.logger.Debug("media link signed",
+		slog.Int64("file_id", link.FileID), slog.Int64("domain_id", link.DomainID),
+		slog.String("contact_id", identity.GetContactID()), slog.Time("expires_at", link.ExpiresAt))
+
+	return link, nil
+}
+
+// Authorize implements MediaLinker. A token is DOMAIN.EXPIRES.DISPOSITION.SIGNATURE.
+func (s *MediaLinkService) Authorize(ctx context.Context, fileID int64, token string) (context.Context, *dto.SignedLink, error) {
+	if s.cfg.LinkSecret == "" {
+		return nil, nil, ErrLinksDisabled
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return nil, nil, ErrLinkInvalid
+	}
+	domainID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, nil, ErrLinkInvalid
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, nil, ErrLinkInvalid
+	}
+
+	link := &dto.SignedLink{
+		FileID:      fileID,
+		DomainID:    domainID,
+		Disposition: dto.MediaDisposition(parts[2]),
+		ExpiresAt:   time.Unix(expires, 0),
+		Token:       token,
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil || !hmac.Equal(signature, s.mac(link)) {
+		return nil, nil, ErrLinkInvalid
+	}
+	if !time.Now().Before(link.ExpiresAt) {
+		return nil, nil, ErrLinkExpired
+	}
+
+	return context.WithValue(ctx, auth.AuthContextKey, linkIdentity{domainID: domainID}), link, nil
+}
+
+func (s *MediaLinkService) sign(link *dto.SignedLink) string {
+	return base64.RawURLEncoding.EncodeToString(s.mac(link))
+}
+
+func (s *MediaLinkService) mac(link *dto.SignedLink) []byte {
+	h := hmac.New(sha256.New, []byte(s.cfg.LinkSecret))
+	fmt.Fprintf(h, "v1\n%d\n%d\n%d\n%s", link.FileID, link.DomainID, link.ExpiresAt.Unix(), link.Disposition)
+
+	return h.Sum(nil)
+}
+
+// linkIdentity is the identity of a signed link download: the domain the
+// link was issued in, and no contact.
+type linkIdentity struct {
+	domainID int64
+}
+
+func (i linkIdentity) GetContactID() string { return "" }
+func (i linkIdentity) GetDomainID() int64   { return i.domainID }
+func (i linkIdentity) GetIssuer() string    { return linkIssuer }
+func (i linkIdentity) GetName() string      { return "" }
+func (i linkIdentity) GetVia() string       { return "" }
+func (i linkIdentity) GetViaPtr() *string   { return nil }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/infra/auth"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+func TestMediaLinkService(t *testing.T) {
+	links := NewMediaLinkService(slog.New(slog.DiscardHandler), config.MediaConfig{
+		LinkSecret: "secret",
+		LinkTTL:    time.Minute,
+		LinkMaxTTL: time.Hour,
+	})
+
+	link, err := links.SignLink(identityContext(t, 3, "c1"), &dto.SignLinkRequest{FileID: 42, TTL: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Disposition != dto.MediaDispositionInline || time.Until(link.ExpiresAt) > time.Hour {
+		t.Errorf("link = %+v, want inline and capped at an hour", link)
+	}
+
+	ctx, got, err := links.Authorize(t.Context(), 42, link.Token)
+	if err != nil || got.DomainID != 3 || got.Disposition != dto.MediaDispositionInline {
+		t.Fatalf("authorize = %+v, %v", got, err)
+	}
+	if identity, ok := auth.GetIdentityFromContext(ctx); !ok || identity.GetDomainID() != 3 {
+		t.Errorf("link identity = %v, want domain 3", identity)
+	}
+
+	for name, tt := range map[string]struct {
+		fileID int64
+		token  string
+	}{
+		"other file":        {43, link.Token},
+		"other domain":      {42, "4" + strings.TrimPrefix(link.Token, "3")},
+		"attachment":        {42, strings.Replace(link.Token, ".inline.", ".attachment.", 1)},
+		"malformed":         {42, "3.123"},
+		"bad signature":     {42, link.Token[:strings.LastIndexByte(link.Token, '.')+1] + "AAAA"},
+		"extended lifetime": {42, strings.Replace(link.Token, ".", ".9", 1)},
+	} {
+		if _, _, err := links.Authorize(t.Context(), tt.fileID, tt.token); !errors.Is(err, ErrLinkInvalid) {
+			t.Errorf("%s: authorize = %v, want ErrLinkInvalid", name, err)
+		}
+	}
+
+	expired := NewMediaLinkService(slog.New(slog.DiscardHandler), config.MediaConfig{
+		LinkSecret: "secret",
+		LinkTTL:    -time.Second,
+		LinkMaxTTL: time.Hour,
+	})
+	link, _ = expired.SignLink(identityContext(t, 3, "c1"), &dto.SignLinkRequest{FileID: 42})
+	if _, _, err := links.Authorize(t.Context(), 42, link.Token); !errors.Is(err, ErrLinkExpired) {
+		t.Errorf("expired link = %v, want ErrLinkExpired", err)
+	}
+
+	disabled := NewMediaLinkService(slog.New(slog.DiscardHandler), config.MediaConfig{})
+	if _, _, err := disabled.Authorize(t.Context(), 42, link.Token); !errors.Is(err, ErrLinksDisabled) {
+		t.Errorf("links without a secret = %v, want ErrLinksDisabled", err)
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// recordedSessions records the uploads unregistered.
+type recordedSessions struct {
+	LocalSessions
+
+	mu           sync.Mutex
+	unregistered []string
+}
+
+func (r *recordedSessions) Unregister(uploadID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.unregistered = append(r.unregistered, uploadID)
+}
+
+func TestMediaService_Drain(t *testing.T) {
+	ctx := identityContext(t, 1, "c1")
+	logger := slog.New(slog.DiscardHandler)
+	registry := &recordedSessions{}
+	media := NewMediaService(logger, nil, 4096, nil, config.MediaConfig{UploadTTL: time.Minute, DrainTimeout: 50 * time.Millisecond},
+		nil, NewQuotaService(logger, nil, config.QuotaConfig{}, time.Minute), registry)
+
+	idle, err := media.CreateUpload(ctx, &dto.CreateUploadRequest{Name: "idle.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy, err := media.CreateUpload(ctx, &dto.CreateUploadRequest{Name: "busy.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The write waits for the body, which never comes before the drain
+	// timeout.
+	body, client := io.Pipe()
+	written := make(chan error, 1)
+	go func() {
+		_, err := media.WriteUpload(ctx, &dto.WriteUploadRequest{UploadID: busy.ID, Body: body})
+		written <- err
+	}()
+	for {
+		media.mu.Lock()
+		writing := media.writing
+		media.mu.Unlock()
+		if writing > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	media.Drain()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("drain returned after %v, before its timeout", elapsed)
+	}
+
+	for _, id := range []string{idle.ID, busy.ID} {
+		if _, err := media.GetUpload(ctx, id); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("upload %s after the drain = %v, want it removed", id, err)
+		}
+	}
+	registry.mu.Lock()
+	if !slices.Contains(registry.unregistered, idle.ID) || !slices.Contains(registry.unregistered, busy.ID) {
+		t.Errorf("unregistered by the drain: %v, want both uploads", registry.unregistered)
+	}
+	registry.mu.Unlock()
+	if _, err := media.CreateUpload(ctx, &dto.CreateUploadRequest{Name: "late.txt"}); !errors.Is(err, ErrDraining) {
+		t.Errorf("upload created while draining = %v, want ErrDraining", err)
+	}
+	if _, err := media.WriteUpload(ctx, &dto.WriteUploadRequest{UploadID: idle.ID}); !errors.Is(err, ErrDraining) {
+		t.Errorf("write while draining = %v, want ErrDraining", err)
+	}
+
+	_ = client.CloseWithError(io.ErrUnexpectedEOF)
+	if err := <-written; err == nil {
+		t.Error("the write cut off by the drain succeeded")
+	}
+
+	// Draining again returns at once.
+	if err := media.Shutdown(ctx); err != nil {
+		t.Errorf("second drain = %v", err)
+	}
+}
+
+func TestMediaService_WritesExtendUploads(t *testing.T) {
+	const ttl = 300 * time.Millisecond
+
+	ctx := identityContext(t, 1, "c1")
+	logger := slog.New(slog.DiscardHandler)
+	media := NewMediaService(logger, nil, 4096, nil, config.MediaConfig{UploadTTL: ttl},
+		nil, NewQuotaService(logger, nil, config.QuotaConfig{}, time.Minute), LocalSessions{})
+
+	info, err := media.CreateUpload(ctx, &dto.CreateUploadRequest{Name: "notes.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An empty write fails, but still counts as activity.
+	time.Sleep(ttl * 2 / 3)
+	if _, err := media.WriteUpload(ctx, &dto.WriteUploadRequest{UploadID: info.ID, Body: strings.NewReader("")}); !errors.Is(err, ErrEmptyBody) {
+		t.Fatalf("empty write = %v, want ErrEmptyBody", err)
+	}
+
+	time.Sleep(ttl * 2 / 3)
+	extended, err := media.GetUpload(ctx, info.ID)
+	if err != nil {
+		t.Fatalf("upload after its first TTL = %v, want it extended by the write", err)
+	}
+	if !extended.ExpiresAt.After(info.ExpiresAt) {
+		t.Errorf("expiry %v not moved past %v", extended.ExpiresAt, info.ExpiresAt)
+	}
+
+	time.Sleep(ttl)
+	if _, err := media.GetUpload(ctx, info.ID); err == nil {
+		t.Error("upload still active a TTL after its last write")
+	}
+}
+
+func TestUploadSession_AttachedStreamLastsTheTTL(t *testing.T) {
+	const ttl = 200 * time.Millisecond
+
+	sess := newUploadSession("notes.txt", 0, ttl)
+	canceled := make(chan struct{})
+	if !sess.attachStream(nil, func() { close(canceled) }, func() {}, "s1", nil) {
+		t.Fatal("stream not attached")
+	}
+
+	expired := make(chan struct{})
+	go func() {
+		sess.expire()
+		close(expired)
+	}()
+
+	// A pause shorter than the TTL keeps the session and its stream.
+	time.Sleep(ttl / 2)
+	if !sess.isActive() {
+		t.Fatal("session ended before its TTL")
+	}
+	sess.extend()
+	time.Sleep(ttl * 3 / 4)
+	if !sess.isActive() {
+		t.Fatal("session ended before the TTL of its last write")
+	}
+
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("session did not expire")
+	}
+	select {
+	case <-canceled:
+	default:
+		t.Error("stream of the expired session was not canceled")
+	}
+}
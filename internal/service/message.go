@@ -45,6 +45,8 @@ type MessageService struct {
 	threader   *imthread.Client
 	contacter  *imcontact.Client
 	viasClient *imcontact.ViaClient
+	receipts   ReadReceipter
+	events     EventEmitter
 }
 
 // SendContact implements Messenger.
@@ -80,6 +82,8 @@ func (m *MessageService) SendContact(ctx context.Context, in *api.SendContactReq
 		return nil, err
 	}
 
+	m.emitSent(ctx, identity, resp.GetId(), to)
+
 	return &api.SendMessageResponse{
 		To: in.To,
 		Id: resp.Id,
@@ -119,6 +123,8 @@ func (m *MessageService) SendInteractive(ctx context.Context, in *api.SendIntera
 		return nil, err
 	}
 
+	m.emitSent(ctx, identity, resp.GetId(), to)
+
 	return &api.SendMessageResponse{
 		To: in.To,
 		Id: resp.Id,
@@ -148,6 +154,10 @@ func (m *MessageService) SendInteractiveCallback(ctx context.Context, in *api.In
 		return nil, err
 	}
 
+	m.events.Emit(ctx, newDomainEvent(dto.EventInteractiveCallback, identity, in.InReplyTo,
+		"message_id", in.InReplyTo,
+	))
+
 	return &api.InteractiveCallbackResponse{
 		InReplyTo:    resp.InReplyTo,
 		ButtonCode:   resp.ButtonCode,
@@ -189,17 +199,21 @@ func (m *MessageService) SendLocation(ctx context.Context, in *api.SendLocationR
 		return nil, err
 	}
 
+	m.emitSent(ctx, identity, resp.GetId(), to)
+
 	return &api.SendMessageResponse{
 		To: in.To,
 		Id: resp.Id,
 	}, nil
 }
 
-func NewMessageService(logger *slog.Logger, threadClient *imthread.Client, contacter *imcontact.Client) *MessageService {
+func NewMessageService(logger *slog.Logger, threadClient *imthread.Client, contacter *imcontact.Client, receipts ReadReceipter, events EventEmitter) *MessageService {
 	return &MessageService{
 		logger:    logger,
 		threader:  threadClient,
 		contacter: contacter,
+		receipts:  receipts,
+		events:    events,
 	}
 }
 
@@ -234,6 +248,8 @@ func (m *MessageService) SendText(ctx context.Context, in *dto.SendTextRequest)
 		return nil, err
 	}
 
+	m.emitSent(ctx, identity, resp.GetId(), to)
+
 	return &dto.SendTextResponse{To: in.To, ID: m.parseUUID(resp.GetId())}, nil
 }
 
@@ -268,6 +284,8 @@ func (m *MessageService) SendDocument(ctx context.Context, in *dto.SendDocumentR
 		return nil, err
 	}
 
+	m.emitSent(ctx, identity, resp.GetId(), to)
+
 	return &dto.SendDocumentResponse{To: in.To, ID: m.parseUUID(resp.GetId())}, nil
 }
 
@@ -301,6 +319,8 @@ func (m *MessageService) SendSystemMessage(ctx context.Context, in *dto.SendSyst
 		return nil, err
 	}
 
+	m.emitSent(ctx, identity, resp.GetId(), to)
+
 	return &dto.SendSystemMessageResponse{
 		To: in.To,
 		ID: m.parseUUID(resp.GetId()),
@@ -323,11 +343,47 @@ func (m *MessageService) Read(ctx context.Context, in *dto.ReadMessageRequest) e
 	if err != nil {
 		return err
 	}
+
+	m.events.Emit(ctx, newDomainEvent(dto.EventMessageRead, identity, in.MessageID,
+		"message_id", in.MessageID,
+		"thread_id", in.ThreadID,
+	))
+
+	// im-thread has accepted the read at this point, so a lost read position
+	// must not make the client retry it.
+	if err := m.receipts.MarkRead(ctx, in); err != nil {
+		m.logger.Warn("failed to store read position",
+			"thread_id", in.ThreadID,
+			"message_id", in.MessageID,
+			"err", err,
+		)
+	}
+
 	return nil
 }
 
 // --- Internal Helpers & Mappers ---
 
+// [INTERNAL] emitSent announces a message accepted by im-thread
+func (m *MessageService) emitSent(ctx context.Context, identity auth.Identifier, messageID string, to *threadv1.Peer) {
+	var peerKind, peerID string
+	switch kind := to.GetKind().(type) {
+	case *threadv1.Peer_ContactId:
+		peerKind, peerID = "to_contact_id", kind.ContactId
+	case *threadv1.Peer_GroupId:
+		peerKind, peerID = "to_group_id", kind.GroupId
+	case *threadv1.Peer_ChannelId:
+		peerKind, peerID = "to_channel_id", kind.ChannelId
+	case *threadv1.Peer_ThreadId:
+		peerKind, peerID = "thread_id", kind.ThreadId
+	}
+
+	m.events.Emit(ctx, newDomainEvent(dto.EventMessageSent, identity, messageID,
+		"message_id", messageID,
+		peerKind, peerID,
+	))
+}
+
 // [INTERNAL] resolveRecipient maps shared.Peer to threadv1.Peer and performs contact lookup if necessary
 func (m *MessageService) resolveRecipient(ctx context.Context, p shared.Peer, domainID int32) (*threadv1.Peer, error) {
 	switch p.Type {
@@ -1,11 +1,15 @@
 package service
 
 import (
+	"context"
 	"log/slog"
 
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/fx"
 
 	"github.com/webitel/im-gateway-service/config"
+	imcontact "github.com/webitel/im-gateway-service/infra/client/im-contact"
+	imthread "github.com/webitel/im-gateway-service/infra/client/im-thread"
 	storageclient "github.com/webitel/im-gateway-service/infra/client/storage"
 )
 
@@ -20,10 +24,53 @@ var Module = fx.Module(
 		),
 
 		fx.Annotate(
-			func(logger *slog.Logger, storageClient *storageclient.Client, cfg *config.Config) Media {
-				return NewMediaService(logger, storageClient, cfg.Service.UploadChunkSize)
+			func(logger *slog.Logger, storageClient *storageclient.Client, events EventEmitter, quotas *QuotaService, registry SessionRegistry, cfg *config.Config, lc fx.Lifecycle) *MediaService {
+				var scanner Scanner
+				if cfg.Service.Media.Scan.ClamdAddr != "" {
+					scanner = NewClamdScanner(logger, cfg.Service.Media.Scan)
+				}
+
+				media := NewMediaService(logger, storageClient, cfg.Service.UploadChunkSize, events, cfg.Service.Media, scanner, quotas, registry)
+				// The HTTP server starts the drain as it shuts down; this waits
+				// for it, and drains if it did not.
+				lc.Append(fx.StopHook(media.Shutdown))
+
+				return media
 			},
 			fx.As(new(Media)),
+			fx.As(new(UploadDrainer)),
+		),
+
+		// The media service accounts for uploads through the concrete type.
+		fx.Annotate(
+			func(logger *slog.Logger, redisClient *redis.Client, cfg *config.Config) *QuotaService {
+				return NewQuotaService(logger, redisClient, cfg.Service.Media.Quota, cfg.Service.Media.UploadTTL)
+			},
+			fx.As(fx.Self()),
+			fx.As(new(MediaQuotas)),
+		),
+
+		// Sessions are shared once replicas can reach each other.
+		func(logger *slog.Logger, redisClient *redis.Client, cfg *config.Config) SessionRegistry {
+			if cfg.Service.HTTP.AdvertiseURL == "" {
+				return LocalSessions{}
+			}
+
+			return NewRedisSessionRegistry(logger, redisClient, cfg.Service.HTTP.AdvertiseURL)
+		},
+
+		fx.Annotate(
+			func(logger *slog.Logger, media Media, cfg *config.Config) *ThumbnailService {
+				return NewThumbnailService(logger, media, cfg.Service.Media)
+			},
+			fx.As(new(Thumbnailer)),
+		),
+
+		fx.Annotate(
+			func(logger *slog.Logger, cfg *config.Config) *MediaLinkService {
+				return NewMediaLinkService(logger, cfg.Service.Media)
+			},
+			fx.As(new(MediaLinker)),
 		),
 
 		fx.Annotate(
@@ -59,5 +106,76 @@ var Module = fx.Module(
 			fx.As(new(ContactSettingsManager)),
 		),
 		fx.Annotate(newVia, fx.As(new(Via))),
+
+		// Realtime
+		fx.Annotate(
+			func(logger *slog.Logger, cfg *config.Config, lc fx.Lifecycle) *UpdateService {
+				updatesConfig := cfg.Service.Updates
+				updater := NewUpdateService(logger, updatesConfig.BufferSize, updatesConfig.ReplaySize, updatesConfig.ReplayTTL)
+				lc.Append(fx.StopHook(func(context.Context) { updater.Close() }))
+
+				return updater
+			},
+			fx.As(new(Updater)),
+		),
+		fx.Annotate(
+			func(threadClient *imthread.ThreadClient, cfg *config.Config) *ThreadMemberCache {
+				return NewThreadMemberCache(threadClient, cfg.Service.Updates.MembersTTL)
+			},
+			fx.As(new(ThreadMembers)),
+		),
+		fx.Annotate(
+			func(logger *slog.Logger, contactClient *imcontact.Client, redisClient *redis.Client, cfg *config.Config, lc fx.Lifecycle) *WebhookService {
+				webhooker := NewWebhookService(logger, contactClient, redisClient, cfg.Service.Webhooks)
+				lc.Append(fx.StopHook(webhooker.Stop))
+
+				return webhooker
+			},
+			fx.As(new(Webhooker)),
+		),
+		fx.Annotate(
+			func(logger *slog.Logger, threadClient *imthread.ThreadClient, redisClient *redis.Client, publisher UpdatePublisher, cfg *config.Config) *ChatActionService {
+				return NewChatActionService(logger, threadClient, redisClient, publisher, cfg.Service.ChatActions)
+			},
+			fx.As(new(ChatActioner)),
+		),
+		fx.Annotate(
+			func(logger *slog.Logger, threadClient *imthread.ThreadClient, historyClient *imthread.MessageHistoryClient, redisClient *redis.Client) *ReadReceiptService {
+				return NewReadReceiptService(logger, threadClient, historyClient, redisClient)
+			},
+			fx.As(new(ReadReceipter)),
+		),
+
+		// Push notifications
+		fx.Annotate(
+			func(logger *slog.Logger, redisClient *redis.Client, cfg *config.Config, lc fx.Lifecycle) *PresenceService {
+				presence := NewPresenceService(logger, redisClient, cfg.Service.Push.PresenceTTL)
+				lc.Append(fx.Hook{
+					OnStart: func(context.Context) error {
+						presence.Start()
+
+						return nil
+					},
+					OnStop: presence.Stop,
+				})
+
+				return presence
+			},
+			fx.As(new(Presence)),
+		),
+		fx.Annotate(
+			NewPushDeviceStore,
+			fx.As(new(PushDevices)),
+		),
+		fx.Annotate(
+			NewLogPushSender,
+			fx.As(new(PushSender)),
+		),
+		fx.Annotate(
+			func(logger *slog.Logger, devices PushDevices, presence Presence, sender PushSender, cfg *config.Config) *PushService {
+				return NewPushService(logger, devices, presence, sender, cfg.Service.Push.PreviewLength)
+			},
+			fx.As(new(Pusher)),
+		),
 	),
 )
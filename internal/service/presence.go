@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/im-gateway-service/infra/auth"
+	"github.com/webitel/im-gateway-service/internal/model"
+)
+
+var _ Presence = (*PresenceService)(nil)
+
+// Presence knows which contacts have a realtime connection open on any
+// replica.
+type Presence interface {
+	// Track marks the identity found in ctx online until the returned
+	// function is called.
+	Track(ctx context.Context) (release func())
+	IsOnline(ctx context.Context, domainID int64, contactID string) (bool, error)
+}
+
+// PresenceService keeps a sorted set per contact in Redis, with one member
+// per open connection scored by its expiry. Connections of this replica are
+// refreshed every third of the TTL, so the ones of a crashed replica expire
+// on their own.
+type PresenceService struct {
+	logger *slog.Logger
+	redis  *redis.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	conns map[string]string // connection ID -> presence key
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewPresenceService(logger *slog.Logger, redisClient *redis.Client, ttl time.Duration) *PresenceService {
+	return &PresenceService{
+		logger: logger,
+		redis:  redisClient,
+		ttl:    ttl,
+		conns:  make(map[string]string),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start runs the heartbeat of the connections of this replica.
+func (s *PresenceService) Start() {
+	go s.run()
+}
+
+// Stop ends the heartbeat and marks every connection of this replica offline.
+func (s *PresenceService) Stop(ctx context.Context) error {
+	close(s.stop)
+	<-s.done
+
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = make(map[string]string)
+	s.mu.Unlock()
+
+	_, err := s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for id, key := range conns {
+			pipe.ZRem(ctx, key, id)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// Track implements Presence. Failing to reach Redis only costs a redundant
+// push notification, so it is logged rather than returned.
+func (s *PresenceService) Track(ctx context.Context) func() {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return func() {}
+	}
+
+	id := uuid.NewString()
+	key := presenceKey(identity.GetDomainID(), identity.GetContactID())
+
+	s.mu.Lock()
+	s.conns[id] = key
+	s.mu.Unlock()
+
+	if err := s.touch(ctx, map[string]string{id: key}); err != nil {
+		s.logger.Warn("mark contact online", "contact_id", identity.GetContactID(), "err", err)
+	}
+
+	return func() {
+		s.mu.Lock()
+		delete(s.conns, id)
+		s.mu.Unlock()
+
+		// The request context is usually gone by now.
+		if err := s.redis.ZRem(context.Background(), key, id).Err(); err != nil {
+			s.logger.Warn("mark contact offline", "contact_id", identity.GetContactID(), "err", err)
+		}
+	}
+}
+
+// IsOnline implements Presence.
+func (s *PresenceService) IsOnline(ctx context.Context, domainID int64, contactID string) (bool, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	n, err := s.redis.ZCount(ctx, presenceKey(domainID, contactID), "("+now, "+inf").Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (s *PresenceService) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			conns := make(map[string]string, len(s.conns))
+			for id, key := range s.conns {
+				conns[id] = key
+			}
+			s.mu.Unlock()
+
+			ctx, cancel := context.WithTimeout(context.Background(), s.ttl/3)
+			if err := s.touch(ctx, conns); err != nil {
+				s.logger.Warn("refresh presence", "connections", len(conns), "err", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// touch extends the connections by the TTL and drops expired ones.
+func (s *PresenceService) touch(ctx context.Context, conns map[string]string) error {
+	if len(conns) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	expiry := float64(now.Add(s.ttl).UnixMilli())
+
+	_, err := s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for id, key := range conns {
+			pipe.ZAdd(ctx, key, redis.Z{Score: expiry, Member: id})
+			pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.UnixMilli(), 10))
+			pipe.Expire(ctx, key, s.ttl)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+func presenceKey(domainID int64, contactID string) string {
+	return fmt.Sprintf("%s:presence:%d:%s", model.ServiceName, domainID, contactID)
+}
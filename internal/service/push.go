@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/internal/model"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// ErrPushTokenInvalid is returned, possibly wrapped, by a PushSender when the
+// provider reports the device token as expired or unknown. The device is
+// then forgotten.
+var ErrPushTokenInvalid = errors.New("push token is no longer valid")
+
+// pushNotifiedTTL is how long the sessions an update was pushed to are
+// remembered, which bounds how late a retried update can still skip them.
+const pushNotifiedTTL = time.Hour
+
+var (
+	_ Pusher      = (*PushService)(nil)
+	_ PushDevices = (*PushDeviceStore)(nil)
+	_ PushSender  = (*LogPushSender)(nil)
+)
+
+// Pusher notifies offline thread members about updates through push.
+type Pusher interface {
+	Notify(ctx context.Context, u *dto.Update) error
+}
+
+// PushSender delivers a notification to a push provider. Implementations
+// own the provider credentials and any payload encryption the device
+// requires.
+type PushSender interface {
+	Send(ctx context.Context, msg *dto.PushMessage) error
+}
+
+// PushDevices keeps the push subscriptions of contacts.
+type PushDevices interface {
+	Register(ctx context.Context, domainID int64, contactID string, device *dto.PushDevice) error
+	Unregister(ctx context.Context, domainID int64, contactID, sessionID string) error
+	List(ctx context.Context, domainID int64, contactID string) ([]*dto.PushDevice, error)
+	// MarkNotified records that the update was pushed to the session and
+	// reports whether it had not been before.
+	MarkNotified(ctx context.Context, updateID, sessionID string) (bool, error)
+}
+
+type PushService struct {
+	logger        *slog.Logger
+	devices       PushDevices
+	presence      Presence
+	sender        PushSender
+	previewLength int
+}
+
+func NewPushService(logger *slog.Logger, devices PushDevices, presence Presence, sender PushSender, previewLength int) *PushService {
+	return &PushService{
+		logger:        logger,
+		devices:       devices,
+		presence:      presence,
+		sender:        sender,
+		previewLength: previewLength,
+	}
+}
+
+// Notify implements Pusher. Only new messages are pushed, to every recipient
+// but the sender that has no realtime connection open. Failing to look up
+// presence or devices is returned so the update is retried; the devices
+// notified before the failure are skipped by the retry. A failed send only
+// costs that one notification and is logged.
+func (s *PushService) Notify(ctx context.Context, u *dto.Update) error {
+	if u.Type != dto.UpdateNewMessage || u.Message == nil {
+		return nil
+	}
+
+	for _, contactID := range u.Recipients {
+		if contactID == u.Message.SenderID {
+			continue
+		}
+
+		online, err := s.presence.IsOnline(ctx, u.DomainID, contactID)
+		if err != nil {
+			return err
+		}
+		if online {
+			continue
+		}
+
+		devices, err := s.devices.List(ctx, u.DomainID, contactID)
+		if err != nil {
+			return err
+		}
+
+		for _, device := range devices {
+			first, err := s.devices.MarkNotified(ctx, u.ID, device.SessionID)
+			if err != nil {
+				return err
+			}
+			if first {
+				s.send(ctx, u, contactID, device)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *PushService) send(ctx context.Context, u *dto.Update, contactID string, device *dto.PushDevice) {
+	log := s.logger.With(
+		"contact_id", contactID,
+		"session_id", device.SessionID,
+		"provider", device.Provider,
+		"update_id", u.ID,
+	)
+
+	payload, err := s.payload(u, device)
+	if err != nil {
+		log.Warn("build push payload", "err", err)
+
+		return
+	}
+
+	err = s.sender.Send(ctx, &dto.PushMessage{
+		Device:      device,
+		Payload:     payload,
+		CollapseKey: u.ThreadID,
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrPushTokenInvalid):
+		log.Info("forgetting device with invalid push token")
+		if err := s.devices.Unregister(ctx, u.DomainID, contactID, device.SessionID); err != nil {
+			log.Warn("unregister device", "err", err)
+		}
+	default:
+		log.Warn("send push notification", "err", err)
+	}
+}
+
+// payload renders the notification in the format of the device provider.
+func (s *PushService) payload(u *dto.Update, device *dto.PushDevice) ([]byte, error) {
+	preview := s.preview(u.Message.Body)
+	data := map[string]string{
+		"type":       string(u.Type),
+		"thread_id":  u.ThreadID,
+		"message_id": u.Message.ID,
+		"sender_id":  u.Message.SenderID,
+	}
+
+	switch device.Provider {
+	case dto.PushProviderFCM:
+		return json.Marshal(fcmPayload(device.Token, u.ThreadID, preview, data))
+	case dto.PushProviderAPNs:
+		return json.Marshal(apnsPayload(u.ThreadID, preview, data))
+	case dto.PushProviderWeb:
+		return json.Marshal(webPushPayload(u, preview))
+	default:
+		return nil, fmt.Errorf("unknown push provider %q", device.Provider)
+	}
+}
+
+// preview cuts the message body to the configured number of characters.
+func (s *PushService) preview(body string) string {
+	if s.previewLength <= 0 {
+		return ""
+	}
+
+	runes := []rune(body)
+	if len(runes) <= s.previewLength {
+		return body
+	}
+
+	return string(runes[:s.previewLength]) + "…"
+}
+
+// fcmPayload is an FCM HTTP v1 send request. Without a preview the message
+// is data-only and the app renders the notification itself.
+func fcmPayload(token, threadID, preview string, data map[string]string) map[string]any {
+	message := map[string]any{
+		"token": token,
+		"data":  data,
+		"android": map[string]any{
+			"collapse_key": threadID,
+			"priority":     "high",
+		},
+	}
+	if preview != "" {
+		message["notification"] = map[string]any{"body": preview}
+	}
+
+	return map[string]any{"message": message}
+}
+
+// apnsPayload groups notifications by thread and lets a notification service
+// extension enrich them.
+func apnsPayload(threadID, preview string, data map[string]string) map[string]any {
+	aps := map[string]any{
+		"thread-id":       threadID,
+		"mutable-content": 1,
+		"sound":           "default",
+	}
+	if preview != "" {
+		aps["alert"] = map[string]any{"body": preview}
+	}
+
+	payload := map[string]any{"aps": aps}
+	for k, v := range data {
+		payload[k] = v
+	}
+
+	return payload
+}
+
+// webPushPayload is the message data a service worker receives.
+func webPushPayload(u *dto.Update, preview string) map[string]any {
+	payload := map[string]any{
+		"type":      u.Type,
+		"threadId":  u.ThreadID,
+		"messageId": u.Message.ID,
+		"senderId":  u.Message.SenderID,
+	}
+	if preview != "" {
+		payload["body"] = preview
+	}
+
+	return payload
+}
+
+// LogPushSender only logs notifications. It is the default until a sender
+// holding provider credentials is provided in its place.
+type LogPushSender struct {
+	logger *slog.Logger
+}
+
+func NewLogPushSender(logger *slog.Logger) *LogPushSender {
+	return &LogPushSender{logger: logger}
+}
+
+// Send implements PushSender.
+func (s *LogPushSender) Send(_ context.Context, msg *dto.PushMessage) error {
+	s.logger.Debug("push notification not sent: no sender configured",
+		"provider", msg.Device.Provider,
+		"session_id", msg.Device.SessionID,
+		"payload_size", len(msg.Payload),
+	)
+
+	return nil
+}
+
+// PushDeviceStore keeps the devices of a contact in a Redis hash keyed by
+// session ID.
+type PushDeviceStore struct {
+	redis *redis.Client
+}
+
+func NewPushDeviceStore(redisClient *redis.Client) *PushDeviceStore {
+	return &PushDeviceStore{redis: redisClient}
+}
+
+// Register implements PushDevices. A session registering again replaces
+// its previous device.
+func (s *PushDeviceStore) Register(ctx context.Context, domainID int64, contactID string, device *dto.PushDevice) error {
+	data, err := json.Marshal(device)
+	if err != nil {
+		return err
+	}
+
+	return s.redis.HSet(ctx, pushDevicesKey(domainID, contactID), device.SessionID, data).Err()
+}
+
+// Unregister implements PushDevices.
+func (s *PushDeviceStore) Unregister(ctx context.Context, domainID int64, contactID, sessionID string) error {
+	return s.redis.HDel(ctx, pushDevicesKey(domainID, contactID), sessionID).Err()
+}
+
+// List implements PushDevices.
+func (s *PushDeviceStore) List(ctx context.Context, domainID int64, contactID string) ([]*dto.PushDevice, error) {
+	entries, err := s.redis.HGetAll(ctx, pushDevicesKey(domainID, contactID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*dto.PushDevice, 0, len(entries))
+	for _, data := range entries {
+		var device dto.PushDevice
+		if err := json.Unmarshal([]byte(data), &device); err != nil {
+			continue
+		}
+		devices = append(devices, &device)
+	}
+
+	return devices, nil
+}
+
+// MarkNotified implements PushDevices.
+func (s *PushDeviceStore) MarkNotified(ctx context.Context, updateID, sessionID string) (bool, error) {
+	key := pushNotifiedKey(updateID)
+
+	var added *redis.IntCmd
+	_, err := s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		added = pipe.SAdd(ctx, key, sessionID)
+		pipe.Expire(ctx, key, pushNotifiedTTL)
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return added.Val() == 1, nil
+}
+
+func pushNotifiedKey(updateID string) string {
+	return fmt.Sprintf("%s:push_notified:%s", model.ServiceName, updateID)
+}
+
+func pushDevicesKey(domainID int64, contactID string) string {
+	return fmt.Sprintf("%s:push:%d:%s", model.ServiceName, domainID, contactID)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+type fakePresence map[string]bool
+
+func (p fakePresence) Track(context.Context) func() { return func() {} }
+
+func (p fakePresence) IsOnline(_ context.Context, _ int64, contactID string) (bool, error) {
+	return p[contactID], nil
+}
+
+type fakeDevices map[string][]*dto.PushDevice
+
+func (d fakeDevices) Register(_ context.Context, _ int64, contactID string, device *dto.PushDevice) error {
+	d[contactID] = append(d[contactID], device)
+	return nil
+}
+
+func (d fakeDevices) Unregister(_ context.Context, _ int64, contactID, sessionID string) error {
+	var kept []*dto.PushDevice
+	for _, device := range d[contactID] {
+		if device.SessionID != sessionID {
+			kept = append(kept, device)
+		}
+	}
+	d[contactID] = kept
+	return nil
+}
+
+func (d fakeDevices) List(_ context.Context, _ int64, contactID string) ([]*dto.PushDevice, error) {
+	return d[contactID], nil
+}
+
+func (d fakeDevices) MarkNotified(context.Context, string, string) (bool, error) {
+	return true, nil
+}
+
+// fakeSender records sent messages and rejects the tokens listed in invalid.
+type fakeSender struct {
+	sent    []*dto.PushMessage
+	invalid map[string]bool
+}
+
+func (s *fakeSender) Send(_ context.Context, msg *dto.PushMessage) error {
+	if s.invalid[msg.Device.Token] {
+		return fmt.Errorf("fcm: %w", ErrPushTokenInvalid)
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func newMessageUpdate(recipients ...string) *dto.Update {
+	return &dto.Update{
+		ID:         "u1",
+		Type:       dto.UpdateNewMessage,
+		ThreadID:   "t1",
+		DomainID:   1,
+		Recipients: recipients,
+		Message: &dto.UpdateMessage{
+			ID:       "m1",
+			SenderID: "alice",
+			Body:     "hello there",
+		},
+	}
+}
+
+func TestPushService_NotifiesOfflineRecipientsOnly(t *testing.T) {
+	devices := fakeDevices{
+		"alice": {{SessionID: "s0", Provider: dto.PushProviderFCM, Token: "alice-token"}},
+		"bob":   {{SessionID: "s1", Provider: dto.PushProviderFCM, Token: "bob-token"}},
+		"carol": {{SessionID: "s2", Provider: dto.PushProviderAPNs, Token: "carol-token"}},
+	}
+	sender := &fakeSender{}
+	pusher := NewPushService(slog.Default(), devices, fakePresence{"carol": true}, sender, 5)
+
+	if err := pusher.Notify(context.Background(), newMessageUpdate("alice", "bob", "carol")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sender.sent) != 1 || sender.sent[0].Device.Token != "bob-token" {
+		t.Fatalf("sent %d notifications, want one to bob", len(sender.sent))
+	}
+
+	var payload struct {
+		Message struct {
+			Token        string            `json:"token"`
+			Data         map[string]string `json:"data"`
+			Notification struct {
+				Body string `json:"body"`
+			} `json:"notification"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(sender.sent[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Message.Token != "bob-token" || payload.Message.Data["message_id"] != "m1" {
+		t.Errorf("unexpected FCM payload %s", sender.sent[0].Payload)
+	}
+	if payload.Message.Notification.Body != "hello…" {
+		t.Errorf("preview = %q, want %q", payload.Message.Notification.Body, "hello…")
+	}
+}
+
+func TestPushService_ForgetsInvalidTokens(t *testing.T) {
+	devices := fakeDevices{
+		"bob": {
+			{SessionID: "s1", Provider: dto.PushProviderFCM, Token: "stale"},
+			{SessionID: "s2", Provider: dto.PushProviderWeb, Token: "https://push.example.com/x"},
+		},
+	}
+	sender := &fakeSender{invalid: map[string]bool{"stale": true}}
+	pusher := NewPushService(slog.Default(), devices, fakePresence{}, sender, 0)
+
+	if err := pusher.Notify(context.Background(), newMessageUpdate("bob")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sender.sent) != 1 || sender.sent[0].Device.SessionID != "s2" {
+		t.Fatalf("sent %d notifications, want one to the web push device", len(sender.sent))
+	}
+	if len(devices["bob"]) != 1 || devices["bob"][0].SessionID != "s2" {
+		t.Errorf("stale device was not unregistered: %+v", devices["bob"])
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(sender.sent[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := payload["body"]; ok {
+		t.Error("web push payload has a preview although previews are disabled")
+	}
+}
+
+// flakyPresence fails the first lookup of the contacts listed in failOnce.
+type flakyPresence struct {
+	failOnce map[string]bool
+}
+
+func (p *flakyPresence) Track(context.Context) func() { return func() {} }
+
+func (p *flakyPresence) IsOnline(_ context.Context, _ int64, contactID string) (bool, error) {
+	if p.failOnce[contactID] {
+		delete(p.failOnce, contactID)
+		return false, errors.New("presence unavailable")
+	}
+	return false, nil
+}
+
+func TestPushService_RetrySkipsNotifiedDevices(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	ctx := context.Background()
+	devices := NewPushDeviceStore(client)
+	for contactID, token := range map[string]string{"bob": "bob-token", "carol": "carol-token"} {
+		if err := devices.Register(ctx, 1, contactID, &dto.PushDevice{SessionID: contactID + "-session", Provider: dto.PushProviderFCM, Token: token}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sender := &fakeSender{}
+	pusher := NewPushService(slog.Default(), devices, &flakyPresence{failOnce: map[string]bool{"carol": true}}, sender, 0)
+
+	update := newMessageUpdate("bob", "carol")
+	if err := pusher.Notify(ctx, update); err == nil {
+		t.Fatal("Notify succeeded although the presence of carol failed")
+	}
+	if err := pusher.Notify(ctx, update); err != nil {
+		t.Fatal(err)
+	}
+
+	var tokens []string
+	for _, msg := range sender.sent {
+		tokens = append(tokens, msg.Device.Token)
+	}
+	if len(tokens) != 2 || tokens[0] != "bob-token" || tokens[1] != "carol-token" {
+		t.Errorf("sent to %v, want bob once and then carol", tokens)
+	}
+}
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/internal/model"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+const (
+	// quotaBlock is how many bytes an upload reserves at a time, so that
+	// chunks do not each cost a Redis round trip.
+	quotaBlock = 1 << 20
+	// dailyUsageTTL keeps the usage of a day past its end, for uploads that
+	// started on it to be counted.
+	dailyUsageTTL = 48 * time.Hour
+	// quotaReleaseTimeout bounds the release of a reservation, which happens
+	// outside of any request.
+	quotaReleaseTimeout = 5 * time.Second
+)
+
+var ErrQuotaExceeded = errors.New("upload: domain quota exceeded")
+
+// reservationsLua drops the reservations of the uploads in progress that
+// expired by ARGV[1] (ms) from the hash KEYS[3], of reserved bytes by upload
+// ID, and its index KEYS[4], of expiry times. It leaves in bytes, files and
+// daily the committed usage (KEYS[1], KEYS[2]), and in reserved and uploads
+// the reservations left.
+const reservationsLua = `
+for _, id in ipairs(redis.call('ZRANGEBYSCORE', KEYS[4], '-inf', ARGV[1])) do
+	redis.call('HDEL', KEYS[3], id)
+	redis.call('ZREM', KEYS[4], id)
+end
+local reserved = 0
+for _, n in ipairs(redis.call('HVALS', KEYS[3])) do
+	reserved = reserved + tonumber(n)
+end
+local uploads = redis.call('HLEN', KEYS[3])
+local bytes = tonumber(redis.call('HGET', KEYS[1], 'bytes') or '0')
+local files = tonumber(redis.call('HGET', KEYS[1], 'files') or '0')
+local daily = tonumber(redis.call('GET', KEYS[2]) or '0')
+`
+
+// storeReservationLua records the stored bytes reserved by the upload
+// ARGV[2], until ARGV[3] ms from now.
+const storeReservationLua = `
+local expiry = tonumber(ARGV[1]) + tonumber(ARGV[3])
+redis.call('HSET', KEYS[3], ARGV[2], stored)
+redis.call('ZADD', KEYS[4], expiry, ARGV[2])
+redis.call('PEXPIRE', KEYS[3], ARGV[3])
+redis.call('PEXPIRE', KEYS[4], ARGV[3])
+`
+
+// openScript starts the reservation of the upload ARGV[2], of ARGV[4] bytes
+// if known, unless with the other uploads in progress that exceeds the total
+// bytes (ARGV[5]), daily bytes (ARGV[6]) or files (ARGV[7]) limit, zero
+// meaning none. Nothing is reserved yet. It returns 0, or 1, 2 or 3 for the
+// limit that would be exceeded.
+var openScript = redis.NewScript(reservationsLua + `
+local n = tonumber(ARGV[4])
+if tonumber(ARGV[5]) > 0 and bytes + reserved + n > tonumber(ARGV[5]) then
+	return 1
+end
+if tonumber(ARGV[6]) > 0 and daily + reserved + n > tonumber(ARGV[6]) then
+	return 2
+end
+if tonumber(ARGV[7]) > 0 and files + uploads + 1 > tonumber(ARGV[7]) then
+	return 3
+end
+local stored = 0
+` + storeReservationLua + `
+return 0
+`)
+
+// reserveScript sets the reservation of the upload ARGV[2] to ARGV[4] bytes
+// and keeps it for ARGV[3] ms, unless growing it exceeds the total (ARGV[5])
+// or daily (ARGV[6]) limit. It returns 0, 1 or 2 for the limit that would be
+// exceeded, or 4 if the reservation expired.
+var reserveScript = redis.NewScript(reservationsLua + `
+local current = redis.call('HGET', KEYS[3], ARGV[2])
+if not current then
+	return 4
+end
+local extra = tonumber(ARGV[4]) - tonumber(current)
+if extra > 0 then
+	if tonumber(ARGV[5]) > 0 and bytes + reserved + extra > tonumber(ARGV[5]) then
+		return 1
+	end
+	if tonumber(ARGV[6]) > 0 and daily + reserved + extra > tonumber(ARGV[6]) then
+		return 2
+	end
+end
+local stored = ARGV[4]
+` + storeReservationLua + `
+return 0
+`)
+
+// commitScript drops the reservation of the upload ARGV[1] and counts its
+// stored file of ARGV[2] bytes in the usage hash KEYS[1] and the daily
+// counter KEYS[2], kept for ARGV[3] seconds.
+var commitScript = redis.NewScript(`
+redis.call('HDEL', KEYS[3], ARGV[1])
+redis.call('ZREM', KEYS[4], ARGV[1])
+redis.call('HINCRBY', KEYS[1], 'bytes', ARGV[2])
+redis.call('HINCRBY', KEYS[1], 'files', 1)
+redis.call('INCRBY', KEYS[2], ARGV[2])
+redis.call('EXPIRE', KEYS[2], ARGV[3])
+return 0
+`)
+
+var _ MediaQuotas = (*QuotaService)(nil)
+
+// MediaQuotas reads and resets the upload usage of domains, for admins.
+type MediaQuotas interface {
+	Usage(ctx context.Context, domainID int64) (*dto.MediaUsage, error)
+	ResetUsage(ctx context.Context, domainID int64) error
+}
+
+// QuotaService accounts for the uploads of every domain in Redis and holds
+// them to the configured limits. Uploads in progress reserve bytes as they
+// stream, in entries of their own that expire with them; only finalized
+// files are counted in the usage. Usage counts what was uploaded through the
+// gateway: files deleted from storage are not subtracted.
+type QuotaService struct {
+	logger   *slog.Logger
+	redis    *redis.Client
+	fallback config.QuotaLimits
+	domains  map[int64]config.QuotaLimits
+	// reservationTTL is how long the reservation of an upload is kept
+	// after its last write, as long as the upload session.
+	reservationTTL time.Duration
+	now            func() time.Time
+}
+
+func NewQuotaService(logger *slog.Logger, redisClient *redis.Client, cfg config.QuotaConfig, reservationTTL time.Duration) *QuotaService {
+	domains := make(map[int64]config.QuotaLimits, len(cfg.Domains))
+	for name, limits := range cfg.Domains {
+		// Keys are checked by config.validate.
+		domainID, _ := strconv.ParseInt(name, 10, 64)
+		domains[domainID] = limits
+	}
+
+	return &QuotaService{
+		logger:   logger,
+		redis:    redisClient,
+		fallback: cfg.QuotaLimits,
+		domains:  domains,
+
+		reservationTTL: reservationTTL,
+		now:            time.Now,
+	}
+}
+
+// Usage implements MediaQuotas.
+func (s *QuotaService) Usage(ctx context.Context, domainID int64) (*dto.MediaUsage, error) {
+	var (
+		usage *redis.MapStringStringCmd
+		daily *redis.StringCmd
+	)
+	if _, err := s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		usage = pipe.HGetAll(ctx, usageKey(domainID))
+		daily = pipe.Get(ctx, dailyUsageKey(domainID, s.day()))
+
+		return nil
+	}); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	limits := s.limits(domainID)
+	result := &dto.MediaUsage{
+		DomainID:      domainID,
+		MaxBytes:      limits.MaxBytes,
+		MaxFiles:      limits.MaxFiles,
+		MaxDailyBytes: limits.MaxDailyBytes,
+	}
+	result.Bytes, _ = strconv.ParseInt(usage.Val()["bytes"], 10, 64)
+	result.Files, _ = strconv.ParseInt(usage.Val()["files"], 10, 64)
+	result.DailyBytes, _ = strconv.ParseInt(daily.Val(), 10, 64)
+
+	return result, nil
+}
+
+// ResetUsage implements MediaQuotas. Uploads in progress keep the bytes they
+// reserved until they end.
+func (s *QuotaService) ResetUsage(ctx context.Context, domainID int64) error {
+	if err := s.redis.Del(ctx, usageKey(domainID), dailyUsageKey(domainID, s.day())).Err(); err != nil {
+		return err
+	}
+
+	s.logger.Info("media usage reset", slog.Int64("domain_id", domainID))
+
+	return nil
+}
+
+// open starts the accounting of the upload uploadID of the domain, of length
+// bytes, zero if unknown. It fails if the upload would exceed the limits of
+// the domain, counting the other uploads in progress. Uploads of domains
+// without limits reserve nothing.
+func (s *QuotaService) open(ctx context.Context, domainID int64, uploadID string, length int64) (*quotaLease, error) {
+	lease := &quotaLease{quotas: s, domainID: domainID, uploadID: uploadID, day: s.day()}
+
+	limits := s.limits(domainID)
+	if limits == (config.QuotaLimits{}) {
+		return lease, nil
+	}
+
+	exceeded, err := openScript.Run(ctx, s.redis, lease.keys(),
+		s.now().UnixMilli(), uploadID, s.reservationTTL.Milliseconds(), length,
+		limits.MaxBytes, limits.MaxDailyBytes, limits.MaxFiles,
+	).Int()
+	if err != nil {
+		return nil, err
+	}
+	if err := exceededErr(limits, exceeded); err != nil {
+		return nil, err
+	}
+	lease.tracked = true
+
+	return lease, nil
+}
+
+// exceededErr describes the limit a quota script found exceeded.
+func exceededErr(limits config.QuotaLimits, exceeded int) error {
+	switch exceeded {
+	case 1:
+		return fmt.Errorf("%w: %d bytes", ErrQuotaExceeded, limits.MaxBytes)
+	case 2:
+		return fmt.Errorf("%w: %d bytes a day", ErrQuotaExceeded, limits.MaxDailyBytes)
+	case 3:
+		return fmt.Errorf("%w: %d files", ErrQuotaExceeded, limits.MaxFiles)
+	case 4:
+		return ErrSessionDone
+	}
+
+	return nil
+}
+
+func (s *QuotaService) limits(domainID int64) config.QuotaLimits {
+	if limits, ok := s.domains[domainID]; ok {
+		return limits
+	}
+
+	return s.fallback
+}
+
+// day is the current UTC day, which daily usage is counted by.
+func (s *QuotaService) day() string {
+	return s.now().UTC().Format(time.DateOnly)
+}
+
+func usageKey(domainID int64) string {
+	return fmt.Sprintf("%s:media_usage:%d", model.ServiceName, domainID)
+}
+
+func dailyUsageKey(domainID int64, day string) string {
+	return fmt.Sprintf("%s:media_usage:%d:%s", model.ServiceName, domainID, day)
+}
+
+func reservationsKey(domainID int64) string {
+	return fmt.Sprintf("%s:media_reservations:%d", model.ServiceName, domainID)
+}
+
+func reservationExpiryKey(domainID int64) string {
+	return fmt.Sprintf("%s:media_reservations:%d:expiry", model.ServiceName, domainID)
+}
+
+// quotaLease is the bytes one upload has reserved. An upload counts towards
+// the day it started on.
+type quotaLease struct {
+	quotas   *QuotaService
+	domainID int64
+	uploadID string
+	day      string
+	// tracked is set if the upload has a reservation, which it has under
+	// limits only.
+	tracked bool
+
+	mu       sync.Mutex
+	reserved int64
+	done     bool
+}
+
+// reserve makes sure size bytes of the upload are reserved, reserving a
+// block more if needed.
+func (l *quotaLease) reserve(ctx context.Context, size int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done {
+		return ErrSessionDone
+	}
+	if !l.tracked || size <= l.reserved {
+		return nil
+	}
+
+	// A whole block may not fit under the limit where the rest of the file
+	// does.
+	target := max(size, l.reserved+quotaBlock)
+	err := l.reserveLocked(ctx, target)
+	if errors.Is(err, ErrQuotaExceeded) && size < target {
+		err = l.reserveLocked(ctx, size)
+	}
+
+	return err
+}
+
+// extend keeps the reservation for the reservation TTL from now.
+func (l *quotaLease) extend(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done || !l.tracked {
+		return nil
+	}
+
+	return l.reserveLocked(ctx, l.reserved)
+}
+
+// reserveLocked sets the reservation to n bytes.
+func (l *quotaLease) reserveLocked(ctx context.Context, n int64) error {
+	limits := l.quotas.limits(l.domainID)
+
+	exceeded, err := reserveScript.Run(ctx, l.quotas.redis, l.keys(),
+		l.quotas.now().UnixMilli(), l.uploadID, l.quotas.reservationTTL.Milliseconds(), n,
+		limits.MaxBytes, limits.MaxDailyBytes,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if err := exceededErr(limits, exceeded); err != nil {
+		return err
+	}
+	l.reserved = n
+
+	return nil
+}
+
+// commit counts the stored file of size bytes in place of the reservation.
+func (l *quotaLease) commit(ctx context.Context, size int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done {
+		return nil
+	}
+	l.done = true
+
+	return commitScript.Run(ctx, l.quotas.redis, l.keys(),
+		l.uploadID, size, int64(dailyUsageTTL/time.Second),
+	).Err()
+}
+
+// release drops the reservation of an upload that ended without a file. If
+// that fails, the reservation is dropped once it expires.
+func (l *quotaLease) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done {
+		return
+	}
+	l.done = true
+	if !l.tracked {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), quotaReleaseTimeout)
+	defer cancel()
+
+	if _, err := l.quotas.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, reservationsKey(l.domainID), l.uploadID)
+		pipe.ZRem(ctx, reservationExpiryKey(l.domainID), l.uploadID)
+
+		return nil
+	}); err != nil {
+		l.quotas.logger.Warn("quota: releasing an aborted upload failed",
+			slog.Int64("domain_id", l.domainID), slog.Int64("bytes", l.reserved), slog.String("error", err.Error()))
+	}
+}
+
+// keys are the keys of the quota scripts.
+func (l *quotaLease) keys() []string {
+	return []string{
+		usageKey(l.domainID),
+		dailyUsageKey(l.domainID, l.day),
+		reservationsKey(l.domainID),
+		reservationExpiryKey(l.domainID),
+	}
+}
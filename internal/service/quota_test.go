@@ -0,0 +1,128 @@
+package service
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+)
+
+func newTestQuotas(t *testing.T, cfg config.QuotaConfig) *QuotaService {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	quotas := NewQuotaService(slog.New(slog.DiscardHandler), client, cfg, time.Minute)
+	quotas.now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	return quotas
+}
+
+func TestQuotaService(t *testing.T) {
+	ctx := t.Context()
+	quotas := newTestQuotas(t, config.QuotaConfig{
+		QuotaLimits: config.QuotaLimits{MaxBytes: 3 << 20, MaxFiles: 2},
+		Domains: map[string]config.QuotaLimits{
+			"2": {MaxDailyBytes: 100},
+		},
+	})
+	now := quotas.now()
+	quotas.now = func() time.Time { return now }
+
+	// A file of 1.5 MiB reserves blocks as it streams, and counts for its
+	// size once stored.
+	lease, err := quotas.open(ctx, 1, "a", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int64{4096, 1 << 20, 3 << 19} {
+		if err := lease.reserve(ctx, size); err != nil {
+			t.Fatalf("reserve %d: %v", size, err)
+		}
+	}
+	if err := lease.commit(ctx, 3<<19); err != nil {
+		t.Fatal(err)
+	}
+	usage, err := quotas.Usage(ctx, 1)
+	if err != nil || usage.Bytes != 3<<19 || usage.Files != 1 || usage.DailyBytes != 3<<19 || usage.MaxFiles != 2 {
+		t.Fatalf("usage = %+v, %v", usage, err)
+	}
+
+	if _, err := quotas.open(ctx, 1, "b", 2<<20); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("open of a file over the quota = %v", err)
+	}
+	lease, err = quotas.open(ctx, 1, "b", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The upload in progress takes the last file of the quota.
+	if _, err := quotas.open(ctx, 1, "c", 0); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("open over the file count with an upload in progress = %v", err)
+	}
+	if err := lease.reserve(ctx, 3<<19+1); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("reserve over the quota = %v", err)
+	}
+	if err := lease.reserve(ctx, 1000); err != nil || lease.reserved != quotaBlock {
+		t.Errorf("reserve under the quota = %v, reserved %d", err, lease.reserved)
+	}
+	// The last 512 KiB of the quota are less than a block.
+	if err := lease.reserve(ctx, 3<<19); err != nil || lease.reserved != 3<<19 {
+		t.Errorf("reserve of the rest of the quota = %v, reserved %d", err, lease.reserved)
+	}
+	if usage, _ = quotas.Usage(ctx, 1); usage.Bytes != 3<<19 {
+		t.Errorf("usage with an upload in progress = %+v", usage)
+	}
+
+	// An upload abandoned without a release gives back its reservation
+	// once it expires.
+	now = now.Add(2 * time.Minute)
+	abandoned := lease
+	if lease, err = quotas.open(ctx, 1, "c", 3<<19); err != nil {
+		t.Fatalf("open after an abandoned upload expired = %v", err)
+	}
+	if err := abandoned.reserve(ctx, 2<<20); !errors.Is(err, ErrSessionDone) {
+		t.Errorf("reserve of an expired upload = %v", err)
+	}
+
+	// An aborted upload gives back its reservation.
+	lease.release()
+	if lease, err = quotas.open(ctx, 1, "d", 0); err != nil {
+		t.Fatalf("open after an aborted upload = %v", err)
+	}
+	_ = lease.reserve(ctx, 10)
+	_ = lease.commit(ctx, 10)
+	if _, err := quotas.open(ctx, 1, "e", 0); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("open over the file count = %v", err)
+	}
+
+	if err := quotas.ResetUsage(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := quotas.open(ctx, 1, "e", 0); err != nil {
+		t.Errorf("open after a reset = %v", err)
+	}
+
+	// Domain 2 has a daily limit only.
+	lease, _ = quotas.open(ctx, 2, "f", 0)
+	if err := lease.reserve(ctx, 60); err != nil {
+		t.Fatal(err)
+	}
+	_ = lease.commit(ctx, 60)
+	lease, _ = quotas.open(ctx, 2, "g", 0)
+	if err := lease.reserve(ctx, 60); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("reserve over the daily quota = %v", err)
+	}
+	lease.release()
+	now = time.Date(2026, 3, 2, 0, 0, 1, 0, time.UTC)
+	lease, _ = quotas.open(ctx, 2, "h", 0)
+	if err := lease.reserve(ctx, 60); err != nil {
+		t.Errorf("reserve on the next day = %v", err)
+	}
+}
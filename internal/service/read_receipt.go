@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	threadv1 "github.com/webitel/im-gateway-service/gen/go/thread/v1"
+	"github.com/webitel/im-gateway-service/infra/auth"
+	"github.com/webitel/im-gateway-service/internal/model"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// readPositionsTTL is how long the read positions of a thread outlive its
+// last read. Positions of threads idle for longer are forgotten.
+const readPositionsTTL = 30 * 24 * time.Hour
+
+// storeReadPositionScript stores the read position ARGV[2] of the contact
+// ARGV[1] in the hash KEYS[1] unless the contact has already read a message
+// created after ARGV[3], so that a late or replayed read never moves the
+// position backwards. It returns 1 when the position was stored.
+var storeReadPositionScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if current then
+	local date = cjson.decode(current).messageDate
+	if date and date > tonumber(ARGV[3]) then
+		return 0
+	end
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return 1
+`)
+
+var _ ReadReceipter = (*ReadReceiptService)(nil)
+
+// ReadReceipter tracks how far every thread member has read. The other
+// members learn about a read from the "message.read" update of im-thread.
+type ReadReceipter interface {
+	// MarkRead moves the read position of the identity found in ctx forward
+	// to the message, if it was created after the one read before.
+	MarkRead(ctx context.Context, in *dto.ReadMessageRequest) error
+	ListReadPositions(ctx context.Context, in *dto.ListReadPositionsRequest) (*dto.ListReadPositionsResponse, error)
+}
+
+// messageSearcher finds the messages read receipts refer to.
+type messageSearcher interface {
+	Search(ctx context.Context, searchQuery *dto.SearchMessageHistoryRequest) (*dto.SearchMessageHistoryResponse, []*threadv1.ThreadMember, error)
+}
+
+type ReadReceiptService struct {
+	logger       *slog.Logger
+	threadClient threadGetter
+	history      messageSearcher
+	redis        *redis.Client
+}
+
+func NewReadReceiptService(
+	logger *slog.Logger,
+	threadClient threadGetter,
+	history messageSearcher,
+	redisClient *redis.Client,
+) *ReadReceiptService {
+	return &ReadReceiptService{
+		logger:       logger,
+		threadClient: threadClient,
+		history:      history,
+		redis:        redisClient,
+	}
+}
+
+// MarkRead implements ReadReceipter.
+func (s *ReadReceiptService) MarkRead(ctx context.Context, in *dto.ReadMessageRequest) error {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return auth.IdentityNotFoundErr
+	}
+
+	if _, err := s.threadMembers(ctx, identity, in.ThreadID); err != nil {
+		return err
+	}
+
+	messageDate, err := s.messageDate(ctx, identity, in)
+	if err != nil {
+		return err
+	}
+
+	position := &dto.ReadPosition{
+		ContactID:   identity.GetContactID(),
+		MessageID:   in.MessageID,
+		MessageDate: messageDate,
+		ReadAt:      time.Now().UnixMilli(),
+	}
+	data, err := json.Marshal(position)
+	if err != nil {
+		return err
+	}
+
+	err = storeReadPositionScript.Run(ctx, s.redis,
+		[]string{readPositionsKey(identity.GetDomainID(), in.ThreadID)},
+		position.ContactID, data, position.MessageDate, readPositionsTTL.Milliseconds(),
+	).Err()
+	if err != nil {
+		return errors.Internal("store read position",
+			errors.WithID("service.read_receipt.store"),
+			errors.WithCause(err),
+		)
+	}
+
+	return nil
+}
+
+// messageDate returns the creation time of the read message.
+func (s *ReadReceiptService) messageDate(ctx context.Context, identity auth.Identifier, in *dto.ReadMessageRequest) (int64, error) {
+	resp, _, err := s.history.Search(ctx, &dto.SearchMessageHistoryRequest{
+		IDs:       []string{in.MessageID},
+		ThreadIDs: []string{in.ThreadID},
+		DomainID:  int32(identity.GetDomainID()),
+		Size:      1,
+		CallerID:  identity.GetContactID(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Messages) == 0 {
+		return 0, errors.NotFound("read message not found",
+			errors.WithID("service.read_receipt.message"),
+		)
+	}
+
+	return resp.Messages[0].CreatedAt, nil
+}
+
+// ListReadPositions implements ReadReceipter. Members that have not read
+// anything yet are left out.
+func (s *ReadReceiptService) ListReadPositions(ctx context.Context, in *dto.ListReadPositionsRequest) (*dto.ListReadPositionsResponse, error) {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return nil, auth.IdentityNotFoundErr
+	}
+
+	members, err := s.threadMembers(ctx, identity, in.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := s.redis.HGetAll(ctx, readPositionsKey(identity.GetDomainID(), in.ThreadID)).Result()
+	if err != nil {
+		return nil, errors.Internal("load read positions",
+			errors.WithID("service.read_receipt.load"),
+			errors.WithCause(err),
+		)
+	}
+
+	items := make([]*dto.ReadPosition, 0, len(positions))
+	for _, contactID := range members {
+		data, ok := positions[contactID]
+		if !ok {
+			continue
+		}
+
+		if position := s.decodePosition(in.ThreadID, contactID, data); position != nil {
+			items = append(items, position)
+		}
+	}
+
+	return &dto.ListReadPositionsResponse{Items: items}, nil
+}
+
+// decodePosition decodes a stored read position, or returns nil when it is
+// malformed.
+func (s *ReadReceiptService) decodePosition(threadID, contactID, data string) *dto.ReadPosition {
+	var position dto.ReadPosition
+	if err := json.Unmarshal([]byte(data), &position); err != nil {
+		s.logger.Warn("skipping malformed read position",
+			slog.String("thread_id", threadID),
+			slog.String("contact_id", contactID),
+			slog.String("error", err.Error()),
+		)
+
+		return nil
+	}
+
+	return &position
+}
+
+// threadMembers returns the contact IDs of the thread members, failing with
+// ErrNotThreadMember unless the identity is one of them.
+func (s *ReadReceiptService) threadMembers(ctx context.Context, identity auth.Identifier, threadID string) ([]string, error) {
+	thread, err := s.threadClient.Get(ctx, &threadv1.GetThreadRequest{
+		Id:       threadID,
+		DomainId: int32(identity.GetDomainID()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]string, 0, len(thread.GetMembers()))
+	for _, member := range thread.GetMembers() {
+		members = append(members, member.GetContactId())
+	}
+	if !slices.Contains(members, identity.GetContactID()) {
+		return nil, ErrNotThreadMember
+	}
+
+	return members, nil
+}
+
+func readPositionsKey(domainID int64, threadID string) string {
+	return fmt.Sprintf("%s:read_positions:%d:%s", model.ServiceName, domainID, threadID)
+}
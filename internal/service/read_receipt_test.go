@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	threadv1 "github.com/webitel/im-gateway-service/gen/go/thread/v1"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// fakeHistory holds messages by ID with their creation times.
+type fakeHistory map[string]int64
+
+func (f fakeHistory) Search(_ context.Context, req *dto.SearchMessageHistoryRequest) (*dto.SearchMessageHistoryResponse, []*threadv1.ThreadMember, error) {
+	resp := &dto.SearchMessageHistoryResponse{}
+	for _, id := range req.IDs {
+		if createdAt, ok := f[id]; ok {
+			resp.Messages = append(resp.Messages, &dto.HistoryMessage{ID: id, CreatedAt: createdAt})
+		}
+	}
+
+	return resp, nil, nil
+}
+
+func TestReadReceiptService_MarkReadNeverMovesBack(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	s := NewReadReceiptService(slog.New(slog.DiscardHandler), &fakeThreads{members: []string{"alice", "bob"}},
+		fakeHistory{"m1": 100, "m2": 200}, client)
+	ctx := identityContext(t, 1, "alice")
+
+	for _, messageID := range []string{"m2", "m1"} {
+		if err := s.MarkRead(ctx, &dto.ReadMessageRequest{ThreadID: "t1", MessageID: messageID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := s.ListReadPositions(ctx, &dto.ListReadPositionsRequest{ThreadID: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].ContactID != "alice" || resp.Items[0].MessageID != "m2" || resp.Items[0].MessageDate != 200 {
+		t.Errorf("read positions = %+v, want m2 kept after the late read of m1", resp.Items)
+	}
+
+	if err := s.MarkRead(identityContext(t, 1, "mallory"), &dto.ReadMessageRequest{ThreadID: "t1", MessageID: "m2"}); err == nil {
+		t.Error("an outsider moved its read position")
+	}
+}
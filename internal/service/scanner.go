@@ -0,0 +1,175 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+)
+
+var (
+	ErrFileInfected = errors.New("upload: malware detected")
+	ErrScanFailed   = errors.New("upload: file could not be scanned")
+)
+
+// maxClamdReply bounds the reply clamd sends to a scan.
+const maxClamdReply = 4 << 10
+
+// Scanner checks uploaded files for malware before they are stored.
+type Scanner interface {
+	// NewScan starts the scan of one file. Its content is written to the
+	// scan as it is uploaded.
+	NewScan(ctx context.Context) (FileScan, error)
+}
+
+// FileScan is the scan of one file.
+type FileScan interface {
+	io.Writer
+	// Verdict ends the content of the file and waits for the verdict: nil
+	// for a clean file, ErrFileInfected, or ErrScanFailed.
+	Verdict(ctx context.Context) error
+	// Abort drops the scan. It may be called concurrently with Write, and
+	// after Verdict.
+	Abort()
+}
+
+var _ Scanner = (*ClamdScanner)(nil)
+
+// ClamdScanner scans files with clamd, each through an INSTREAM command on a
+// connection of its own. The connection stays open for the whole upload:
+// clamd must allow uploads to idle for as long as its ReadTimeout, and accept
+// files up to its StreamMaxLength.
+type ClamdScanner struct {
+	logger  *slog.Logger
+	network string
+	addr    string
+	timeout time.Duration
+}
+
+// NewClamdScanner returns a scanner of the clamd listening at cfg.ClamdAddr:
+// host:port, or the path of a unix socket.
+func NewClamdScanner(logger *slog.Logger, cfg config.ScanConfig) *ClamdScanner {
+	network := "tcp"
+	if strings.HasPrefix(cfg.ClamdAddr, "/") {
+		network = "unix"
+	}
+
+	return &ClamdScanner{
+		logger:  logger,
+		network: network,
+		addr:    cfg.ClamdAddr,
+		timeout: cfg.Timeout,
+	}
+}
+
+// NewScan implements Scanner.
+func (s *ClamdScanner) NewScan(ctx context.Context) (FileScan, error) {
+	dialer := net.Dialer{Timeout: s.timeout}
+
+	conn, err := dialer.DialContext(ctx, s.network, s.addr)
+	if err != nil {
+		s.logger.Warn("clamd: dial failed", slog.String("addr", s.addr), slog.String("error", err.Error()))
+
+		return nil, ErrScanFailed
+	}
+
+	scan := &clamdScan{logger: s.logger, conn: conn, timeout: s.timeout}
+	if err := scan.send([]byte("zINSTREAM\x00")); err != nil {
+		scan.Abort()
+
+		return nil, err
+	}
+
+	return scan, nil
+}
+
+type clamdScan struct {
+	logger  *slog.Logger
+	conn    net.Conn
+	timeout time.Duration
+	// header is the length prefix of the chunk being written.
+	header [4]byte
+}
+
+// Write sends p as one INSTREAM chunk.
+func (c *clamdScan) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	binary.BigEndian.PutUint32(c.header[:], uint32(len(p)))
+	if err := c.send(c.header[:], p); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Verdict implements FileScan. clamd answers "stream: OK", "stream: NAME
+// FOUND", or an error such as "INSTREAM size limit exceeded. ERROR".
+func (c *clamdScan) Verdict(ctx context.Context) error {
+	defer c.Abort()
+
+	// A zero-length chunk ends the stream.
+	if err := c.send(make([]byte, 4)); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = c.conn.SetReadDeadline(deadline)
+
+	stop := context.AfterFunc(ctx, c.Abort)
+	defer stop()
+
+	reply, err := bufio.NewReader(io.LimitReader(c.conn, maxClamdReply)).ReadBytes(0)
+	if err != nil {
+		c.logger.Warn("clamd: reading the verdict failed", slog.String("error", err.Error()))
+
+		return ErrScanFailed
+	}
+	verdict := string(bytes.TrimSuffix(reply, []byte{0}))
+	verdict = strings.TrimPrefix(verdict, "stream: ")
+
+	switch {
+	case verdict == "OK":
+		return nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return fmt.Errorf("%w: %s", ErrFileInfected, strings.TrimSuffix(verdict, " FOUND"))
+	default:
+		c.logger.Warn("clamd: scan failed", slog.String("reply", verdict))
+
+		return ErrScanFailed
+	}
+}
+
+// Abort implements FileScan.
+func (c *clamdScan) Abort() {
+	_ = c.conn.Close()
+}
+
+func (c *clamdScan) send(parts ...[]byte) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+
+	buffers := net.Buffers(parts)
+	if _, err := buffers.WriteTo(c.conn); err != nil {
+		// clamd drops the connection once a file exceeds StreamMaxLength.
+		c.logger.Warn("clamd: sending the file failed", slog.String("error", err.Error()))
+
+		return ErrScanFailed
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+)
+
+// fakeClamd serves INSTREAM commands: files containing "EICAR" are infected,
+// files over maxSize get the size limit error.
+func fakeClamd(t *testing.T, maxSize int) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, maxSize)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func serveClamd(conn net.Conn, maxSize int) {
+	defer conn.Close()
+
+	command := make([]byte, len("zINSTREAM\x00"))
+	if _, err := io.ReadFull(conn, command); err != nil || string(command) != "zINSTREAM\x00" {
+		_, _ = conn.Write([]byte("UNKNOWN COMMAND\x00"))
+
+		return
+	}
+
+	var file bytes.Buffer
+	for {
+		var size uint32
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		if _, err := io.CopyN(&file, conn, int64(size)); err != nil {
+			return
+		}
+		if file.Len() > maxSize {
+			_, _ = conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+
+			return
+		}
+	}
+
+	reply := "stream: OK\x00"
+	if bytes.Contains(file.Bytes(), []byte("EICAR")) {
+		reply = "stream: Eicar-Test-Signature FOUND\x00"
+	}
+	_, _ = conn.Write([]byte(reply))
+}
+
+func TestClamdScanner(t *testing.T) {
+	scanner := NewClamdScanner(slog.New(slog.DiscardHandler), config.ScanConfig{
+		ClamdAddr: fakeClamd(t, 64),
+		Timeout:   time.Second,
+	})
+
+	scanFile := func(chunks ...string) error {
+		scan, err := scanner.NewScan(t.Context())
+		if err != nil {
+			return err
+		}
+		for _, chunk := range chunks {
+			if _, err := scan.Write([]byte(chunk)); err != nil {
+				scan.Abort()
+
+				return err
+			}
+		}
+
+		return scan.Verdict(t.Context())
+	}
+
+	if err := scanFile("hello ", "there"); err != nil {
+		t.Errorf("clean file = %v", err)
+	}
+
+	err := scanFile("X5O!P%@AP[4\\PZX54(P^)7CC)7}$", "EICAR-STANDARD-ANTIVIRUS-TEST-FILE!")
+	if !errors.Is(err, ErrFileInfected) || !strings.Contains(err.Error(), "Eicar-Test-Signature") {
+		t.Errorf("infected file = %v, want ErrFileInfected with the signature", err)
+	}
+
+	if err := scanFile(strings.Repeat("a", 50), strings.Repeat("b", 50)); !errors.Is(err, ErrScanFailed) {
+		t.Errorf("file over the clamd limit = %v, want ErrScanFailed", err)
+	}
+}
+
+func TestClamdScanner_Unavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	scanner := NewClamdScanner(slog.New(slog.DiscardHandler), config.ScanConfig{ClamdAddr: addr, Timeout: time.Second})
+	if _, err := scanner.NewScan(t.Context()); !errors.Is(err, ErrScanFailed) {
+		t.Errorf("scan without clamd = %v, want ErrScanFailed", err)
+	}
+}
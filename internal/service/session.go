@@ -12,15 +12,15 @@ import (
 	"github.com/webitel/im-gateway-service/internal/service/dto"
 )
 
-const uploadIdleTimeout = 3 * time.Minute
-
 // uploadSession holds upload state across the POST→PUT lifecycle. The gRPC
 // stream to storage is attached lazily on the first AppendContent call, after
-// the mime type has been sniffed from the body. A heartbeat goroutine guards
-// against idle streams once attached.
+// the mime type has been sniffed from the body. The session, stream included,
+// is discarded ttl after its last write, whether a stream is attached or not.
 type uploadSession struct {
 	// Set at creation, never changes.
 	name string
+	// ttl is how long the session is kept after its last write.
+	ttl time.Duration
 
 	mu        sync.Mutex
 	writeLock sync.Mutex
@@ -35,39 +35,66 @@ type uploadSession struct {
 	// authoritative uploaded size after the local stream goes inactive.
 	storageUploadID string
 
-	inactive      bool
-	lastOffset    int64
-	terminateOnce sync.Once
+	// scan receives the content sent to storage, nil if uploads are not
+	// scanned. Attached with the stream.
+	scan FileScan
+
+	// lease accounts for the upload in the quota of its domain. Set at
+	// creation.
+	lease *quotaLease
+
+	// maxSize is the size cap of the sniffed type of the file, zero if none.
+	// Set with the stream, under writeLock.
+	maxSize int64
 
-	// aliveChan receives a signal on each uploaded chunk to reset the idle timer.
-	aliveChan chan struct{}
+	// digest hashes the content sent to storage, and checksum is the one
+	// the client expects, nil if none. Both are used under writeLock.
+	digest   *uploadDigest
+	checksum *dto.Checksum
+
+	// expiresAt is when the session is discarded, complete or not. Every
+	// write moves it ttl ahead.
+	expiresAt time.Time
+
+	inactive   bool
+	lastOffset int64
+	// length is the declared size of the file, zero until it is known: at
+	// creation or by a later write.
+	length        int64
+	terminateOnce sync.Once
 
 	// terminateChan is closed when the session is terminated, signaling all
-	// waiting goroutines (heartbeat, cleanup) to exit.
+	// waiting goroutines (expiry, cleanup) to exit.
 	terminateChan chan struct{}
 }
 
-func newUploadSession(name string) *uploadSession {
+func newUploadSession(name string, length int64, ttl time.Duration) *uploadSession {
 	return &uploadSession{
 		name:          name,
-		aliveChan:     make(chan struct{}, 1),
+		ttl:           ttl,
+		length:        length,
+		digest:        newUploadDigest(),
+		expiresAt:     time.Now().Add(ttl),
 		terminateChan: make(chan struct{}),
 	}
 }
 
-// attachStream binds the freshly-opened storage stream to the session and
-// starts its heartbeat. It returns false if the session was already terminated
-// (idle timeout or max TTL) while the stream was being opened: in that case
+// attachStream binds the freshly-opened storage stream, and the scan of the
+// file if any, to the session. It returns false if the session was already terminated
+// (expired or aborted) while the stream was being opened: in that case
 // terminate() ran with no stream attached and will not run again (sync.Once),
 // so the stream is released here to avoid leaking the gRPC stream and
 // connection, and the caller must abort the upload.
-func (s *uploadSession) attachStream(stream storagev1.FileService_SafeUploadFileClient, cancelFn context.CancelFunc, releaseFn func(), storageUploadID string) bool {
+func (s *uploadSession) attachStream(stream storagev1.FileService_SafeUploadFileClient, cancelFn context.CancelFunc, releaseFn func(), storageUploadID string, scan FileScan) bool {
 	s.mu.Lock()
 	if s.inactive || s.stream != nil {
 		s.mu.Unlock()
 
 		cancelFn()
 		releaseFn()
+		if scan != nil {
+			scan.Abort()
+		}
 
 		return false
 	}
@@ -76,10 +103,9 @@ func (s *uploadSession) attachStream(stream storagev1.FileService_SafeUploadFile
 	s.cancelFn = cancelFn
 	s.releaseFn = releaseFn
 	s.storageUploadID = storageUploadID
+	s.scan = scan
 	s.mu.Unlock()
 
-	go s.heartbeat()
-
 	return true
 }
 
@@ -98,35 +124,65 @@ func (s *uploadSession) offset() int64 {
 	return s.lastOffset
 }
 
-// addOffset advances the forwarded-bytes counter by n.
-func (s *uploadSession) addOffset(n int) {
+// declaredLength returns the declared size of the file, zero if not known yet.
+func (s *uploadSession) declaredLength() int64 {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.lastOffset += int64(n)
+	return s.length
 }
 
-// storageID returns the upload ID assigned by the storage service, or "" if no
-// stream has been attached yet.
-func (s *uploadSession) storageID() string {
+// declareLength records the size of the file. A size cannot be changed once
+// declared, nor be less than what was already uploaded.
+func (s *uploadSession) declareLength(n int64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return s.storageUploadID
+	switch {
+	case s.length == n:
+		return nil
+	case s.length != 0:
+		return ErrUploadLengthMismatch
+	case n < s.lastOffset:
+		return ErrUploadLengthExceeded
+	}
+	s.length = n
+
+	return nil
+}
+
+// expiry returns when the session is discarded, unless written to before.
+func (s *uploadSession) expiry() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.expiresAt
 }
 
-// heartbeat terminates the session after uploadIdleTimeout of inactivity.
-func (s *uploadSession) heartbeat() {
-	ticker := time.NewTicker(uploadIdleTimeout)
-	defer ticker.Stop()
+// extend keeps the session for ttl from now.
+func (s *uploadSession) extend() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expiresAt = time.Now().Add(s.ttl)
+}
+
+// expire terminates the session once it expires, and returns when the
+// session is terminated.
+func (s *uploadSession) expire() {
+	timer := time.NewTimer(time.Until(s.expiry()))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-s.terminateChan:
 			return
-		case <-s.aliveChan:
-			ticker.Reset(uploadIdleTimeout)
-		case <-ticker.C:
+		case <-timer.C:
+			if left := time.Until(s.expiry()); left > 0 {
+				timer.Reset(left)
+
+				continue
+			}
 			s.terminate()
 
 			return
@@ -134,8 +190,25 @@ func (s *uploadSession) heartbeat() {
 	}
 }
 
-// terminate cancels the underlying gRPC stream (if attached) and marks the
-// session inactive. Safe to call multiple times and from multiple goroutines,
+// addOffset advances the forwarded-bytes counter by n.
+func (s *uploadSession) addOffset(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastOffset += int64(n)
+}
+
+// storageID returns the upload ID assigned by the storage service, or "" if no
+// stream has been attached yet.
+func (s *uploadSession) storageID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.storageUploadID
+}
+
+// terminate cancels the underlying gRPC stream (if attached), drops the scan
+// of the file and marks the session inactive. Safe to call multiple times and from multiple goroutines,
 // and safe to call before a stream is attached.
 func (s *uploadSession) terminate() {
 	s.terminateOnce.Do(func() {
@@ -145,13 +218,23 @@ func (s *uploadSession) terminate() {
 		}
 
 		release := s.releaseFn
+		scan := s.scan
 		s.inactive = true
 		close(s.terminateChan)
 		s.mu.Unlock()
 
+		// Gives back the reservation of an upload that was not finalized,
+		// before the session is known to be over.
+		if s.lease != nil {
+			s.lease.release()
+		}
+
 		if release != nil {
 			release()
 		}
+		if scan != nil {
+			scan.Abort()
+		}
 	})
 }
 
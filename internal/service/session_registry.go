@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/internal/model"
+)
+
+// sessionRegistryTimeout bounds the removal of a session from the registry,
+// which happens outside of any request.
+const sessionRegistryTimeout = 5 * time.Second
+
+// unregisterScript deletes KEYS[1] only if it still names the replica
+// ARGV[1].
+var unregisterScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// refreshScript sets the TTL of KEYS[1] to ARGV[2] milliseconds only if it
+// still names this replica (ARGV[1]).
+var refreshScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// SessionRegistry records which replica holds each upload session. Upload
+// sessions live in the memory of the replica that created them, along with
+// their storage stream: requests for a session that land on another replica
+// are forwarded to it.
+type SessionRegistry interface {
+	// Register records this replica as the holder of the upload for ttl.
+	Register(ctx context.Context, uploadID string, ttl time.Duration) error
+	// Refresh keeps the upload recorded for ttl from now, if this replica
+	// still holds it.
+	Refresh(ctx context.Context, uploadID string, ttl time.Duration) error
+	// Unregister forgets the upload, if this replica still holds it.
+	Unregister(uploadID string)
+	// Evict forgets the upload of a replica found gone, if owner still
+	// holds it.
+	Evict(ctx context.Context, uploadID, owner string) error
+	// Locate returns the base URL of the replica holding the upload, "" if
+	// it is this one or if the upload is not known.
+	Locate(ctx context.Context, uploadID string) (string, error)
+}
+
+var _ SessionRegistry = LocalSessions{}
+
+// LocalSessions is the registry of a single replica, which holds every
+// session.
+type LocalSessions struct{}
+
+// Register implements SessionRegistry.
+func (LocalSessions) Register(context.Context, string, time.Duration) error { return nil }
+
+// Refresh implements SessionRegistry.
+func (LocalSessions) Refresh(context.Context, string, time.Duration) error { return nil }
+
+// Unregister implements SessionRegistry.
+func (LocalSessions) Unregister(string) {}
+
+// Evict implements SessionRegistry.
+func (LocalSessions) Evict(context.Context, string, string) error { return nil }
+
+// Locate implements SessionRegistry.
+func (LocalSessions) Locate(context.Context, string) (string, error) { return "", nil }
+
+var _ SessionRegistry = (*RedisSessionRegistry)(nil)
+
+// RedisSessionRegistry shares the holders of upload sessions between replicas
+// in Redis. Each replica is known by the base URL other replicas reach its
+// HTTP server at.
+type RedisSessionRegistry struct {
+	logger *slog.Logger
+	redis  *redis.Client
+	self   string
+}
+
+func NewRedisSessionRegistry(logger *slog.Logger, redisClient *redis.Client, advertiseURL string) *RedisSessionRegistry {
+	return &RedisSessionRegistry{logger: logger, redis: redisClient, self: advertiseURL}
+}
+
+// Register implements SessionRegistry.
+func (r *RedisSessionRegistry) Register(ctx context.Context, uploadID string, ttl time.Duration) error {
+	return r.redis.Set(ctx, uploadSessionKey(uploadID), r.self, ttl).Err()
+}
+
+// Refresh implements SessionRegistry.
+func (r *RedisSessionRegistry) Refresh(ctx context.Context, uploadID string, ttl time.Duration) error {
+	return refreshScript.Run(ctx, r.redis, []string{uploadSessionKey(uploadID)}, r.self, ttl.Milliseconds()).Err()
+}
+
+// Unregister implements SessionRegistry.
+func (r *RedisSessionRegistry) Unregister(uploadID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sessionRegistryTimeout)
+	defer cancel()
+
+	if err := unregisterScript.Run(ctx, r.redis, []string{uploadSessionKey(uploadID)}, r.self).Err(); err != nil {
+		r.logger.Warn("session registry: unregistering an upload failed",
+			slog.String("upload_id", uploadID), slog.String("error", err.Error()))
+	}
+}
+
+// Evict implements SessionRegistry.
+func (r *RedisSessionRegistry) Evict(ctx context.Context, uploadID, owner string) error {
+	return unregisterScript.Run(ctx, r.redis, []string{uploadSessionKey(uploadID)}, owner).Err()
+}
+
+// Locate implements SessionRegistry.
+func (r *RedisSessionRegistry) Locate(ctx context.Context, uploadID string) (string, error) {
+	owner, err := r.redis.Get(ctx, uploadSessionKey(uploadID)).Result()
+	switch {
+	case errors.Is(err, redis.Nil), owner == r.self:
+		return "", nil
+	case err != nil:
+		return "", err
+	}
+
+	return owner, nil
+}
+
+func uploadSessionKey(uploadID string) string {
+	return fmt.Sprintf("%s:upload_session:%s", model.ServiceName, uploadID)
+}
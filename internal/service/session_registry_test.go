@@ -0,0 +1,80 @@
+package service
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisSessionRegistry(t *testing.T) {
+	ctx := t.Context()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	first := NewRedisSessionRegistry(slog.New(slog.DiscardHandler), client, "http://10.0.0.1:8081")
+	second := NewRedisSessionRegistry(slog.New(slog.DiscardHandler), client, "http://10.0.0.2:8081")
+
+	if err := first.Register(ctx, "u1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if owner, err := first.Locate(ctx, "u1"); err != nil || owner != "" {
+		t.Errorf("locate on the owner = %q, %v, want it served here", owner, err)
+	}
+	if owner, err := second.Locate(ctx, "u1"); err != nil || owner != "http://10.0.0.1:8081" {
+		t.Errorf("locate on another replica = %q, %v", owner, err)
+	}
+	if owner, err := second.Locate(ctx, "u2"); err != nil || owner != "" {
+		t.Errorf("locate of an unknown upload = %q, %v", owner, err)
+	}
+
+	// Only the owner removes its sessions.
+	second.Unregister("u1")
+	if owner, _ := second.Locate(ctx, "u1"); owner == "" {
+		t.Error("another replica unregistered the session")
+	}
+	first.Unregister("u1")
+	if owner, _ := second.Locate(ctx, "u1"); owner != "" {
+		t.Errorf("locate after unregister = %q", owner)
+	}
+
+	// A replica found gone loses its sessions, but not those moved since.
+	_ = first.Register(ctx, "u5", time.Minute)
+	if err := first.Evict(ctx, "u5", "http://10.0.0.2:8081"); err != nil {
+		t.Fatal(err)
+	}
+	if owner, _ := second.Locate(ctx, "u5"); owner == "" {
+		t.Error("the session of another replica was evicted")
+	}
+	if err := second.Evict(ctx, "u5", "http://10.0.0.1:8081"); err != nil {
+		t.Fatal(err)
+	}
+	if owner, _ := second.Locate(ctx, "u5"); owner != "" {
+		t.Errorf("locate after evict = %q", owner)
+	}
+
+	// Only the owner extends its sessions.
+	_ = first.Register(ctx, "u4", time.Minute)
+	if err := second.Refresh(ctx, "u4", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL(uploadSessionKey("u4")); ttl != time.Minute {
+		t.Errorf("TTL after a refresh by another replica = %v", ttl)
+	}
+	if err := first.Refresh(ctx, "u4", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL(uploadSessionKey("u4")); ttl != time.Hour {
+		t.Errorf("TTL after a refresh by the owner = %v", ttl)
+	}
+
+	// Sessions are forgotten once they expire.
+	_ = first.Register(ctx, "u3", time.Minute)
+	server.FastForward(2 * time.Minute)
+	if owner, _ := second.Locate(ctx, "u3"); owner != "" {
+		t.Errorf("locate of an expired session = %q", owner)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/webitel/im-gateway-service/infra/auth"
 	imcontact "github.com/webitel/im-gateway-service/infra/client/im-contact"
 	imthread "github.com/webitel/im-gateway-service/infra/client/im-thread"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
 )
 
 const (
@@ -42,6 +43,7 @@ type thread struct {
 
 	threadClient  *imthread.ThreadClient
 	contactClient *imcontact.Client
+	events        EventEmitter
 }
 
 func (t *thread) prepareCreateDirectConfig(ctx context.Context, req *gtwthread.ThreadManagementCreateRequest, session auth.Identifier) (*threadv1.ThreadManagementCreateRequest, error) {
@@ -124,6 +126,10 @@ func (t *thread) Create(ctx context.Context, req *gtwthread.ThreadManagementCrea
 
 	response.Subject = threadSubject
 
+	t.events.Emit(ctx, newDomainEvent(dto.EventThreadCreated, session, response.GetId(),
+		"thread_id", response.GetId(),
+	))
+
 	return &gtwthread.ThreadManagementCreateResponse{Thread: response}, nil
 }
 
@@ -162,6 +168,12 @@ func (t *thread) AddMember(ctx context.Context, req *gtwthread.AddMemberRequest)
 		return nil, err
 	}
 
+	t.events.Emit(ctx, newDomainEvent(dto.EventMemberAdded, identity, response.GetMember().GetId(),
+		"thread_id", req.GetThreadId(),
+		"member_id", response.GetMember().GetId(),
+		"contact_id", target.GetId(),
+	))
+
 	return &gtwthread.AddMemberResponse{Member: &gtwthread.ThreadMember{
 		Id: response.GetMember().GetId(),
 	}}, nil
@@ -202,6 +214,12 @@ func (t *thread) Transfer(ctx context.Context, req *gtwthread.TransferRequest) (
 		return nil, err
 	}
 
+	t.events.Emit(ctx, newDomainEvent(dto.EventMemberTransferred, identity, response.GetMember().GetId(),
+		"thread_id", req.GetThreadId(),
+		"member_id", response.GetMember().GetId(),
+		"contact_id", target.GetId(),
+	))
+
 	return &gtwthread.TransferResponse{Member: &gtwthread.ThreadMember{
 		Id: response.GetMember().GetId(),
 	}}, nil
@@ -223,7 +241,16 @@ func (t *thread) RemoveMember(ctx context.Context, req *gtwthread.RemoveMemberRe
 		TargetMemberId:     req.GetMemberId(),
 		InitiatorContactId: &initiatorContactId,
 	}
-	return t.threadClient.RemoveMember(ctx, removeMemberRequest)
+	if err := t.threadClient.RemoveMember(ctx, removeMemberRequest); err != nil {
+		return err
+	}
+
+	t.events.Emit(ctx, newDomainEvent(dto.EventMemberRemoved, identity, req.GetMemberId(),
+		"thread_id", req.GetThreadId(),
+		"member_id", req.GetMemberId(),
+	))
+
+	return nil
 }
 
 func (t *thread) fetchContact(ctx context.Context, sub, iss string, domainID int32) (*contact.Contact, error) {
@@ -261,11 +288,12 @@ func (t *thread) fetchContacts(ctx context.Context, ids []string, domainID int32
 	return contacts, nil
 }
 
-func NewThread(logger *slog.Logger, threadClient *imthread.ThreadClient, contactClient *imcontact.Client) *thread {
+func NewThread(logger *slog.Logger, threadClient *imthread.ThreadClient, contactClient *imcontact.Client, events EventEmitter) *thread {
 	o := new(thread)
 	o.logger = logger
 	o.threadClient = threadClient
 	o.contactClient = contactClient
+	o.events = events
 	return o
 }
 
@@ -425,6 +453,10 @@ func (t *thread) SetVariables(ctx context.Context, req *gtwthread.SetVariablesRe
 		return nil, err
 	}
 
+	t.events.Emit(ctx, newDomainEvent(dto.EventVariablesSet, identity, req.GetThreadId(),
+		"thread_id", req.GetThreadId(),
+	))
+
 	threadVars, err := t.convertToThreadVariables(ctx, response, int32(identity.GetDomainID()))
 	if err != nil {
 		log.Error("convert to thread variables", "err", err, "thread_id", req.GetThreadId(), "contact_id", identity.GetContactID())
@@ -518,6 +550,10 @@ func (t *thread) FlushVariables(ctx context.Context, req *gtwthread.FlushVariabl
 		return nil, err
 	}
 
+	t.events.Emit(ctx, newDomainEvent(dto.EventVariablesFlushed, identity, req.GetThreadId(),
+		"thread_id", req.GetThreadId(),
+	))
+
 	v, err := t.convertToThreadVariables(ctx, response, int32(identity.GetDomainID()))
 	if err != nil {
 		log.Error("convert to thread variables", "err", err)
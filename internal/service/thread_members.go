@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	threadv1 "github.com/webitel/im-gateway-service/gen/go/thread/v1"
+)
+
+// threadMembersMaxEntries bounds the number of threads whose members are
+// cached; the cache is emptied once it is reached.
+const threadMembersMaxEntries = 10_000
+
+var _ ThreadMembers = (*ThreadMemberCache)(nil)
+
+// ThreadMembers resolves who belongs to a thread right now, so that updates
+// reach the current members rather than whoever the event publisher
+// thought of.
+type ThreadMembers interface {
+	// Members returns the contact IDs of the members of the thread.
+	Members(ctx context.Context, domainID int64, threadID string) ([]string, error)
+	// Forget drops what is known of the thread's members, after they changed.
+	Forget(domainID int64, threadID string)
+}
+
+// threadGetter reads threads from im-thread, see imthread.ThreadClient.
+type threadGetter interface {
+	Get(ctx context.Context, req *threadv1.GetThreadRequest) (*threadv1.Thread, error)
+}
+
+type threadMembersKey struct {
+	domainID int64
+	threadID string
+}
+
+type threadMembersEntry struct {
+	members   []string
+	expiresAt time.Time
+}
+
+// ThreadMemberCache reads thread members from im-thread and keeps them for a
+// short TTL: every update of a busy thread would otherwise cost a lookup.
+type ThreadMemberCache struct {
+	threads threadGetter
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	entries map[threadMembersKey]threadMembersEntry
+}
+
+func NewThreadMemberCache(threads threadGetter, ttl time.Duration) *ThreadMemberCache {
+	return &ThreadMemberCache{
+		threads: threads,
+		ttl:     ttl,
+		entries: make(map[threadMembersKey]threadMembersEntry),
+	}
+}
+
+// Members implements ThreadMembers.
+func (c *ThreadMemberCache) Members(ctx context.Context, domainID int64, threadID string) ([]string, error) {
+	key := threadMembersKey{domainID: domainID, threadID: threadID}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.members, nil
+	}
+
+	thread, err := c.threads.Get(ctx, &threadv1.GetThreadRequest{
+		Id:       threadID,
+		DomainId: int32(domainID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]string, 0, len(thread.GetMembers()))
+	for _, member := range thread.GetMembers() {
+		members = append(members, member.GetContactId())
+	}
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		if len(c.entries) >= threadMembersMaxEntries {
+			clear(c.entries)
+		}
+		c.entries[key] = threadMembersEntry{members: members, expiresAt: time.Now().Add(c.ttl)}
+		c.mu.Unlock()
+	}
+
+	return members, nil
+}
+
+// Forget implements ThreadMembers.
+func (c *ThreadMemberCache) Forget(domainID int64, threadID string) {
+	c.mu.Lock()
+	delete(c.entries, threadMembersKey{domainID: domainID, threadID: threadID})
+	c.mu.Unlock()
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/webitel/im-gateway-service/infra/auth"
 	imcontact "github.com/webitel/im-gateway-service/infra/client/im-contact"
 	permcli "github.com/webitel/im-gateway-service/infra/client/im-thread"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
 	"github.com/webitel/webitel-go-kit/pkg/errors"
 )
 
@@ -21,13 +22,15 @@ type ThreadPermissionService struct {
 	logger        *slog.Logger
 	threadClient  *permcli.ThreadPermissionClient
 	contactClient *imcontact.Client
+	events        EventEmitter
 }
 
-func NewThreadPermissionService(logger *slog.Logger, thread *permcli.ThreadPermissionClient, contactClient *imcontact.Client) *ThreadPermissionService {
+func NewThreadPermissionService(logger *slog.Logger, thread *permcli.ThreadPermissionClient, contactClient *imcontact.Client, events EventEmitter) *ThreadPermissionService {
 	return &ThreadPermissionService{
 		logger:        logger,
 		threadClient:  thread,
 		contactClient: contactClient,
+		events:        events,
 	}
 }
 
@@ -88,6 +91,10 @@ func (s *ThreadPermissionService) Update(ctx context.Context, req *gtwperm.Updat
 		return nil, err
 	}
 
+	s.events.Emit(ctx, newDomainEvent(dto.EventPermissionsUpdated, identity, req.MemberId,
+		"member_id", req.MemberId,
+	))
+
 	convertedPerm := s.convertToThreadPermission(resp)
 
 	return &gtwperm.UpdateThreadPermissionsResponse{
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif" // GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"runtime"
+	"sync"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // WebP decoder
+	"golang.org/x/sync/singleflight"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/infra/auth"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// maxThumbnailSide bounds both dimensions of a requested thumbnail.
+const maxThumbnailSide = 2048
+
+var (
+	ErrInvalidThumbnailSize = errors.InvalidArgument(fmt.Sprintf("thumbnail width and height must be within 0..%d, not both 0", maxThumbnailSide),
+		errors.WithID("service.thumbnail.size"))
+	ErrInvalidThumbnailFit = errors.InvalidArgument("thumbnail fit must be contain, cover or fill",
+		errors.WithID("service.thumbnail.fit"))
+	ErrUnsupportedImage = errors.New("thumbnail: not a JPEG, PNG, GIF or WebP image")
+	ErrImageTooLarge    = errors.New("thumbnail: image exceeds the decode limits")
+)
+
+var _ Thumbnailer = (*ThumbnailService)(nil)
+
+// Thumbnailer renders downscaled previews of image files.
+type Thumbnailer interface {
+	Thumbnail(ctx context.Context, req *dto.ThumbnailRequest) (*dto.Thumbnail, error)
+}
+
+// ThumbnailService decodes images downloaded through Media, in pure Go, and
+// keeps the thumbnails made of them in an in-process LRU cache. Images are
+// checked against the configured size and pixel limits from their header,
+// before anything is decoded.
+type ThumbnailService struct {
+	logger *slog.Logger
+	media  Media
+	cfg    config.MediaConfig
+	cache  *thumbnailCache
+
+	// Concurrent requests for the same thumbnail share one rendering, and at
+	// most one rendering runs per CPU.
+	group   singleflight.Group
+	workers chan struct{}
+}
+
+func NewThumbnailService(logger *slog.Logger, media Media, cfg config.MediaConfig) *ThumbnailService {
+	return &ThumbnailService{
+		logger:  logger,
+		media:   media,
+		cfg:     cfg,
+		cache:   newThumbnailCache(cfg.ThumbnailCacheSize),
+		workers: make(chan struct{}, runtime.GOMAXPROCS(0)),
+	}
+}
+
+// Thumbnail implements Thumbnailer. Only JPEG, PNG, GIF (first frame) and
+// WebP images are supported; images are never scaled up. The thumbnail is a
+// JPEG, or a PNG if it has transparent pixels.
+func (s *ThumbnailService) Thumbnail(ctx context.Context, req *dto.ThumbnailRequest) (*dto.Thumbnail, error) {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return nil, auth.IdentityNotFoundErr
+	}
+
+	if req.Width < 0 || req.Height < 0 || req.Width > maxThumbnailSide || req.Height > maxThumbnailSide ||
+		req.Width == 0 && req.Height == 0 {
+		return nil, ErrInvalidThumbnailSize
+	}
+	fit := req.Fit
+	switch fit {
+	case "":
+		fit = dto.ThumbnailFitContain
+	case dto.ThumbnailFitContain, dto.ThumbnailFitCover, dto.ThumbnailFitFill:
+	default:
+		return nil, ErrInvalidThumbnailFit
+	}
+
+	// File IDs are only meaningful within a domain.
+	key := fmt.Sprintf("%d:%d:%dx%d:%s", identity.GetDomainID(), req.FileID, req.Width, req.Height, fit)
+	if thumb, ok := s.cache.get(key); ok {
+		return thumb, nil
+	}
+
+	v, err, _ := s.group.Do(key, func() (any, error) {
+		select {
+		case s.workers <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-s.workers }()
+
+		thumb, err := s.render(ctx, req.FileID, req.Width, req.Height, fit)
+		if err != nil {
+			return nil, err
+		}
+		s.cache.add(key, thumb)
+
+		return thumb, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*dto.Thumbnail), nil
+}
+
+func (s *ThumbnailService) render(ctx context.Context, fileID int64, width, height int, fit dto.ThumbnailFit) (*dto.Thumbnail, error) {
+	log := s.logger.With(slog.Int64("file_id", fileID))
+
+	result, err := s.media.Download(ctx, &dto.MediaDownloadRequest{FileID: fileID})
+	if err != nil {
+		return nil, err
+	}
+	defer result.Body.Close()
+
+	if result.Metadata.Size > s.cfg.ThumbnailMaxSourceSize {
+		return nil, ErrImageTooLarge
+	}
+	body := io.LimitReader(result.Body, s.cfg.ThumbnailMaxSourceSize)
+
+	// Read the header alone first: a small file may declare a huge image.
+	var header bytes.Buffer
+	imgConfig, format, err := image.DecodeConfig(io.TeeReader(body, &header))
+	if err != nil {
+		log.Debug("thumbnail: unrecognized image", slog.String("error", err.Error()))
+
+		return nil, ErrUnsupportedImage
+	}
+	if imgConfig.Width <= 0 || imgConfig.Height <= 0 {
+		return nil, ErrUnsupportedImage
+	}
+	if int64(imgConfig.Width)*int64(imgConfig.Height) > s.cfg.ThumbnailMaxPixels {
+		log.Debug("thumbnail: image too large to decode",
+			slog.Int("width", imgConfig.Width), slog.Int("height", imgConfig.Height))
+
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(io.MultiReader(&header, body))
+	if err != nil {
+		log.Debug("thumbnail: decode failed", slog.String("format", format), slog.String("error", err.Error()))
+
+		return nil, ErrUnsupportedImage
+	}
+
+	size, crop := thumbnailGeometry(src.Bounds(), width, height, fit)
+	dst := image.NewRGBA(image.Rectangle{Max: size})
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+	thumb := &dto.Thumbnail{Width: size.X, Height: size.Y, SourceHash: result.Metadata.Hash}
+
+	var out bytes.Buffer
+	if dst.Opaque() {
+		thumb.MimeType = "image/jpeg"
+		err = jpeg.Encode(&out, dst, &jpeg.Options{Quality: 85})
+	} else {
+		thumb.MimeType = "image/png"
+		err = png.Encode(&out, dst)
+	}
+	if err != nil {
+		return nil, err
+	}
+	thumb.Data = out.Bytes()
+
+	log.Debug("thumbnail rendered", slog.String("format", format),
+		slog.Int("width", size.X), slog.Int("height", size.Y), slog.Int("bytes", len(thumb.Data)))
+
+	return thumb, nil
+}
+
+// thumbnailGeometry returns the size of the thumbnail of an image with bounds
+// src and the part of the image it shows. width or height may be zero, but
+// not both.
+func thumbnailGeometry(src image.Rectangle, width, height int, fit dto.ThumbnailFit) (image.Point, image.Rectangle) {
+	sw, sh := float64(src.Dx()), float64(src.Dy())
+
+	// The box, a missing side following the aspect ratio of the image.
+	bw, bh := float64(width), float64(height)
+	switch {
+	case width == 0:
+		bw = sw * bh / sh
+		fit = dto.ThumbnailFitFill
+	case height == 0:
+		bh = sh * bw / sw
+		fit = dto.ThumbnailFitFill
+	}
+
+	// Never scale up: a box larger than the image shrinks to it, keeping
+	// its ratio.
+	if fit != dto.ThumbnailFitContain && (bw > sw || bh > sh) {
+		shrink := min(sw/bw, sh/bh)
+		bw, bh = bw*shrink, bh*shrink
+	}
+
+	crop := src
+	switch fit {
+	case dto.ThumbnailFitCover:
+		// Scale to cover the box and crop the overflow around the center.
+		scale := max(bw/sw, bh/sh)
+		cw, ch := bw/scale, bh/scale
+
+		x := src.Min.X + int((sw-cw)/2)
+		y := src.Min.Y + int((sh-ch)/2)
+		crop = image.Rect(x, y, x+int(cw+0.5), y+int(ch+0.5))
+	case dto.ThumbnailFitContain:
+		scale := min(bw/sw, bh/sh, 1)
+		bw, bh = sw*scale, sh*scale
+	}
+
+	return image.Pt(max(int(bw+0.5), 1), max(int(bh+0.5), 1)), crop
+}
+
+// thumbnailCache is an LRU cache of thumbnails bounded by their total size in
+// bytes. A zero size disables it.
+type thumbnailCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type thumbnailCacheEntry struct {
+	key   string
+	thumb *dto.Thumbnail
+}
+
+func newThumbnailCache(maxBytes int64) *thumbnailCache {
+	return &thumbnailCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *thumbnailCache) get(key string) (*dto.Thumbnail, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+
+	return elem.Value.(*thumbnailCacheEntry).thumb, true
+}
+
+func (c *thumbnailCache) add(key string, thumb *dto.Thumbnail) {
+	size := int64(len(thumb.Data))
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&thumbnailCacheEntry{key: key, thumb: thumb})
+	c.bytes += size
+
+	for c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		entry := c.order.Remove(oldest).(*thumbnailCacheEntry)
+		delete(c.entries, entry.key)
+		c.bytes -= int64(len(entry.thumb.Data))
+	}
+}
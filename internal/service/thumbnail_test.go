@@ -0,0 +1,151 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// fakeFiles serves the files it holds through Download and counts downloads.
+type fakeFiles struct {
+	Media
+
+	files     map[int64][]byte
+	downloads int
+}
+
+func (f *fakeFiles) Download(_ context.Context, req *dto.MediaDownloadRequest) (*dto.FileDownloadResult, error) {
+	f.downloads++
+	data := f.files[req.FileID]
+
+	return &dto.FileDownloadResult{
+		Metadata: &dto.FileMetadata{Size: int64(len(data)), Hash: "abc"},
+		Body:     io.NopCloser(bytes.NewReader(data)),
+	}, nil
+}
+
+func encodePNG(t *testing.T, width, height int, fill color.Color) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, fill)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestThumbnailGeometry(t *testing.T) {
+	src := image.Rect(0, 0, 400, 200)
+	for _, tt := range []struct {
+		width, height int
+		fit           dto.ThumbnailFit
+		size          image.Point
+		crop          image.Rectangle
+	}{
+		{100, 100, dto.ThumbnailFitContain, image.Pt(100, 50), src},
+		{100, 0, dto.ThumbnailFitContain, image.Pt(100, 50), src},
+		{0, 50, dto.ThumbnailFitCover, image.Pt(100, 50), src},
+		{100, 100, dto.ThumbnailFitCover, image.Pt(100, 100), image.Rect(100, 0, 300, 200)},
+		{100, 100, dto.ThumbnailFitFill, image.Pt(100, 100), src},
+		// Never scaled up.
+		{800, 800, dto.ThumbnailFitContain, image.Pt(400, 200), src},
+		{800, 800, dto.ThumbnailFitCover, image.Pt(200, 200), image.Rect(100, 0, 300, 200)},
+		{800, 400, dto.ThumbnailFitFill, image.Pt(400, 200), src},
+	} {
+		size, crop := thumbnailGeometry(src, tt.width, tt.height, tt.fit)
+		if size != tt.size || crop != tt.crop {
+			t.Errorf("%dx%d %s = %v %v, want %v %v", tt.width, tt.height, tt.fit, size, crop, tt.size, tt.crop)
+		}
+	}
+}
+
+func TestThumbnailService(t *testing.T) {
+	files := &fakeFiles{files: map[int64][]byte{
+		1: encodePNG(t, 400, 200, color.NRGBA{R: 200, A: 255}),
+		2: encodePNG(t, 40, 40, color.NRGBA{G: 200, A: 100}),
+		3: []byte("%PDF-1.7 not an image"),
+	}}
+	thumbnails := NewThumbnailService(slog.New(slog.DiscardHandler), files, config.MediaConfig{
+		ThumbnailMaxSourceSize: 1 << 20,
+		ThumbnailMaxPixels:     100_000,
+		ThumbnailCacheSize:     1 << 20,
+	})
+	ctx := identityContext(t, 1, "c1")
+
+	thumb, err := thumbnails.Thumbnail(ctx, &dto.ThumbnailRequest{FileID: 1, Width: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(thumb.Data))
+	if err != nil || thumb.MimeType != "image/jpeg" || img.Bounds().Size() != image.Pt(100, 50) {
+		t.Fatalf("thumbnail = %s %v, %v", thumb.MimeType, img, err)
+	}
+
+	if _, err := thumbnails.Thumbnail(ctx, &dto.ThumbnailRequest{FileID: 1, Width: 100}); err != nil || files.downloads != 1 {
+		t.Errorf("second request downloaded the file again: %d downloads, %v", files.downloads, err)
+	}
+	if _, err := thumbnails.Thumbnail(identityContext(t, 2, "c2"), &dto.ThumbnailRequest{FileID: 1, Width: 100}); err != nil || files.downloads != 2 {
+		t.Errorf("another domain was served from the cache: %d downloads, %v", files.downloads, err)
+	}
+
+	if thumb, err = thumbnails.Thumbnail(ctx, &dto.ThumbnailRequest{FileID: 2, Width: 20, Height: 20}); err != nil || thumb.MimeType != "image/png" {
+		t.Errorf("translucent thumbnail = %v, %v, want a PNG", thumb, err)
+	}
+
+	if _, err = thumbnails.Thumbnail(ctx, &dto.ThumbnailRequest{FileID: 3, Width: 20}); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("thumbnail of a PDF = %v", err)
+	}
+
+	// 500x500 is over the pixel limit, whatever the size of the file.
+	files.files[4] = encodePNG(t, 500, 500, color.Black)
+	if _, err = thumbnails.Thumbnail(ctx, &dto.ThumbnailRequest{FileID: 4, Width: 20}); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("thumbnail of a 500x500 image = %v, want ErrImageTooLarge", err)
+	}
+
+	if _, err = thumbnails.Thumbnail(ctx, &dto.ThumbnailRequest{FileID: 1, Fit: "stretch", Width: 10}); !errors.Is(err, ErrInvalidThumbnailFit) {
+		t.Errorf("unknown fit = %v", err)
+	}
+}
+
+func TestThumbnailCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newThumbnailCache(10)
+	thumb := func() *dto.Thumbnail { return &dto.Thumbnail{Data: make([]byte, 4)} }
+
+	cache.add("a", thumb())
+	cache.add("b", thumb())
+	cache.get("a")
+	cache.add("c", thumb())
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+
+	cache.add("big", &dto.Thumbnail{Data: make([]byte, 11)})
+	if _, ok := cache.get("big"); ok {
+		t.Error("a thumbnail over the cache size was cached")
+	}
+}
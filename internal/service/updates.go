@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/infra/auth"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+// replayPruneInterval bounds how often idle replay buffers are looked for.
+const replayPruneInterval = time.Minute
+
+var (
+	ErrSlowConsumer  = errors.New("updates subscriber is too slow, disconnected")
+	ErrUpdaterClosed = errors.New("updates service is shutting down")
+)
+
+var _ Updater = (*UpdateService)(nil)
+
+// Updater fans realtime thread updates out to the subscribers they concern.
+type Updater interface {
+	// Subscribe registers the identity found in ctx. The subscription is
+	// removed when ctx is done or Unsubscribe is called.
+	Subscribe(ctx context.Context, in *dto.SubscribeRequest) (*Subscription, error)
+	// Dispatch delivers u to every subscriber of u.DomainID listed in
+	// u.Recipients. It never blocks.
+	Dispatch(u *dto.Update)
+}
+
+// UpdatePublisher sends updates originating in the gateway through the broker,
+// so the updates consumer of every replica dispatches them to its subscribers.
+type UpdatePublisher interface {
+	Publish(ctx context.Context, u *dto.Update) error
+}
+
+// subscriberKey addresses every subscription of one contact in one domain.
+type subscriberKey struct {
+	domainID  int64
+	contactID string
+}
+
+// Subscription is a single live stream of updates for one identity.
+type Subscription struct {
+	key     subscriberKey
+	updates chan *dto.Update
+	done    chan struct{}
+	err     error
+	once    sync.Once
+	hub     *UpdateService
+}
+
+// Updates returns the channel updates are delivered on. It is closed when
+// the subscription ends; Err then reports why.
+func (s *Subscription) Updates() <-chan *dto.Update {
+	return s.updates
+}
+
+// Done is closed when the subscription ends.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason the subscription ended, or nil while it is active
+// or if it was ended by the subscriber itself.
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.err
+}
+
+// Unsubscribe ends the subscription. It is safe to call more than once.
+func (s *Subscription) Unsubscribe() {
+	s.hub.remove(s, nil)
+}
+
+// replayEntry is an update retained for resuming streams.
+type replayEntry struct {
+	seq    uint64
+	update *dto.Update
+}
+
+// replayBuffer is a fixed-size ring of the latest updates of one identity.
+type replayBuffer struct {
+	entries []replayEntry
+	next    int
+	full    bool
+	// floor is the sequence after which every update of the identity is
+	// still retained: the last one evicted, or the last one dispatched
+	// before the buffer was created.
+	floor uint64
+	// idleSince is set when the identity's last subscription ends; the
+	// buffer is dropped once it has been idle for longer than the replay TTL.
+	idleSince time.Time
+}
+
+func (b *replayBuffer) push(e replayEntry) {
+	if b.full {
+		b.floor = b.entries[b.next].seq
+	}
+	b.entries[b.next] = e
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// after returns the retained updates dispatched after seq, oldest first.
+func (b *replayBuffer) after(seq uint64) []*dto.Update {
+	ordered := b.entries[:b.next]
+	if b.full {
+		ordered = append(slices.Clone(b.entries[b.next:]), b.entries[:b.next]...)
+	}
+
+	var updates []*dto.Update
+	for _, e := range ordered {
+		if e.seq > seq {
+			updates = append(updates, e.update)
+		}
+	}
+
+	return updates
+}
+
+type UpdateService struct {
+	logger     *slog.Logger
+	bufferSize int
+	replaySize int
+	replayTTL  time.Duration
+	// epoch prefixes every cursor, so cursors handed out by a previous
+	// process or another replica never match this one's sequence.
+	epoch string
+
+	mu        sync.Mutex
+	seq       uint64
+	subs      map[subscriberKey][]*Subscription
+	replay    map[subscriberKey]*replayBuffer
+	lastPrune time.Time
+	closed    bool
+}
+
+func NewUpdateService(logger *slog.Logger, bufferSize, replaySize int, replayTTL time.Duration) *UpdateService {
+	return &UpdateService{
+		logger:     logger,
+		bufferSize: bufferSize,
+		replaySize: replaySize,
+		replayTTL:  replayTTL,
+		epoch:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		subs:       make(map[subscriberKey][]*Subscription),
+		replay:     make(map[subscriberKey]*replayBuffer),
+		lastPrune:  time.Now(),
+	}
+}
+
+// Subscribe implements Updater.
+func (u *UpdateService) Subscribe(ctx context.Context, in *dto.SubscribeRequest) (*Subscription, error) {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return nil, auth.IdentityNotFoundErr
+	}
+
+	key := subscriberKey{
+		domainID:  identity.GetDomainID(),
+		contactID: identity.GetContactID(),
+	}
+
+	u.mu.Lock()
+	if u.closed {
+		u.mu.Unlock()
+		return nil, ErrUpdaterClosed
+	}
+	u.pruneReplay()
+
+	// Replayed updates are queued under the same lock that registers the
+	// subscription, so nothing dispatched in between can be lost or reordered.
+	var (
+		replayed []*dto.Update
+		buf      *replayBuffer
+	)
+	if u.replaySize > 0 {
+		if buf = u.replay[key]; buf == nil {
+			buf = &replayBuffer{entries: make([]replayEntry, u.replaySize), floor: u.seq}
+			u.replay[key] = buf
+		}
+		buf.idleSince = time.Time{}
+	}
+	if in.After != "" {
+		// The updates after a cursor can only be replayed if none of them
+		// has been evicted, or dispatched before the buffer existed.
+		if seq, ok := u.parseCursor(in.After); ok && buf != nil && seq >= buf.floor {
+			replayed = buf.after(seq)
+		} else {
+			replayed = []*dto.Update{u.resetUpdate()}
+		}
+	}
+
+	sub := &Subscription{
+		key:     key,
+		updates: make(chan *dto.Update, u.bufferSize+len(replayed)),
+		done:    make(chan struct{}),
+		hub:     u,
+	}
+	for _, update := range replayed {
+		sub.updates <- update
+	}
+	u.subs[key] = append(u.subs[key], sub)
+	u.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			u.remove(sub, nil)
+		case <-sub.done:
+		}
+	}()
+
+	return sub, nil
+}
+
+// Dispatch implements Updater.
+func (u *UpdateService) Dispatch(update *dto.Update) {
+	var slow []*Subscription
+
+	u.mu.Lock()
+	u.pruneReplay()
+	u.seq++
+	update.Cursor = u.formatCursor(u.seq)
+
+	for _, contactID := range update.Recipients {
+		key := subscriberKey{domainID: update.DomainID, contactID: contactID}
+		if buf, ok := u.replay[key]; ok && update.ExpiresAt == 0 {
+			buf.push(replayEntry{seq: u.seq, update: update})
+		}
+
+		for _, sub := range u.subs[key] {
+			select {
+			case sub.updates <- update:
+			default:
+				slow = append(slow, sub)
+			}
+		}
+	}
+	u.mu.Unlock()
+
+	for _, sub := range slow {
+		u.logger.Warn("disconnecting slow updates subscriber",
+			"domain_id", sub.key.domainID,
+			"contact_id", sub.key.contactID,
+			"update_id", update.ID,
+		)
+		u.remove(sub, ErrSlowConsumer)
+	}
+}
+
+// Close ends every subscription with ErrUpdaterClosed and rejects new ones.
+func (u *UpdateService) Close() {
+	u.mu.Lock()
+	u.closed = true
+	all := make([]*Subscription, 0, len(u.subs))
+	for _, subs := range u.subs {
+		all = append(all, subs...)
+	}
+	u.mu.Unlock()
+
+	for _, sub := range all {
+		u.remove(sub, ErrUpdaterClosed)
+	}
+}
+
+// remove detaches sub from the hub and closes its channels. The channels are
+// closed under the lock so Dispatch can never send on a closed channel.
+func (u *UpdateService) remove(sub *Subscription, reason error) {
+	sub.once.Do(func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+
+		subs := slices.DeleteFunc(u.subs[sub.key], func(s *Subscription) bool { return s == sub })
+		if len(subs) == 0 {
+			delete(u.subs, sub.key)
+			if buf, ok := u.replay[sub.key]; ok {
+				buf.idleSince = time.Now()
+			}
+		} else {
+			u.subs[sub.key] = subs
+		}
+
+		sub.err = reason
+		close(sub.updates)
+		close(sub.done)
+	})
+}
+
+// pruneReplay drops replay buffers idle for longer than the replay TTL.
+// Must be called with u.mu held.
+func (u *UpdateService) pruneReplay() {
+	now := time.Now()
+	if now.Sub(u.lastPrune) < replayPruneInterval {
+		return
+	}
+	u.lastPrune = now
+
+	for key, buf := range u.replay {
+		if !buf.idleSince.IsZero() && now.Sub(buf.idleSince) > u.replayTTL {
+			delete(u.replay, key)
+		}
+	}
+}
+
+// resetUpdate tells a resuming subscriber that the updates since its cursor
+// are lost. Must be called with u.mu held.
+func (u *UpdateService) resetUpdate() *dto.Update {
+	return &dto.Update{
+		ID:     uuid.NewString(),
+		Type:   dto.UpdateReset,
+		Date:   time.Now().UnixMilli(),
+		Cursor: u.formatCursor(u.seq),
+	}
+}
+
+func (u *UpdateService) formatCursor(seq uint64) string {
+	return fmt.Sprintf("%s-%d", u.epoch, seq)
+}
+
+// parseCursor returns the sequence of a cursor issued by this hub.
+func (u *UpdateService) parseCursor(cursor string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(cursor, "-")
+	if !ok || epoch != u.epoch {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
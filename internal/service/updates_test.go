@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/webitel/im-gateway-service/infra/auth"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+type testIdentity struct {
+	domainID  int64
+	contactID string
+}
+
+func (i testIdentity) GetContactID() string { return i.contactID }
+func (i testIdentity) GetDomainID() int64   { return i.domainID }
+func (i testIdentity) GetIssuer() string    { return "" }
+func (i testIdentity) GetName() string      { return "" }
+func (i testIdentity) GetVia() string       { return "" }
+func (i testIdentity) GetViaPtr() *string   { return nil }
+
+func identityContext(t *testing.T, domainID int64, contactID string) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return context.WithValue(ctx, auth.AuthContextKey, testIdentity{domainID: domainID, contactID: contactID})
+}
+
+func receive(t *testing.T, sub *Subscription) *dto.Update {
+	t.Helper()
+
+	select {
+	case u, ok := <-sub.Updates():
+		if !ok {
+			t.Fatalf("subscription closed: %v", sub.Err())
+		}
+		return u
+	case <-time.After(time.Second):
+		t.Fatal("no update received")
+	}
+
+	return nil
+}
+
+func TestUpdateService_DispatchFiltersByIdentity(t *testing.T) {
+	hub := NewUpdateService(slog.Default(), 4, 0, time.Minute)
+
+	alice, err := hub.Subscribe(identityContext(t, 1, "alice"), &dto.SubscribeRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherDomain, err := hub.Subscribe(identityContext(t, 2, "alice"), &dto.SubscribeRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hub.Dispatch(&dto.Update{ID: "u1", Type: dto.UpdateNewMessage, DomainID: 1, Recipients: []string{"bob"}})
+	hub.Dispatch(&dto.Update{ID: "u2", Type: dto.UpdateNewMessage, DomainID: 1, Recipients: []string{"alice", "bob"}})
+
+	if got := receive(t, alice); got.ID != "u2" {
+		t.Errorf("alice got %q, want u2", got.ID)
+	}
+	if n := len(otherDomain.Updates()); n != 0 {
+		t.Errorf("subscriber of another domain got %d updates", n)
+	}
+}
+
+func TestUpdateService_SlowConsumerIsDisconnected(t *testing.T) {
+	hub := NewUpdateService(slog.Default(), 1, 0, time.Minute)
+
+	sub, err := hub.Subscribe(identityContext(t, 1, "alice"), &dto.SubscribeRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"u1", "u2"} {
+		hub.Dispatch(&dto.Update{ID: id, DomainID: 1, Recipients: []string{"alice"}})
+	}
+
+	<-sub.Done()
+	if err := sub.Err(); err != ErrSlowConsumer {
+		t.Errorf("Err() = %v, want ErrSlowConsumer", err)
+	}
+}
+
+func TestUpdateService_ReplayAfterCursor(t *testing.T) {
+	hub := NewUpdateService(slog.Default(), 4, 2, time.Minute)
+
+	first, err := hub.Subscribe(identityContext(t, 1, "alice"), &dto.SubscribeRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub.Dispatch(&dto.Update{ID: "u1", DomainID: 1, Recipients: []string{"alice"}})
+	cursor := receive(t, first).Cursor
+	first.Unsubscribe()
+
+	hub.Dispatch(&dto.Update{ID: "u2", DomainID: 1, Recipients: []string{"alice"}})
+
+	resumed, err := hub.Subscribe(identityContext(t, 1, "alice"), &dto.SubscribeRequest{After: cursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, resumed); got.ID != "u2" {
+		t.Errorf("replayed %q, want u2", got.ID)
+	}
+	resumed.Unsubscribe()
+
+	// u2 is evicted from the two-slot buffer by u3 and u4: the stream
+	// cannot be resumed after u1 any more.
+	for _, id := range []string{"u3", "u4"} {
+		hub.Dispatch(&dto.Update{ID: id, DomainID: 1, Recipients: []string{"alice"}})
+	}
+	for _, after := range []string{cursor, "stale-1"} {
+		reset, err := hub.Subscribe(identityContext(t, 1, "alice"), &dto.SubscribeRequest{After: after})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := receive(t, reset)
+		if got.Type != dto.UpdateReset || got.Cursor == "" {
+			t.Errorf("resuming after %q got %+v, want a reset", after, got)
+		}
+		if n := len(reset.Updates()); n != 0 {
+			t.Errorf("resuming after %q replayed %d updates past the reset", after, n)
+		}
+
+		// The reset cursor resumes the stream.
+		reset.Unsubscribe()
+		hub.Dispatch(&dto.Update{ID: "u5", DomainID: 1, Recipients: []string{"alice"}})
+		resumed, err := hub.Subscribe(identityContext(t, 1, "alice"), &dto.SubscribeRequest{After: got.Cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := receive(t, resumed); got.ID != "u5" {
+			t.Errorf("resuming after the reset replayed %q, want u5", got.ID)
+		}
+		resumed.Unsubscribe()
+	}
+}
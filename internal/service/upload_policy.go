@@ -0,0 +1,216 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+)
+
+// ErrUnsupportedMedia is returned, wrapped with the reason, for uploads the
+// upload policy of their domain rejects.
+var ErrUnsupportedMedia = errors.New("upload: content not allowed")
+
+// genericTypes are sniffed for content http.DetectContentType cannot tell
+// apart, such as office documents (zip) or JSON (text): they are not held
+// against the extension of a file.
+var genericTypes = []string{"application/octet-stream", "application/zip", "text/plain", "text/xml"}
+
+// exactTypes are sniffed only for content of that very type, and named only
+// by its own extensions: the content and the extension of a file must agree
+// on them exactly.
+var exactTypes = []string{"application/pdf", "image/gif", "image/jpeg", "image/png", "image/webp"}
+
+// executableTypes are the executables sniffContentType recognizes. They only
+// match executableExtensions, or no extension at all.
+var (
+	executableTypes      = []string{"application/x-msdownload", "application/x-executable", "application/x-mach-binary"}
+	executableExtensions = []string{".exe", ".dll", ".com", ".scr", ".sys", ".so", ".dylib", ".bin", ".elf", ".out"}
+)
+
+// uploadPolicies holds the upload policy of every domain.
+type uploadPolicies struct {
+	fallback *uploadPolicy
+	domains  map[int64]*uploadPolicy
+}
+
+func newUploadPolicies(cfg config.MediaConfig) *uploadPolicies {
+	p := &uploadPolicies{
+		fallback: newUploadPolicy(cfg.UploadPolicy),
+		domains:  make(map[int64]*uploadPolicy, len(cfg.DomainUploadPolicies)),
+	}
+	for name, policy := range cfg.DomainUploadPolicies {
+		// Keys are checked by config.validate.
+		domainID, _ := strconv.ParseInt(name, 10, 64)
+		p.domains[domainID] = newUploadPolicy(policy)
+	}
+
+	return p
+}
+
+func (p *uploadPolicies) forDomain(domainID int64) *uploadPolicy {
+	if policy, ok := p.domains[domainID]; ok {
+		return policy
+	}
+
+	return p.fallback
+}
+
+// uploadPolicy is a config.UploadPolicy with its lists lower-cased.
+type uploadPolicy struct {
+	allowedTypes      []string
+	deniedTypes       []string
+	allowedExtensions []string
+	deniedExtensions  []string
+	matchExtension    bool
+	maxSizes          map[string]int64
+}
+
+func newUploadPolicy(cfg config.UploadPolicy) *uploadPolicy {
+	lower := func(list []string) []string {
+		out := make([]string, 0, len(list))
+		for _, s := range list {
+			if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+				out = append(out, s)
+			}
+		}
+
+		return out
+	}
+
+	maxSizes := make(map[string]int64, len(cfg.MaxSizes))
+	for typ, size := range cfg.MaxSizes {
+		maxSizes[strings.ToLower(typ)] = size
+	}
+
+	return &uploadPolicy{
+		allowedTypes:      lower(cfg.AllowedTypes),
+		deniedTypes:       lower(cfg.DeniedTypes),
+		allowedExtensions: lower(cfg.AllowedExtensions),
+		deniedExtensions:  lower(cfg.DeniedExtensions),
+		matchExtension:    cfg.MatchExtension,
+		maxSizes:          maxSizes,
+	}
+}
+
+// checkName checks the extension of a file name, before any content is read.
+func (p *uploadPolicy) checkName(name string) error {
+	ext := strings.ToLower(path.Ext(name))
+
+	if slices.Contains(p.deniedExtensions, ext) ||
+		len(p.allowedExtensions) > 0 && !slices.Contains(p.allowedExtensions, ext) {
+		return fmt.Errorf("%w: %q files may not be uploaded", ErrUnsupportedMedia, ext)
+	}
+
+	return nil
+}
+
+// checkContent checks the sniffed content type of the file name and returns
+// the max size of the file, zero if unlimited.
+func (p *uploadPolicy) checkContent(name, contentType string) (int64, error) {
+	typ := mediaType(contentType)
+
+	if slices.ContainsFunc(p.deniedTypes, func(pattern string) bool { return matchType(pattern, typ) }) ||
+		len(p.allowedTypes) > 0 && !slices.ContainsFunc(p.allowedTypes, func(pattern string) bool { return matchType(pattern, typ) }) {
+		return 0, fmt.Errorf("%w: %s content may not be uploaded", ErrUnsupportedMedia, typ)
+	}
+
+	if p.matchExtension {
+		if ext := strings.ToLower(path.Ext(name)); !extensionMatches(ext, typ) {
+			return 0, fmt.Errorf("%w: %s content does not match the %q extension", ErrUnsupportedMedia, typ, ext)
+		}
+	}
+
+	return p.maxSize(typ), nil
+}
+
+// maxSize returns the size cap of typ: its own entry, else the entry of its
+// family, else the "*/*" entry. Zero means unlimited.
+func (p *uploadPolicy) maxSize(typ string) int64 {
+	family, _, _ := strings.Cut(typ, "/")
+	for _, key := range []string{typ, family + "/*", "*/*"} {
+		if size, ok := p.maxSizes[key]; ok {
+			return size
+		}
+	}
+
+	return 0
+}
+
+// mediaType returns contentType without its parameters, lower-cased.
+func mediaType(contentType string) string {
+	typ, _, _ := strings.Cut(contentType, ";")
+
+	return strings.ToLower(strings.TrimSpace(typ))
+}
+
+// matchType reports whether typ is pattern, or of the family "family/*" names.
+func matchType(pattern, typ string) bool {
+	if family, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return family == "*" || strings.HasPrefix(typ, family+"/")
+	}
+
+	return pattern == typ
+}
+
+// extensionMatches reports whether content sniffed as typ may have the
+// extension ext. Executables and exactTypes are compared exactly; other types
+// only by family: the sniffer tells few of their subtypes apart, and audio
+// and video share their containers. An extension of no known type matches
+// any content but an executable.
+func extensionMatches(ext, typ string) bool {
+	if slices.Contains(executableTypes, typ) {
+		return ext == "" || slices.Contains(executableExtensions, ext)
+	}
+
+	extType := mediaType(mime.TypeByExtension(ext))
+	switch {
+	case extType == "":
+		return true
+	case slices.Contains(exactTypes, typ), slices.Contains(exactTypes, extType):
+		return extType == typ
+	case slices.Contains(genericTypes, typ):
+		return true
+	default:
+		return contentFamily(extType) == contentFamily(typ)
+	}
+}
+
+func contentFamily(typ string) string {
+	family, _, _ := strings.Cut(typ, "/")
+	switch {
+	case family == "audio", family == "video", typ == "application/ogg":
+		return "av"
+	default:
+		return family
+	}
+}
+
+// sniffContentType detects the type of content from its first bytes like
+// http.DetectContentType, which tells executables from other binary data.
+func sniffContentType(sniff []byte) string {
+	contentType := http.DetectContentType(sniff)
+	if contentType != "application/octet-stream" {
+		return contentType
+	}
+
+	switch {
+	case bytes.HasPrefix(sniff, []byte("MZ")):
+		return "application/x-msdownload"
+	case bytes.HasPrefix(sniff, []byte("\x7fELF")):
+		return "application/x-executable"
+	case bytes.HasPrefix(sniff, []byte{0xfe, 0xed, 0xfa, 0xce}), bytes.HasPrefix(sniff, []byte{0xfe, 0xed, 0xfa, 0xcf}),
+		bytes.HasPrefix(sniff, []byte{0xce, 0xfa, 0xed, 0xfe}), bytes.HasPrefix(sniff, []byte{0xcf, 0xfa, 0xed, 0xfe}):
+		return "application/x-mach-binary"
+	default:
+		return contentType
+	}
+}
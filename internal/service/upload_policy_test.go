@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+)
+
+func TestUploadPolicy(t *testing.T) {
+	policies := newUploadPolicies(config.MediaConfig{
+		UploadPolicy: config.UploadPolicy{
+			DeniedTypes:      []string{"text/html", "application/x-msdownload"},
+			DeniedExtensions: []string{".exe", ".HTML"},
+			MatchExtension:   true,
+			MaxSizes:         map[string]int64{"image/*": 100, "image/gif": 10, "*/*": 1000},
+		},
+		DomainUploadPolicies: map[string]config.UploadPolicy{
+			"2": {AllowedTypes: []string{"image/*"}, AllowedExtensions: []string{".png"}},
+		},
+	})
+	policy := policies.forDomain(1)
+
+	for _, name := range []string{"setup.exe", "Page.html"} {
+		if err := policy.checkName(name); !errors.Is(err, ErrUnsupportedMedia) {
+			t.Errorf("name %s = %v, want ErrUnsupportedMedia", name, err)
+		}
+	}
+	if err := policy.checkName("photo.png"); err != nil {
+		t.Errorf("name photo.png = %v", err)
+	}
+
+	for _, tt := range []struct {
+		name, content string
+		maxSize       int64
+		ok            bool
+	}{
+		{"photo.png", "\x89PNG\r\n\x1a\n", 100, true},
+		{"anim.gif", "GIF89a", 10, true},
+		{"report.pdf", "%PDF-1.7", 1000, true},
+		{"data.json", `{"a": 1}`, 1000, true},
+		{"song.ogg", "OggS\x00", 1000, true},
+		{"notes.txt", "<!DOCTYPE html><html></html>", 0, false},
+		{"photo.png", "<html><script></script></html>", 0, false},
+		{"photo.jpg", "%PDF-1.7", 0, false},
+		{"tool.bin", "MZ\x90\x00\x03\x00\x00\x00", 0, false},
+		{"photo.png", "\xff\xd8\xff\xe0", 0, false},
+		{"report.pdf", "\x7fELF\x02\x01\x01", 0, false},
+		{"doc.docx", "\x7fELF\x02\x01\x01", 0, false},
+		{"report.pdf", "\xcf\xfa\xed\xfe", 0, false},
+		{"report.pdf", "plain text", 0, false},
+		{"doc.docx", "PK\x03\x04", 1000, true},
+		{"tool.so", "\x7fELF\x02\x01\x01", 1000, true},
+		{"tool", "\x7fELF\x02\x01\x01", 1000, true},
+	} {
+		maxSize, err := policy.checkContent(tt.name, sniffContentType([]byte(tt.content)))
+		if tt.ok && (err != nil || maxSize != tt.maxSize) {
+			t.Errorf("%s = %d, %v, want %d", tt.name, maxSize, err, tt.maxSize)
+		}
+		if !tt.ok && !errors.Is(err, ErrUnsupportedMedia) {
+			t.Errorf("%s = %v, want ErrUnsupportedMedia", tt.name, err)
+		}
+	}
+
+	// The policy of domain 2 replaces the default one.
+	policy = policies.forDomain(2)
+	if err := policy.checkName("setup.exe"); !errors.Is(err, ErrUnsupportedMedia) {
+		t.Errorf("domain 2: setup.exe = %v", err)
+	}
+	if err := policy.checkName("anim.gif"); !errors.Is(err, ErrUnsupportedMedia) {
+		t.Errorf("domain 2: anim.gif = %v, want ErrUnsupportedMedia", err)
+	}
+	if _, err := policy.checkContent("report.png", sniffContentType([]byte("%PDF-1.7"))); !errors.Is(err, ErrUnsupportedMedia) {
+		t.Errorf("domain 2: PDF = %v, want ErrUnsupportedMedia", err)
+	}
+	if maxSize, err := policy.checkContent("photo.png", sniffContentType([]byte("\x89PNG\r\n\x1a\n"))); err != nil || maxSize != 0 {
+		t.Errorf("domain 2: PNG = %d, %v", maxSize, err)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/webitel/webitel-go-kit/pkg/errors"
 
 	"github.com/webitel/im-gateway-service/gen/go/contact/v1"
+	"github.com/webitel/im-gateway-service/infra/auth"
 	imcontact "github.com/webitel/im-gateway-service/infra/client/im-contact"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
 )
 
 type Via interface {
@@ -20,10 +22,11 @@ type Via interface {
 type via struct {
 	viaClient *imcontact.ViaClient
 	logger    *slog.Logger
+	events    EventEmitter
 }
 
-func newVia(logger *slog.Logger, viaClient *imcontact.ViaClient) *via {
-	return &via{logger: logger.With("component", "via"), viaClient: viaClient}
+func newVia(logger *slog.Logger, viaClient *imcontact.ViaClient, events EventEmitter) *via {
+	return &via{logger: logger.With("component", "via"), viaClient: viaClient, events: events}
 }
 
 func (via *via) Search(ctx context.Context, req *contact.SearchViaRequest) (*contact.SearchViaResponse, error) {
@@ -49,6 +52,8 @@ func (via *via) Update(ctx context.Context, req *contact.UpdateViaRequest) (*con
 		return nil, errors.Wrap(err, errors.WithID("service.via.update"))
 	}
 
+	via.emit(ctx, dto.EventViaUpdated, response)
+
 	return response, nil
 }
 
@@ -62,6 +67,8 @@ func (via *via) Create(ctx context.Context, req *contact.CreateViaRequest) (*con
 		return nil, errors.Wrap(err, errors.WithID("service.via.create"))
 	}
 
+	via.emit(ctx, dto.EventViaCreated, response)
+
 	return response, nil
 }
 
@@ -75,5 +82,19 @@ func (via *via) PartialUpdate(ctx context.Context, req *contact.PartialUpdateVia
 		return nil, errors.Wrap(err, errors.WithID("service.via.partial_update"))
 	}
 
+	via.emit(ctx, dto.EventViaUpdated, response)
+
 	return response, nil
 }
+
+// emit announces a change of v made by the identity found in ctx.
+func (via *via) emit(ctx context.Context, eventType dto.EventType, v *contact.Via) {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	via.events.Emit(ctx, newDomainEvent(eventType, identity, v.GetVia(),
+		"contact_id", v.GetContactId(),
+	))
+}
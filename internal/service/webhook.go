@@ -0,0 +1,587 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/webitel-go-kit/pkg/errors"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/infra/auth"
+	imcontact "github.com/webitel/im-gateway-service/infra/client/im-contact"
+	"github.com/webitel/im-gateway-service/internal/model"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+const (
+	// WebhookSignatureHeader carries "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+	WebhookSignatureHeader = "X-Webitel-Signature"
+	// WebhookTimestampHeader carries the unix time the delivery was signed at.
+	WebhookTimestampHeader = "X-Webitel-Timestamp"
+	// WebhookDeliveryHeader carries the delivery id, stable across retries.
+	WebhookDeliveryHeader = "X-Webitel-Delivery"
+
+	minWebhookSecretLen     = 16
+	defaultDeliveriesSize   = 20
+	webhookResponseDrainMax = 64 << 10
+
+	// webhookCacheTTL is how long the webhook of a bot, or its absence, is
+	// remembered. Changes made on another replica take up to this long to
+	// be seen here.
+	webhookCacheTTL = 30 * time.Second
+	// webhookCacheMaxEntries bounds the number of bots whose webhooks are
+	// cached; the cache is emptied once it is reached.
+	webhookCacheMaxEntries = 10_000
+)
+
+var (
+	ErrWebhookNotFound = errors.NotFound("webhook is not registered for the bot")
+	ErrWebhookURL      = errors.InvalidArgument("webhook url must be an absolute https url")
+	ErrWebhookSecret   = errors.InvalidArgument(fmt.Sprintf("webhook secret must be at least %d characters", minWebhookSecretLen))
+
+	errWebhookStopped = errors.New("webhook delivery interrupted by shutdown")
+)
+
+var _ Webhooker = (*WebhookService)(nil)
+
+// Webhooker registers bot webhooks and delivers messages addressed to bots.
+type Webhooker interface {
+	SetWebhook(ctx context.Context, in *dto.SetWebhookRequest) (*dto.Webhook, error)
+	DeleteWebhook(ctx context.Context, in *dto.DeleteWebhookRequest) error
+	ListDeliveries(ctx context.Context, in *dto.ListDeliveriesRequest) ([]*dto.Delivery, error)
+	// Deliver posts u to every recipient bot with a webhook. It returns once
+	// every delivery succeeded or was dead-lettered.
+	Deliver(ctx context.Context, u *dto.Update) error
+}
+
+// webhookPayload is the JSON body posted to a bot webhook.
+type webhookPayload struct {
+	ID       string             `json:"id"`
+	Type     dto.UpdateType     `json:"type"`
+	BotID    string             `json:"botId"`
+	ThreadID string             `json:"threadId"`
+	Date     int64              `json:"date"`
+	Message  *dto.UpdateMessage `json:"message"`
+}
+
+type webhookJob struct {
+	id        string
+	domainID  int64
+	botID     string
+	update    *dto.Update
+	hook      *dto.Webhook
+	payload   []byte
+	createdAt time.Time
+}
+
+type webhookCacheKey struct {
+	domainID int64
+	botID    string
+}
+
+// cachedWebhook is a webhook read from Redis; hook is nil for a bot without
+// one.
+type cachedWebhook struct {
+	hook      *dto.Webhook
+	expiresAt time.Time
+}
+
+type WebhookService struct {
+	logger        *slog.Logger
+	contactClient *imcontact.Client
+	redis         *redis.Client
+	httpClient    *http.Client
+	cfg           config.WebhooksConfig
+
+	cacheMu sync.Mutex
+	cache   map[webhookCacheKey]cachedWebhook
+
+	// mu guards stopped, so that no delivery starts once Stop waits for the
+	// running ones.
+	mu       sync.Mutex
+	stopped  bool
+	stop     chan struct{}
+	inflight sync.WaitGroup
+}
+
+func NewWebhookService(
+	logger *slog.Logger,
+	contactClient *imcontact.Client,
+	redisClient *redis.Client,
+	cfg config.WebhooksConfig,
+) *WebhookService {
+	return &WebhookService{
+		logger:        logger,
+		contactClient: contactClient,
+		redis:         redisClient,
+		httpClient:    newWebhookHTTPClient(cfg),
+		cfg:           cfg,
+		cache:         make(map[webhookCacheKey]cachedWebhook),
+		stop:          make(chan struct{}),
+	}
+}
+
+// Stop interrupts pending retries and waits for the running deliveries to
+// return. The messages cut short are redelivered, to this replica after a
+// restart or to another one.
+func (s *WebhookService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetWebhook implements Webhooker.
+func (s *WebhookService) SetWebhook(ctx context.Context, in *dto.SetWebhookRequest) (*dto.Webhook, error) {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return nil, auth.IdentityNotFoundErr
+	}
+
+	u, err := url.Parse(in.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return nil, ErrWebhookURL
+	}
+
+	secret := in.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := cryptorand.Read(buf); err != nil {
+			return nil, errors.Internal("generate webhook secret", errors.WithCause(err))
+		}
+		secret = hex.EncodeToString(buf)
+	}
+	if len(secret) < minWebhookSecretLen {
+		return nil, ErrWebhookSecret
+	}
+
+	if err := authorizeBot(ctx, s.redis, identity, in.BotID); err != nil {
+		return nil, err
+	}
+	bot, err := findBot(ctx, s.contactClient, identity.GetDomainID(), in.BotID)
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &dto.Webhook{
+		BotID:     bot.GetId(),
+		URL:       u.String(),
+		Secret:    secret,
+		CreatedAt: time.Now().UnixMilli(),
+	}
+
+	if err := s.redis.HSet(ctx, webhookKey(identity.GetDomainID(), hook.BotID),
+		"url", hook.URL,
+		"secret", hook.Secret,
+		"created_at", hook.CreatedAt,
+	).Err(); err != nil {
+		return nil, err
+	}
+	s.forgetWebhook(identity.GetDomainID(), hook.BotID)
+
+	return hook, nil
+}
+
+// DeleteWebhook implements Webhooker. Recorded deliveries are kept.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, in *dto.DeleteWebhookRequest) error {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return auth.IdentityNotFoundErr
+	}
+
+	if err := authorizeBot(ctx, s.redis, identity, in.BotID); err != nil {
+		return err
+	}
+	if _, err := findBot(ctx, s.contactClient, identity.GetDomainID(), in.BotID); err != nil {
+		return err
+	}
+
+	n, err := s.redis.Del(ctx, webhookKey(identity.GetDomainID(), in.BotID)).Result()
+	if err != nil {
+		return err
+	}
+	s.forgetWebhook(identity.GetDomainID(), in.BotID)
+	if n == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// ListDeliveries implements Webhooker.
+func (s *WebhookService) ListDeliveries(ctx context.Context, in *dto.ListDeliveriesRequest) ([]*dto.Delivery, error) {
+	identity, ok := auth.GetIdentityFromContext(ctx)
+	if !ok {
+		return nil, auth.IdentityNotFoundErr
+	}
+
+	if err := authorizeBot(ctx, s.redis, identity, in.BotID); err != nil {
+		return nil, err
+	}
+	if _, err := findBot(ctx, s.contactClient, identity.GetDomainID(), in.BotID); err != nil {
+		return nil, err
+	}
+
+	size := in.Size
+	if size <= 0 {
+		size = defaultDeliveriesSize
+	}
+	size = min(size, s.cfg.DeadLetterSize)
+
+	raw, err := s.redis.LRange(ctx, deliveriesKey(identity.GetDomainID(), in.BotID), 0, size-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*dto.Delivery, 0, len(raw))
+	for _, item := range raw {
+		var d dto.Delivery
+		if err := json.Unmarshal([]byte(item), &d); err != nil {
+			s.logger.Warn("skip malformed webhook delivery record", "bot_id", in.BotID, "err", err)
+			continue
+		}
+		deliveries = append(deliveries, &d)
+	}
+
+	return deliveries, nil
+}
+
+// Deliver implements Webhooker. The deliveries of u run concurrently and are
+// retried with backoff: the message u came with is acked only once each of
+// them succeeded or was dead-lettered. An error, for which the message is
+// redelivered, means the webhooks could not be read, a dead letter could not
+// be stored or the service is stopping.
+func (s *WebhookService) Deliver(ctx context.Context, u *dto.Update) error {
+	if u.Type != dto.UpdateNewMessage || u.Message == nil {
+		return nil
+	}
+
+	var jobs []*webhookJob
+	for _, botID := range u.Recipients {
+		// Bots must not be woken up by their own messages.
+		if botID == u.Message.SenderID {
+			continue
+		}
+
+		hook, err := s.loadWebhook(ctx, u.DomainID, botID)
+		if err != nil {
+			return err
+		}
+		if hook == nil {
+			continue
+		}
+
+		payload, err := json.Marshal(&webhookPayload{
+			ID:       u.ID,
+			Type:     u.Type,
+			BotID:    botID,
+			ThreadID: u.ThreadID,
+			Date:     u.Date,
+			Message:  u.Message,
+		})
+		if err != nil {
+			return err
+		}
+
+		jobs = append(jobs, &webhookJob{
+			// Derived from the update, so that a redelivered message is
+			// posted under the same id and receivers can deduplicate it.
+			id:        uuid.NewSHA1(uuid.NameSpaceURL, []byte(u.ID+"/"+botID)).String(),
+			domainID:  u.DomainID,
+			botID:     botID,
+			update:    u,
+			hook:      hook,
+			payload:   payload,
+			createdAt: time.Now(),
+		})
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+
+		return errWebhookStopped
+	}
+	s.inflight.Add(1)
+	s.mu.Unlock()
+	defer s.inflight.Done()
+
+	errs := make([]error, len(jobs))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.deliver(job)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadWebhook returns the webhook of the bot, nil if it has none.
+func (s *WebhookService) loadWebhook(ctx context.Context, domainID int64, botID string) (*dto.Webhook, error) {
+	key := webhookCacheKey{domainID: domainID, botID: botID}
+
+	s.cacheMu.Lock()
+	cached, ok := s.cache[key]
+	s.cacheMu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.hook, nil
+	}
+
+	fields, err := s.redis.HGetAll(ctx, webhookKey(domainID, botID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var hook *dto.Webhook
+	if fields["url"] != "" {
+		createdAt, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+		hook = &dto.Webhook{
+			BotID:     botID,
+			URL:       fields["url"],
+			Secret:    fields["secret"],
+			CreatedAt: createdAt,
+		}
+	}
+
+	s.cacheMu.Lock()
+	if len(s.cache) >= webhookCacheMaxEntries {
+		clear(s.cache)
+	}
+	s.cache[key] = cachedWebhook{hook: hook, expiresAt: time.Now().Add(webhookCacheTTL)}
+	s.cacheMu.Unlock()
+
+	return hook, nil
+}
+
+func (s *WebhookService) forgetWebhook(domainID int64, botID string) {
+	s.cacheMu.Lock()
+	delete(s.cache, webhookCacheKey{domainID: domainID, botID: botID})
+	s.cacheMu.Unlock()
+}
+
+// deliver posts job until it succeeds, fails permanently or runs out of
+// attempts, backing off exponentially between tries. Failed jobs are
+// dead-lettered; a job interrupted by Stop is not, and errWebhookStopped is
+// returned instead.
+func (s *WebhookService) deliver(job *webhookJob) error {
+	var (
+		statusCode int
+		err        error
+		attempt    int
+	)
+
+	for attempt = 1; ; attempt++ {
+		var retry bool
+		statusCode, retry, err = s.post(job)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.cfg.MaxAttempts {
+			break
+		}
+
+		timer := time.NewTimer(s.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-s.stop:
+			timer.Stop()
+
+			return errWebhookStopped
+		}
+	}
+
+	s.logger.Warn("webhook delivery failed",
+		"delivery_id", job.id,
+		"bot_id", job.botID,
+		"update_id", job.update.ID,
+		"attempts", attempt,
+		"status", statusCode,
+		"err", err,
+	)
+
+	return s.deadLetter(job, attempt, statusCode, err)
+}
+
+// post makes a single delivery attempt and reports whether a failure is
+// worth retrying: network errors, 429 and 5xx are, other statuses are not.
+func (s *WebhookService) post(job *webhookJob) (int, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.hook.URL, bytes.NewReader(job.payload))
+	if err != nil {
+		return 0, false, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", model.ServiceName)
+	req.Header.Set(WebhookDeliveryHeader, job.id)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(job.hook.Secret, timestamp, job.payload))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookResponseDrainMax))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp.StatusCode, false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return resp.StatusCode, true, fmt.Errorf("webhook responded %s", resp.Status)
+	default:
+		return resp.StatusCode, false, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+}
+
+// backoff returns the delay after the given attempt: the initial backoff
+// doubled per attempt, capped, with jitter so retries of many deliveries
+// do not line up.
+func (s *WebhookService) backoff(attempt int) time.Duration {
+	d := s.cfg.InitialBackoff << (attempt - 1)
+	if d <= 0 || d > s.cfg.MaxBackoff {
+		d = s.cfg.MaxBackoff
+	}
+
+	return d/2 + rand.N(d/2+1)
+}
+
+func (s *WebhookService) deadLetter(job *webhookJob, attempts, statusCode int, cause error) error {
+	record, err := json.Marshal(&dto.Delivery{
+		ID:         job.id,
+		BotID:      job.botID,
+		UpdateID:   job.update.ID,
+		ThreadID:   job.update.ThreadID,
+		URL:        job.hook.URL,
+		Attempts:   attempts,
+		StatusCode: statusCode,
+		Error:      cause.Error(),
+		CreatedAt:  job.createdAt.UnixMilli(),
+		FailedAt:   time.Now().UnixMilli(),
+		Payload:    string(job.payload),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal webhook dead letter %s: %w", job.id, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	key := deliveriesKey(job.domainID, job.botID)
+	_, err = s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, key, record)
+		pipe.LTrim(ctx, key, 0, s.cfg.DeadLetterSize-1)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("store webhook dead letter %s: %w", job.id, err)
+	}
+
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of timestamp + "." + payload, which
+// receivers recompute to verify a delivery.
+func SignWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookKey(domainID int64, botID string) string {
+	return fmt.Sprintf("%s:bot:%d:%s:webhook", model.ServiceName, domainID, botID)
+}
+
+func deliveriesKey(domainID int64, botID string) string {
+	return fmt.Sprintf("%s:bot:%d:%s:deliveries", model.ServiceName, domainID, botID)
+}
+
+// newWebhookHTTPClient builds the client deliveries are posted with. It does
+// not follow redirects and, unless allowed, refuses to connect to loopback,
+// private and link-local addresses so a webhook URL cannot reach into the
+// cluster network.
+func newWebhookHTTPClient(cfg config.WebhooksConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+				return fmt.Errorf("webhook address %s is not allowed", address)
+			}
+
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: cfg.Timeout,
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
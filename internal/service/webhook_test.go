@@ -0,0 +1,225 @@
+package service
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/webitel/im-gateway-service/config"
+	"github.com/webitel/im-gateway-service/internal/service/dto"
+)
+
+func newTestWebhookService(t *testing.T, cfg config.WebhooksConfig) (*WebhookService, *redis.Client) {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewWebhookService(slog.New(slog.DiscardHandler), nil, client, cfg), client
+}
+
+func testWebhooksConfig() config.WebhooksConfig {
+	return config.WebhooksConfig{
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		DeadLetterSize: 10,
+		// httptest servers listen on loopback.
+		AllowPrivate: true,
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	got := SignWebhook("0123456789abcdef", "1700000000", []byte(`{"id":"u1"}`))
+	if want := "fd06f38f5836efe2eef9aaad0b6581969686b85836f011afade54630b728a410"; got != want {
+		t.Errorf("SignWebhook = %s, want %s", got, want)
+	}
+}
+
+func TestWebhookService_PostClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantErr   bool
+		wantRetry bool
+	}{
+		{status: http.StatusNoContent},
+		{status: http.StatusTooManyRequests, wantErr: true, wantRetry: true},
+		{status: http.StatusBadGateway, wantErr: true, wantRetry: true},
+		{status: http.StatusBadRequest, wantErr: true},
+		{status: http.StatusMovedPermanently, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var signature string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				signature = r.Header.Get(WebhookSignatureHeader)
+				w.Header().Set("Location", "http://169.254.169.254/")
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(server.Close)
+
+			s, _ := newTestWebhookService(t, testWebhooksConfig())
+			job := &webhookJob{
+				id:      "d1",
+				update:  &dto.Update{ID: "u1"},
+				hook:    &dto.Webhook{URL: server.URL, Secret: "0123456789abcdef"},
+				payload: []byte(`{}`),
+			}
+
+			status, retry, err := s.post(job)
+			if status != tt.status || retry != tt.wantRetry || (err != nil) != tt.wantErr {
+				t.Errorf("post = %d, retry %v, %v", status, retry, err)
+			}
+			if !strings.HasPrefix(signature, "sha256=") {
+				t.Errorf("signature header = %q", signature)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Backoff(t *testing.T) {
+	s, _ := newTestWebhookService(t, config.WebhooksConfig{
+		Timeout:        time.Second,
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+	})
+
+	for attempt, base := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 70: 10 * time.Second} {
+		for range 20 {
+			if d := s.backoff(attempt); d < base/2 || d > base {
+				t.Errorf("backoff(%d) = %v, want within [%v, %v]", attempt, d, base/2, base)
+			}
+		}
+	}
+}
+
+func TestWebhookService_RefusesPrivateAddresses(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := testWebhooksConfig()
+	cfg.AllowPrivate = false
+	s, _ := newTestWebhookService(t, cfg)
+
+	_, _, err := s.post(&webhookJob{hook: &dto.Webhook{URL: server.URL}, update: &dto.Update{}})
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("post to a loopback webhook = %v, want it refused", err)
+	}
+	if hits.Load() != 0 {
+		t.Error("the loopback webhook was reached")
+	}
+}
+
+func TestWebhookService_DeliverReturnsOnceDelivered(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// The first attempt of every delivery fails.
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(server.Close)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	t.Cleanup(failing.Close)
+
+	s, client := newTestWebhookService(t, testWebhooksConfig())
+	ctx := t.Context()
+	client.HSet(ctx, webhookKey(1, "bot1"), "url", server.URL, "secret", "0123456789abcdef")
+	client.HSet(ctx, webhookKey(1, "bot2"), "url", failing.URL, "secret", "0123456789abcdef")
+
+	update := &dto.Update{
+		ID:         "u1",
+		Type:       dto.UpdateNewMessage,
+		DomainID:   1,
+		Recipients: []string{"bot1", "bot2", "user"},
+		Message:    &dto.UpdateMessage{ID: "m1", SenderID: "user"},
+	}
+	if err := s.Deliver(ctx, update); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both deliveries are settled when Deliver returns: one retried until it
+	// succeeded, the other dead-lettered.
+	if n := hits.Load(); n != 2 {
+		t.Errorf("the retried webhook was posted %d times, want 2", n)
+	}
+	records, _ := client.LRange(ctx, deliveriesKey(1, "bot2"), 0, -1).Result()
+	if len(records) != 1 {
+		t.Fatalf("dead letters of the failing webhook = %d, want 1", len(records))
+	}
+	var delivery dto.Delivery
+	if err := json.Unmarshal([]byte(records[0]), &delivery); err != nil || delivery.StatusCode != http.StatusGone {
+		t.Errorf("dead letter = %+v, %v", delivery, err)
+	}
+	if n, _ := client.LLen(ctx, deliveriesKey(1, "bot1")).Result(); n != 0 {
+		t.Errorf("the delivered webhook has %d dead letters", n)
+	}
+
+	// The webhooks are cached: a removed one is still posted to until the
+	// cache expires on this replica.
+	client.Del(ctx, webhookKey(1, "bot1"))
+	if err := s.Deliver(ctx, update); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("the cached webhook was posted %d times in total, want 3", n)
+	}
+}
+
+func TestAuthorizeBot(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+	ctx := identityContext(t, 1, "owner")
+
+	client.Set(ctx, botOwnerKey(1, "bot1"), "owner", 0)
+
+	tests := []struct {
+		name    string
+		domain  int64
+		contact string
+		botID   string
+		wantErr error
+	}{
+		{name: "owner", domain: 1, contact: "owner", botID: "bot1"},
+		{name: "bot itself", domain: 1, contact: "bot1", botID: "bot1"},
+		{name: "other contact", domain: 1, contact: "other", botID: "bot1", wantErr: ErrNotBotOwner},
+		{name: "owner in another domain", domain: 2, contact: "owner", botID: "bot1", wantErr: ErrNotBotOwner},
+		{name: "bot without owner", domain: 1, contact: "owner", botID: "bot2", wantErr: ErrNotBotOwner},
+	}
+	for _, tt := range tests {
+		err := authorizeBot(ctx, client, testIdentity{domainID: tt.domain, contactID: tt.contact}, tt.botID)
+		if err != tt.wantErr {
+			t.Errorf("%s: authorizeBot = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWebhookService_RejectsOtherContacts(t *testing.T) {
+	s, client := newTestWebhookService(t, testWebhooksConfig())
+	ctx := identityContext(t, 1, "other")
+	client.Set(ctx, botOwnerKey(1, "bot1"), "owner", 0)
+
+	if _, err := s.SetWebhook(ctx, &dto.SetWebhookRequest{BotID: "bot1", URL: "https://example.com/hook"}); err != ErrNotBotOwner {
+		t.Errorf("SetWebhook = %v, want ErrNotBotOwner", err)
+	}
+	if err := s.DeleteWebhook(ctx, &dto.DeleteWebhookRequest{BotID: "bot1"}); err != ErrNotBotOwner {
+		t.Errorf("DeleteWebhook = %v, want ErrNotBotOwner", err)
+	}
+	if _, err := s.ListDeliveries(ctx, &dto.ListDeliveriesRequest{BotID: "bot1"}); err != ErrNotBotOwner {
+		t.Errorf("ListDeliveries = %v, want ErrNotBotOwner", err)
+	}
+}